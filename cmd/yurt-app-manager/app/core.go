@@ -31,6 +31,7 @@ import (
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook"
+	webhookutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -141,14 +142,17 @@ func Run(opts *options.YurtAppOptions) {
 
 	setupLog.Info("setup controllers")
 
-	ctx := genOptCtx(opts.CreateDefaultPool)
+	ctx := genOptCtx(opts.CreateDefaultPool, opts.NodePoolTopologyKey, opts.NodePoolLeaseNamespace)
 	if err = controller.SetupWithManager(mgr, ctx); err != nil {
 		setupLog.Error(err, "unable to setup controllers")
 		os.Exit(1)
 	}
 
 	setupLog.Info("setup webhook")
-	if err = webhook.SetupWithManager(mgr); err != nil {
+	if err = webhook.SetupWithManager(mgr, webhookutil.Options{
+		NodePoolChangeProtection: opts.NodePoolChangeProtection,
+		DefaultNodePoolRules:     opts.DefaultNodePoolRules,
+	}); err != nil {
 		setupLog.Error(err, "unable to setup webhook")
 		os.Exit(1)
 	}
@@ -175,9 +179,14 @@ func Run(opts *options.YurtAppOptions) {
 
 }
 
-func genOptCtx(createDefaultPool bool) context.Context {
-	return context.WithValue(context.Background(),
+func genOptCtx(createDefaultPool bool, nodePoolTopologyKey,
+	nodePoolLeaseNamespace string) context.Context {
+	ctx := context.WithValue(context.Background(),
 		constant.ContextKeyCreateDefaultPool, createDefaultPool)
+	ctx = context.WithValue(ctx,
+		constant.ContextKeyNodePoolTopologyKey, nodePoolTopologyKey)
+	return context.WithValue(ctx,
+		constant.ContextKeyNodePoolLeaseNamespace, nodePoolLeaseNamespace)
 }
 
 func setRestConfig(c *rest.Config) {
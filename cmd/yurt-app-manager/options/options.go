@@ -17,20 +17,31 @@ limitations under the License.
 package options
 
 import (
+	"fmt"
+	"strings"
+
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	nodemutating "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/node/mutating"
+	webhookutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/util"
 )
 
 // YurtAppOptions is the main settings for the yurtapp-manger
 type YurtAppOptions struct {
-	MetricsAddr             string
-	PprofAddr               string
-	HealthProbeAddr         string
-	EnableLeaderElection    bool
-	EnablePprof             bool
-	LeaderElectionNamespace string
-	Namespace               string
-	CreateDefaultPool       bool
-	Version                 bool
+	MetricsAddr              string
+	PprofAddr                string
+	HealthProbeAddr          string
+	EnableLeaderElection     bool
+	EnablePprof              bool
+	LeaderElectionNamespace  string
+	Namespace                string
+	CreateDefaultPool        bool
+	NodePoolTopologyKey      string
+	NodePoolLeaseNamespace   string
+	NodePoolChangeProtection bool
+	DefaultNodePoolRules     []string
+	Version                  bool
 }
 
 // NewYurtAppOptions creates a new YurtAppOptions with a default config.
@@ -44,6 +55,8 @@ func NewYurtAppOptions() *YurtAppOptions {
 		LeaderElectionNamespace: "kube-system",
 		Namespace:               "",
 		CreateDefaultPool:       false,
+		NodePoolTopologyKey:     "",
+		NodePoolLeaseNamespace:  webhookutil.GetNamespace(),
 	}
 
 	return o
@@ -51,7 +64,21 @@ func NewYurtAppOptions() *YurtAppOptions {
 
 // ValidateOptions validates YurtAppOptions
 func ValidateOptions(options *YurtAppOptions) error {
-	// TODO
+	if options.NodePoolTopologyKey != "" {
+		if errs := validation.IsQualifiedName(options.NodePoolTopologyKey); len(errs) != 0 {
+			return fmt.Errorf("invalid nodepool topology key %q: %s",
+				options.NodePoolTopologyKey, strings.Join(errs, ", "))
+		}
+	}
+	if options.NodePoolLeaseNamespace != "" {
+		if errs := validation.IsDNS1123Label(options.NodePoolLeaseNamespace); len(errs) != 0 {
+			return fmt.Errorf("invalid nodepool lease namespace %q: %s",
+				options.NodePoolLeaseNamespace, strings.Join(errs, ", "))
+		}
+	}
+	if _, err := nodemutating.ParseDefaultNodePoolRules(options.DefaultNodePoolRules); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -65,5 +92,9 @@ func (o *YurtAppOptions) AddFlags(fs *pflag.FlagSet) {
 	fs.StringVar(&o.LeaderElectionNamespace, "leader-election-namespace", o.LeaderElectionNamespace, "This determines the namespace in which the leader election configmap will be created, it will use in-cluster namespace if empty.")
 	fs.StringVar(&o.Namespace, "namespace", o.Namespace, "Namespace if specified restricts the manager's cache to watch objects in the desired namespace. Defaults to all namespaces.")
 	fs.BoolVar(&o.CreateDefaultPool, "create-default-pool", o.CreateDefaultPool, "Create default cloud/edge pools if indicated.")
+	fs.StringVar(&o.NodePoolTopologyKey, "nodepool-topology-key", o.NodePoolTopologyKey, "The label key used to publish the nodepool name on the member nodes, e.g. topology.kubernetes.io/zone. The existing value of the label is restored when the node leaves the nodepool. Empty by default, which disables it.")
+	fs.StringVar(&o.NodePoolLeaseNamespace, "nodepool-lease-namespace", o.NodePoolLeaseNamespace, "The namespace of the Leases that record the leader nodes of the nodepools, defaults to the namespace of yurt-app-manager. Set it to empty to disable the leader election.")
+	fs.BoolVar(&o.NodePoolChangeProtection, "nodepool-change-protection", o.NodePoolChangeProtection, "Reject changing the desired nodepool of nodes that still run pods of pool-scoped workloads.")
+	fs.StringSliceVar(&o.DefaultNodePoolRules, "default-nodepool-rules", o.DefaultNodePoolRules, "Rules to decide the desired nodepool of new nodes, in the format of <label-key>=<label-value>:<nodepool>, e.g. openyurt.io/is-edge-worker=true:default-edge-nodepool. The first matching rule wins.")
 	fs.BoolVar(&o.Version, "version", o.Version, "print the version information.")
 }
@@ -52,6 +52,15 @@ func SetDefaultsUnitedDeployment(obj *UnitedDeployment) {
 		obj.Spec.RevisionHistoryLimit = utilpointer.Int32Ptr(10)
 	}
 
+	if policy := obj.Spec.FailoverPolicy; policy != nil {
+		if policy.PendingTimeoutSeconds == nil {
+			policy.PendingTimeoutSeconds = utilpointer.Int32Ptr(DefaultFailoverPendingTimeoutSeconds)
+		}
+		if policy.HoldDownSeconds == nil {
+			policy.HoldDownSeconds = utilpointer.Int32Ptr(DefaultFailoverHoldDownSeconds)
+		}
+	}
+
 	if obj.Spec.WorkloadTemplate.StatefulSetTemplate != nil {
 		SetDefaultPodSpec(&obj.Spec.WorkloadTemplate.StatefulSetTemplate.Spec.Template.Spec)
 		for i := range obj.Spec.WorkloadTemplate.StatefulSetTemplate.Spec.VolumeClaimTemplates {
@@ -19,6 +19,7 @@ package v1alpha1
 import (
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type NodePoolType string
@@ -28,13 +29,76 @@ const (
 	Cloud NodePoolType = "Cloud"
 )
 
+const (
+	// NodePoolFinalizer is used to cleanup the pool related attributes of the
+	// member nodes when the NodePool is deleted
+	NodePoolFinalizer = "nodepool.openyurt.io/finalizer"
+)
+
+// NodePoolDeletionPolicy decides how to handle the nodes that still have the
+// desired-nodepool label pointing to the NodePool when it is deleted.
+type NodePoolDeletionPolicy string
+
+const (
+	// KeepDesiredLabel removes the pool related attributes from the nodes but
+	// keeps their desired-nodepool label, so that they will rejoin the pool if
+	// it is recreated.
+	KeepDesiredLabel NodePoolDeletionPolicy = "KeepDesiredLabel"
+	// RemoveDesiredLabel removes both the pool related attributes and the
+	// desired-nodepool label from the nodes.
+	RemoveDesiredLabel NodePoolDeletionPolicy = "RemoveDesiredLabel"
+	// BlockDeletion refuses to delete the pool as long as there are nodes
+	// whose desired-nodepool label pointing to the pool.
+	BlockDeletion NodePoolDeletionPolicy = "Block"
+)
+
+// NodePoolConditionType indicates valid conditions type of a NodePool.
+type NodePoolConditionType string
+
+const (
+	// NodePoolReady means the pool has enough ready nodes, which is decided by
+	// the NodePool.Spec.HealthPolicy.MinReadyNodes.
+	NodePoolReady NodePoolConditionType = "Ready"
+	// NodePoolDegraded means the number of unready nodes in the pool exceeds
+	// the NodePool.Spec.HealthPolicy.MaxUnreadyNodes.
+	NodePoolDegraded NodePoolConditionType = "Degraded"
+	// NodePoolAllNodesUnreachable means the pool has nodes, but none of them
+	// is ready.
+	NodePoolAllNodesUnreachable NodePoolConditionType = "AllNodesUnreachable"
+)
+
 // NodePoolSpec defines the desired state of NodePool
 type NodePoolSpec struct {
-	// The type of the NodePool
+	// The type of the NodePool, which decides the attributes of the member
+	// nodes: the `openyurt.io/is-edge-worker` label is set to "true" on the
+	// nodes of Edge pools and "false" on the nodes of Cloud pools.
 	// +optional
 	Type NodePoolType `json:"type,omitempty"`
 
-	// A label query over nodes to consider for adding to the pool
+	// DisableTypeAttributes stops the pool from setting the attributes derived
+	// from its Type on the member nodes.
+	// +optional
+	DisableTypeAttributes bool `json:"disableTypeAttributes,omitempty"`
+
+	// Autonomy adds the `node.beta.openyurt.io/autonomy: "true"` annotation
+	// to the member nodes, so that their pods will not be evicted when the
+	// nodes are disconnected from the cloud. Only valid for Edge pools.
+	// +optional
+	Autonomy bool `json:"autonomy,omitempty"`
+
+	// The name of the parent NodePool. The labels, annotations and taints of
+	// the ancestor pools are inherited by the member nodes, and the values of
+	// the pool take precedence over those of its ancestors. Nodes usually
+	// join the leaf pools, and the node counts roll up into the ancestors.
+	// +optional
+	Parent string `json:"parent,omitempty"`
+
+	// A label query over nodes to consider for adding to the pool.
+	// Nodes with the `apps.openyurt.io/desired-nodepool` label always join
+	// the pool specified by the label. If a node matches the selectors of
+	// several pools, it stays in its current pool if that one still matches,
+	// otherwise it joins the pool with the most selector requirements, and
+	// ties are broken by the pool name. An empty selector matches no node.
 	// +optional
 	Selector *metav1.LabelSelector `json:"selector,omitempty"`
 
@@ -51,8 +115,103 @@ type NodePoolSpec struct {
 	// If specified, the Taints will be added to all nodes.
 	// +optional
 	Taints []v1.Taint `json:"taints,omitempty"`
+
+	// HealthPolicy defines the thresholds used to compute the pool conditions.
+	// +optional
+	HealthPolicy *NodePoolHealthPolicy `json:"healthPolicy,omitempty"`
+
+	// DeletionPolicy decides how to handle the nodes that still have the
+	// desired-nodepool label pointing to the pool when the pool is deleted.
+	// Valid values are KeepDesiredLabel, RemoveDesiredLabel and Block.
+	// Defaults to KeepDesiredLabel.
+	// +optional
+	DeletionPolicy NodePoolDeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// Maintenance puts the pool into maintenance mode if specified, all
+	// member nodes will be cordoned, and their pods will be evicted if Drain
+	// is set. Nodes cordoned by the pool become schedulable again once the
+	// Maintenance is removed.
+	// +optional
+	Maintenance *NodePoolMaintenance `json:"maintenance,omitempty"`
+
+	// Quota limits the resources requested by the pods on the member nodes,
+	// new pods exceeding the quota are rejected when they are bound to the
+	// nodes. Pods managed by DaemonSets and mirror pods are not limited. The
+	// quota is advisory: it is checked against the pods cached by the pod
+	// webhook, so pods bound at the same time may exceed it, and the pods of
+	// kube-system and yurt-app-manager are not checked.
+	// +optional
+	Quota *NodePoolQuota `json:"quota,omitempty"`
+
+	// ImageRegistryMirrors maps the image registries to their mirrors, e.g.
+	// `docker.io: registry.site-a.local:5000`. The images of the pods pinned
+	// to the pool are rewritten to pull from the mirrors when the pods are
+	// created. A mirror may contain a path, e.g. `registry.local/docker.io`.
+	// +optional
+	ImageRegistryMirrors map[string]string `json:"imageRegistryMirrors,omitempty"`
+}
+
+// NodePoolQuota defines the limits of the total requests of the pods on the
+// member nodes. Supported resources are cpu, memory and pods.
+type NodePoolQuota struct {
+	// Hard limits the pods of all namespaces.
+	// +optional
+	Hard v1.ResourceList `json:"hard,omitempty"`
+
+	// Namespaces limits the pods of the specified namespaces, in addition to
+	// the Hard limits of the pool.
+	// +optional
+	Namespaces []NodePoolNamespaceQuota `json:"namespaces,omitempty"`
+}
+
+// NodePoolNamespaceQuota defines the limits of the pods of a namespace.
+type NodePoolNamespaceQuota struct {
+	// The name of the namespace.
+	Namespace string `json:"namespace"`
+
+	// Hard limits the pods of the namespace.
+	Hard v1.ResourceList `json:"hard"`
 }
 
+// NodePoolMaintenance defines how the member nodes are maintained.
+type NodePoolMaintenance struct {
+	// Drain evicts pods from the member nodes through the eviction API, so
+	// that the PodDisruptionBudgets are respected. Pods managed by DaemonSets,
+	// mirror pods and pods not managed by a controller are not evicted, the
+	// latter block the drain until they are deleted.
+	// +optional
+	Drain bool `json:"drain,omitempty"`
+}
+
+// NodePoolHealthPolicy defines the thresholds used to compute the NodePool
+// conditions. Values can be an absolute number (ex: 5) or a percentage of
+// the total nodes in the pool (ex: 10%).
+type NodePoolHealthPolicy struct {
+	// The minimum number of ready nodes for the pool to be Ready.
+	// Percentages are calculated by rounding up.
+	// Defaults to 50%.
+	// +optional
+	MinReadyNodes *intstr.IntOrString `json:"minReadyNodes,omitempty"`
+
+	// The maximum number of unready nodes before the pool is Degraded.
+	// Percentages are calculated by rounding down.
+	// Defaults to 0.
+	// +optional
+	MaxUnreadyNodes *intstr.IntOrString `json:"maxUnreadyNodes,omitempty"`
+}
+
+// NodePoolMaintenancePhase is the phase of the NodePool maintenance.
+type NodePoolMaintenancePhase string
+
+const (
+	// MaintenanceInProgress means there are member nodes to be cordoned or
+	// pods to be evicted.
+	MaintenanceInProgress NodePoolMaintenancePhase = "InProgress"
+	// MaintenanceCompleted means all member nodes are cordoned, and drained
+	// if required.
+	MaintenanceCompleted NodePoolMaintenancePhase = "Completed"
+)
+
 // NodePoolStatus defines the observed state of NodePool
 type NodePoolStatus struct {
 	// Total number of ready nodes in the pool.
@@ -66,13 +225,197 @@ type NodePoolStatus struct {
 	// The list of nodes' names in the pool
 	// +optional
 	Nodes []string `json:"nodes,omitempty"`
+
+	// The total resources of all ready nodes in the pool.
+	// +optional
+	Capacity v1.ResourceList `json:"capacity,omitempty"`
+
+	// The total resources of all ready nodes in the pool that are available
+	// for scheduling.
+	// +optional
+	Allocatable v1.ResourceList `json:"allocatable,omitempty"`
+
+	// The names of the pools whose parent is the pool.
+	// +optional
+	ChildNodePools []string `json:"childNodePools,omitempty"`
+
+	// Total number of ready nodes in the pool and all its descendant pools.
+	// +optional
+	TotalReadyNodeNum int32 `json:"totalReadyNodeNum,omitempty"`
+
+	// Total number of unready nodes in the pool and all its descendant pools.
+	// +optional
+	TotalUnreadyNodeNum int32 `json:"totalUnreadyNodeNum,omitempty"`
+
+	// The name of the node elected as the leader of the pool, which is a ready
+	// member node. It is also recorded in the Lease of the pool and marked by
+	// the `apps.openyurt.io/nodepool-leader` label on the node.
+	// +optional
+	LeaderNode string `json:"leaderNode,omitempty"`
+
+	// Represents the latest available observations of a NodePool's current state.
+	// +optional
+	Conditions []NodePoolCondition `json:"conditions,omitempty"`
+
+	// The progress of the maintenance, only set when the pool is under
+	// maintenance.
+	// +optional
+	Maintenance *NodePoolMaintenanceStatus `json:"maintenance,omitempty"`
+
+	// The resources used by the pods on the member nodes, only set when the
+	// pool has a quota.
+	// +optional
+	Quota *NodePoolQuotaStatus `json:"quota,omitempty"`
+
+	// The latest membership changes of the pool, the oldest ones are dropped
+	// once the list is full.
+	// +optional
+	RecentChanges []NodePoolMembershipChange `json:"recentChanges,omitempty"`
+
+	// The nodes that are migrating into the pool through the
+	// `nodepool.openyurt.io/migrate-to` annotation.
+	// +optional
+	Migrations []NodeMigrationStatus `json:"migrations,omitempty"`
+}
+
+// NodeMigrationPhase is the phase of the node migration.
+type NodeMigrationPhase string
+
+const (
+	// MigrationCordoning means the node is being cordoned.
+	MigrationCordoning NodeMigrationPhase = "Cordoning"
+	// MigrationDraining means the pods of the pool-scoped workloads are being
+	// evicted from the node.
+	MigrationDraining NodeMigrationPhase = "Draining"
+	// MigrationSwitching means the node is switching from the old pool to the
+	// new pool.
+	MigrationSwitching NodeMigrationPhase = "Switching"
+)
+
+// NodeMigrationStatus reports the progress of a node migrating into the pool.
+type NodeMigrationStatus struct {
+	// The name of the node.
+	Node string `json:"node"`
+
+	// The pool that the node is migrating from.
+	// +optional
+	FromNodePool string `json:"fromNodePool,omitempty"`
+
+	// Phase of the migration, one of Cordoning, Draining and Switching. The
+	// node is uncordoned and removed from the list once it joins the pool.
+	Phase NodeMigrationPhase `json:"phase"`
+
+	// The number of pods of the pool-scoped workloads that are waiting to be
+	// evicted.
+	// +optional
+	PendingPods int32 `json:"pendingPods,omitempty"`
+
+	// A human readable message indicating why the migration is not progressing.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// NodePoolMembershipChangeType is the type of the NodePool membership change.
+type NodePoolMembershipChangeType string
+
+const (
+	// NodeJoined means the node joined the pool.
+	NodeJoined NodePoolMembershipChangeType = "Joined"
+	// NodeLeft means the node left the pool.
+	NodeLeft NodePoolMembershipChangeType = "Left"
+)
+
+// NodePoolMembershipChange records a node joining or leaving the NodePool.
+type NodePoolMembershipChange struct {
+	// The name of the node.
+	Node string `json:"node"`
+
+	// Type of the change, one of Joined and Left.
+	Type NodePoolMembershipChangeType `json:"type"`
+
+	// The pool that the node left from when it joined the pool, or the pool
+	// that the node joined when it left the pool. Empty if there is none.
+	// +optional
+	PeerNodePool string `json:"peerNodePool,omitempty"`
+
+	// The time when the change happened.
+	Time metav1.Time `json:"time"`
+}
+
+// NodePoolQuotaStatus reports the resources used by the pods on the member
+// nodes.
+type NodePoolQuotaStatus struct {
+	// The total requests of the pods of all namespaces.
+	// +optional
+	Used v1.ResourceList `json:"used,omitempty"`
+
+	// The total requests of the pods of the namespaces that have quotas.
+	// +optional
+	Namespaces []NodePoolNamespaceQuotaStatus `json:"namespaces,omitempty"`
+}
+
+// NodePoolNamespaceQuotaStatus reports the resources used by the pods of a
+// namespace.
+type NodePoolNamespaceQuotaStatus struct {
+	// The name of the namespace.
+	Namespace string `json:"namespace"`
+
+	// The total requests of the pods of the namespace.
+	// +optional
+	Used v1.ResourceList `json:"used,omitempty"`
+}
+
+// NodePoolMaintenanceStatus reports the progress of the NodePool maintenance.
+type NodePoolMaintenanceStatus struct {
+	// Phase of the maintenance, one of InProgress and Completed.
+	Phase NodePoolMaintenancePhase `json:"phase,omitempty"`
+
+	// The number of member nodes that are unschedulable.
+	CordonedNodes int32 `json:"cordonedNodes"`
+
+	// The number of member nodes that have no pods to be evicted.
+	// +optional
+	DrainedNodes int32 `json:"drainedNodes,omitempty"`
+
+	// The number of pods that are waiting to be evicted, including the pods
+	// not managed by a controller, which are not evicted.
+	// +optional
+	PendingPods int32 `json:"pendingPods,omitempty"`
+
+	// A human readable message indicating why the maintenance is not completed.
+	// +optional
+	Message string `json:"message,omitempty"`
+}
+
+// NodePoolCondition describes current state of a NodePool.
+type NodePoolCondition struct {
+	// Type of nodepool condition.
+	Type NodePoolConditionType `json:"type,omitempty"`
+
+	// Status of the condition, one of True, False, Unknown.
+	Status v1.ConditionStatus `json:"status,omitempty"`
+
+	// Last time the condition transitioned from one status to another.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty"`
+
+	// The reason for the condition's last transition.
+	Reason string `json:"reason,omitempty"`
+
+	// A human readable message indicating details about the transition.
+	Message string `json:"message,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:resource:scope=Cluster,path=nodepools,shortName=np,categories=all
 // +kubebuilder:printcolumn:name="Type",type="string",JSONPath=".spec.type",description="The type of nodepool"
+// +kubebuilder:printcolumn:name="Parent",type="string",JSONPath=".spec.parent",description="The parent of nodepool",priority=1
 // +kubebuilder:printcolumn:name="ReadyNodes",type="integer",JSONPath=".status.readyNodeNum",description="The number of ready nodes in the pool"
 // +kubebuilder:printcolumn:name="NotReadyNodes",type="integer",JSONPath=".status.unreadyNodeNum"
+// +kubebuilder:printcolumn:name="Ready",type="string",JSONPath=".status.conditions[?(@.type==\"Ready\")].status",description="Whether the pool has enough ready nodes"
+// +kubebuilder:printcolumn:name="AllocatableCPU",type="string",JSONPath=".status.allocatable.cpu",description="The total allocatable cpu of ready nodes in the pool"
+// +kubebuilder:printcolumn:name="AllocatableMemory",type="string",JSONPath=".status.allocatable.memory",description="The total allocatable memory of ready nodes in the pool"
+// +kubebuilder:printcolumn:name="Leader",type="string",JSONPath=".status.leaderNode",description="The leader node of the pool",priority=1
+// +kubebuilder:printcolumn:name="Maintenance",type="string",JSONPath=".status.maintenance.phase",description="The phase of the pool maintenance",priority=1
 // +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
 // +kubebuilder:subresource:status
 // +genclient:nonNamespaced
@@ -0,0 +1,159 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultPrePullActiveDeadlineSeconds defines the default duration that
+	// the pull pod is allowed to run on a node
+	DefaultPrePullActiveDeadlineSeconds int64 = 600
+
+	// LabelPoolImagePrePull indicates which PoolImagePrePull the pull pod
+	// belongs to, so the name of PoolImagePrePull must be a valid label value
+	LabelPoolImagePrePull = "apps.openyurt.io/pool-image-prepull"
+
+	// AnnotationPrePullGeneration records the generation of the
+	// PoolImagePrePull that the pull pod is created for
+	AnnotationPrePullGeneration = "apps.openyurt.io/pool-image-prepull-generation"
+)
+
+// PrePullPhase is the phase of pulling the images on a node.
+type PrePullPhase string
+
+const (
+	// PrePullPending means the node is not ready, the pull pod is created
+	// once the node becomes ready.
+	PrePullPending PrePullPhase = "Pending"
+	// PrePullRunning means the pull pod is created on the node and the
+	// images are being pulled.
+	PrePullRunning PrePullPhase = "Running"
+	// PrePullSucceeded means all images are pulled on the node.
+	PrePullSucceeded PrePullPhase = "Succeeded"
+	// PrePullFailed means some image can't be pulled on the node, or the
+	// pull pod doesn't finish in time.
+	PrePullFailed PrePullPhase = "Failed"
+)
+
+// PoolImagePrePullSpec defines the desired state of PoolImagePrePull
+type PoolImagePrePullSpec struct {
+	// The images to be pulled on the member nodes of the target pools.
+	Images []string `json:"images"`
+
+	// The names of the target pools, the nodes of their descendant pools
+	// are targeted too.
+	// +optional
+	NodePools []string `json:"nodePools,omitempty"`
+
+	// A label query over the NodePools, the selected pools are targeted
+	// besides the ones in NodePools.
+	// +optional
+	NodePoolSelector *metav1.LabelSelector `json:"nodePoolSelector,omitempty"`
+
+	// The secrets in the same namespace used to pull the images.
+	// +optional
+	ImagePullSecrets []v1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+
+	// The duration in seconds that the pull pod is allowed to run on a node
+	// before the pulling is considered failed. Defaults to 600.
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+}
+
+// PoolImagePrePullNodeStatus describes the pulling of the images on a node
+type PoolImagePrePullNodeStatus struct {
+	// The name of the node.
+	NodeName string `json:"nodeName"`
+
+	// The pool that the node belongs to.
+	NodePool string `json:"nodePool,omitempty"`
+
+	// The phase of pulling the images on the node.
+	Phase PrePullPhase `json:"phase"`
+
+	// A human readable message indicating why the pulling failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// The time when the pulling finished on the node.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// PoolImagePrePullStatus defines the observed state of PoolImagePrePull
+type PoolImagePrePullStatus struct {
+	// The generation observed by the controller, the images are pulled
+	// again on all nodes once the spec is changed.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+
+	// The number of the member nodes of the target pools.
+	// +optional
+	DesiredNodes int32 `json:"desiredNodes"`
+
+	// The number of nodes that have pulled all images.
+	// +optional
+	SucceededNodes int32 `json:"succeededNodes"`
+
+	// The number of nodes that failed to pull the images.
+	// +optional
+	FailedNodes int32 `json:"failedNodes"`
+
+	// The pulling on each member node, sorted by the node name.
+	// +optional
+	Nodes []PoolImagePrePullNodeStatus `json:"nodes,omitempty"`
+
+	// The time when the pulling finished on all member nodes, it is reset
+	// once a new node joins the target pools.
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=poolimageprepulls,shortName=pip,categories=all
+// +kubebuilder:printcolumn:name="Desired",type="integer",JSONPath=".status.desiredNodes",description="The number of the member nodes of the target pools"
+// +kubebuilder:printcolumn:name="Succeeded",type="integer",JSONPath=".status.succeededNodes",description="The number of nodes that have pulled all images"
+// +kubebuilder:printcolumn:name="Failed",type="integer",JSONPath=".status.failedNodes",description="The number of nodes that failed to pull the images"
+// +kubebuilder:printcolumn:name="Age",type="date",JSONPath=".metadata.creationTimestamp"
+// +kubebuilder:subresource:status
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +genclient
+// PoolImagePrePull is the Schema for the poolimageprepulls API
+type PoolImagePrePull struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PoolImagePrePullSpec   `json:"spec,omitempty"`
+	Status PoolImagePrePullStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// PoolImagePrePullList contains a list of PoolImagePrePull
+type PoolImagePrePullList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PoolImagePrePull `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PoolImagePrePull{}, &PoolImagePrePullList{})
+}
@@ -26,6 +26,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type TemplateType string
@@ -53,6 +54,22 @@ type UnitedDeploymentSpec struct {
 	// It must match the pod template's labels.
 	Selector *metav1.LabelSelector `json:"selector"`
 
+	// Replicas is the total number of the pods distributed across the pools.
+	// The pools with fixed replicas take their count first, and the rest is
+	// split by the weight of the other pools. If unspecified, every pool
+	// runs its own replicas. It is the replicas of the scale subresource,
+	// which reports 0 while it is unspecified, so scaling the
+	// UnitedDeployment sets it and switches the pools without fixed
+	// replicas to split the total.
+	// +optional
+	Replicas *int32 `json:"replicas,omitempty"`
+
+	// ReplicasDistribution indicates how the replicas left by the fixed and
+	// percentage pools are split across the other pools. If unspecified, the
+	// replicas are split by the weight of the pools.
+	// +optional
+	ReplicasDistribution *UnitedDeploymentReplicasDistribution `json:"replicasDistribution,omitempty"`
+
 	// WorkloadTemplate describes the pool that will be created.
 	// +optional
 	WorkloadTemplate WorkloadTemplate `json:"workloadTemplate,omitempty"`
@@ -65,6 +82,117 @@ type UnitedDeploymentSpec struct {
 	// If unspecified, defaults to 10.
 	// +optional
 	RevisionHistoryLimit *int32 `json:"revisionHistoryLimit,omitempty"`
+
+	// UpdateStrategy indicates the strategy to roll out a new revision of the
+	// WorkloadTemplate across the pools. If unspecified, all pools are
+	// updated at once.
+	// +optional
+	UpdateStrategy *UnitedDeploymentUpdateStrategy `json:"updateStrategy,omitempty"`
+
+	// FailoverPolicy indicates how the replicas of the unavailable pools are
+	// moved to the fallback pools. If unspecified, the replicas are not moved.
+	// +optional
+	FailoverPolicy *UnitedDeploymentFailoverPolicy `json:"failoverPolicy,omitempty"`
+}
+
+const (
+	// DefaultFailoverPendingTimeoutSeconds is the default time that the pods
+	// of a pool stay unschedulable before the pool fails over
+	DefaultFailoverPendingTimeoutSeconds = 300
+	// DefaultFailoverHoldDownSeconds is the default time that a pool stays
+	// failed over before its replicas move back
+	DefaultFailoverHoldDownSeconds = 300
+)
+
+// UnitedDeploymentFailoverPolicy defines the failover of the pools. A pool
+// fails over when all the nodes of its NodePool are not ready, and all its
+// replicas are moved to the fallback pools until the NodePool has recovered
+// for the hold-down time. A pool also fails over when some of its pods are
+// unschedulable for the pending timeout, e.g. the site runs out of capacity,
+// and the unschedulable replicas are moved to the fallback pools. They move
+// back after the hold-down time to retry, which doubles each time the pods
+// are unschedulable again.
+type UnitedDeploymentFailoverPolicy struct {
+	// FallbackPools is the names of the pools that take the replicas of the
+	// failed pools, the replicas are split evenly across the fallback pools
+	// that are not failed.
+	FallbackPools []string `json:"fallbackPools"`
+
+	// PendingTimeoutSeconds is the number of seconds that some pods of a pool
+	// are unschedulable before the pool fails over. Defaults to 300.
+	// +optional
+	PendingTimeoutSeconds *int32 `json:"pendingTimeoutSeconds,omitempty"`
+
+	// HoldDownSeconds is the number of seconds that a failed pool is kept
+	// failed over after it recovers, and after it fails over for the pending
+	// pods, as the capacity of the pool is unknown until the replicas move
+	// back. The hold-down time of the pending pods doubles with each retry,
+	// up to 32 times. Defaults to 300.
+	// +optional
+	HoldDownSeconds *int32 `json:"holdDownSeconds,omitempty"`
+}
+
+// ReplicasDistributionMode is the mode to split the replicas across the pools.
+type ReplicasDistributionMode string
+
+const (
+	// WeightReplicasDistribution splits the replicas by the weight of the pools.
+	WeightReplicasDistribution ReplicasDistributionMode = "Weight"
+	// ReadyNodesReplicasDistribution splits the replicas by the number of the
+	// ready nodes of the NodePools.
+	ReadyNodesReplicasDistribution ReplicasDistributionMode = "ReadyNodes"
+	// AllocatableReplicasDistribution splits the replicas by the allocatable
+	// resource of the ready nodes of the NodePools.
+	AllocatableReplicasDistribution ReplicasDistributionMode = "Allocatable"
+)
+
+// UnitedDeploymentReplicasDistribution defines how the replicas are split
+// across the pools. With the capacity modes, i.e. ReadyNodes and Allocatable,
+// every pool weighs the capacity of the NodePool it is named after, and the
+// replicas are rebalanced when the capacity changes.
+type UnitedDeploymentReplicasDistribution struct {
+	// Mode is the mode to split the replicas, one of Weight, ReadyNodes and
+	// Allocatable. Defaults to Weight.
+	// +optional
+	Mode ReplicasDistributionMode `json:"mode,omitempty"`
+
+	// Resource is the allocatable resource to weigh the pools with the
+	// Allocatable mode. Defaults to cpu.
+	// +optional
+	Resource corev1.ResourceName `json:"resource,omitempty"`
+}
+
+// UnitedDeploymentUpdateStrategy defines the staged rollout of a new revision,
+// the pools are updated batch by batch, and a batch starts only after all the
+// pools of the previous batches are ready at the new revision.
+type UnitedDeploymentUpdateStrategy struct {
+	// Batches is the ordered list of the pools to update. The pools that are
+	// not listed are updated in an implicit last batch, sorted by name.
+	// A batch may name a parent NodePool to cover all its descendant pools.
+	// +optional
+	Batches []UnitedDeploymentUpdateBatch `json:"batches,omitempty"`
+
+	// MaxUnavailablePools is the maximum number of pools that are updated to
+	// the new revision but not ready yet. If unspecified, all the pools of the
+	// current batch are updated at once.
+	// +optional
+	MaxUnavailablePools *int32 `json:"maxUnavailablePools,omitempty"`
+
+	// BatchIntervalSeconds is the number of seconds to wait after a batch is
+	// ready before the next batch starts. Defaults to 0.
+	// +optional
+	BatchIntervalSeconds int32 `json:"batchIntervalSeconds,omitempty"`
+
+	// Paused stops the rollout from updating more pools, the pools that are
+	// being updated are not affected.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// UnitedDeploymentUpdateBatch defines the pools updated together.
+type UnitedDeploymentUpdateBatch struct {
+	// Pools is the names of the pools in the batch.
+	Pools []string `json:"pools"`
 }
 
 // WorkloadTemplate defines the pool template under the UnitedDeployment.
@@ -123,9 +251,27 @@ type Pool struct {
 	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
 
 	// Indicates the number of the pod to be created under this pool.
-	// +required
+	// It is a fixed count that is not changed by the replicas of the
+	// UnitedDeployment.
+	// +optional
 	Replicas *int32 `json:"replicas,omitempty"`
 
+	// Indicates the share of the pool in the replicas of the UnitedDeployment,
+	// which is required to be set. The value can be an integer weight (ex: 2)
+	// that splits the replicas left by the fixed and percentage pools, or a
+	// percentage of the replicas of the UnitedDeployment (ex: 30%).
+	// Defaults to the weight 1 for the pools without fixed replicas.
+	// +optional
+	Weight *intstr.IntOrString `json:"weight,omitempty"`
+
+	// Indicates the minimum number of the pod distributed to this pool.
+	// +optional
+	MinReplicas *int32 `json:"minReplicas,omitempty"`
+
+	// Indicates the maximum number of the pod distributed to this pool.
+	// +optional
+	MaxReplicas *int32 `json:"maxReplicas,omitempty"`
+
 	// Indicates the patch for the templateSpec
 	// Now support strategic merge path :https://kubernetes.io/docs/tasks/manage-kubernetes-objects/update-api-object-kubectl-patch/#notes-on-the-strategic-merge-patch
 	// Patch takes precedence over Replicas fields
@@ -154,7 +300,8 @@ type UnitedDeploymentStatus struct {
 	// +optional
 	Conditions []UnitedDeploymentCondition `json:"conditions,omitempty"`
 
-	// Records the topology detail information of the replicas of each pool.
+	// Records the topology detail information of the replicas of each pool,
+	// i.e. the replicas distributed to the pools.
 	// +optional
 	PoolReplicas map[string]int32 `json:"poolReplicas,omitempty"`
 
@@ -165,8 +312,102 @@ type UnitedDeploymentStatus struct {
 	// Replicas is the most recently observed number of replicas.
 	Replicas int32 `json:"replicas"`
 
+	// LabelSelector is the label selector of the pods in the string form,
+	// which is exposed by the scale subresource for the HorizontalPodAutoscaler.
+	// +optional
+	LabelSelector string `json:"labelSelector,omitempty"`
+
 	// TemplateType indicates the type of PoolTemplate
 	TemplateType TemplateType `json:"templateType"`
+
+	// UpdateStatus records the progress of the staged rollout, it is only set
+	// when the UpdateStrategy is specified.
+	// +optional
+	UpdateStatus *UnitedDeploymentUpdateStatus `json:"updateStatus,omitempty"`
+
+	// PoolFailovers records the pools that are unavailable, failed over or
+	// recovering, it is only set when the FailoverPolicy is specified.
+	// +optional
+	PoolFailovers []UnitedDeploymentPoolFailover `json:"poolFailovers,omitempty"`
+}
+
+// UnitedDeploymentUpdateStatus describes the progress of the staged rollout.
+type UnitedDeploymentUpdateStatus struct {
+	// UpdatedRevision is the revision that the pools are updated to.
+	UpdatedRevision string `json:"updatedRevision,omitempty"`
+
+	// CurrentBatch is the index of the batch that is being updated, it equals
+	// the number of batches when the rollout is finished.
+	CurrentBatch int32 `json:"currentBatch"`
+
+	// UpdatedPools is the names of the pools at the updated revision.
+	// +optional
+	UpdatedPools []string `json:"updatedPools,omitempty"`
+
+	// ReadyPools is the names of the pools whose replicas are all ready at
+	// the updated revision.
+	// +optional
+	ReadyPools []string `json:"readyPools,omitempty"`
+
+	// LastBatchReadyTime is the time when the last batch became ready.
+	// +optional
+	LastBatchReadyTime *metav1.Time `json:"lastBatchReadyTime,omitempty"`
+
+	// Paused indicates the rollout is paused by the UpdateStrategy.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// PoolFailoverPhase is the phase of a pool in the failover.
+type PoolFailoverPhase string
+
+const (
+	// PoolUnavailable means some pods of the pool are unschedulable, the pool
+	// fails over if it lasts for the pending timeout. The pool that moved
+	// its replicas back to retry also stays in this phase for the hold-down
+	// time.
+	PoolUnavailable PoolFailoverPhase = "Unavailable"
+	// PoolFailedOver means the replicas of the pool are moved to the fallback
+	// pools.
+	PoolFailedOver PoolFailoverPhase = "FailedOver"
+	// PoolRecovering means the pool has recovered, its replicas move back
+	// after the hold-down time.
+	PoolRecovering PoolFailoverPhase = "Recovering"
+)
+
+// PoolFailoverReason is the reason why a pool fails over.
+type PoolFailoverReason string
+
+const (
+	// PoolNodesNotReady means all the nodes of the NodePool are not ready.
+	PoolNodesNotReady PoolFailoverReason = "NodesNotReady"
+	// PoolPodsPending means some pods of the pool are unschedulable.
+	PoolPodsPending PoolFailoverReason = "PodsPending"
+)
+
+// UnitedDeploymentPoolFailover describes the failover state of a pool.
+type UnitedDeploymentPoolFailover struct {
+	// Name is the name of the pool.
+	Name string `json:"name"`
+
+	// Phase is the phase of the pool in the failover.
+	Phase PoolFailoverPhase `json:"phase"`
+
+	// Reason is the reason why the pool is unavailable or failed over.
+	Reason PoolFailoverReason `json:"reason"`
+
+	// LastTransitionTime is the time when the pool entered the phase.
+	LastTransitionTime metav1.Time `json:"lastTransitionTime"`
+
+	// Replicas is the number of the unschedulable replicas moved to the
+	// fallback pools by the pool failed over for the pending pods.
+	// +optional
+	Replicas int32 `json:"replicas,omitempty"`
+
+	// Retries is the number of times the pool failed over for the pending
+	// pods has moved its replicas back.
+	// +optional
+	Retries int32 `json:"retries,omitempty"`
 }
 
 // UnitedDeploymentCondition describes current state of a UnitedDeployment.
@@ -190,6 +431,7 @@ type UnitedDeploymentCondition struct {
 // +genclient
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:subresource:scale:specpath=.spec.replicas,statuspath=.status.replicas,selectorpath=.status.labelSelector
 // +kubebuilder:resource:shortName=ud
 // +kubebuilder:printcolumn:name="READY",type="integer",JSONPath=".status.readyReplicas",description="The number of pods ready."
 // +kubebuilder:printcolumn:name="WorkloadTemplate",type="string",JSONPath=".status.templateType",description="The WorkloadTemplate Type."
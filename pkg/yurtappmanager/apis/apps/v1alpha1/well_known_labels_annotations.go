@@ -52,13 +52,53 @@ const (
 
 	AnnotationPrevAttrs = "nodepool.openyurt.io/previous-attributes"
 
+	// AnnotationCordonedByNodePool indicates the node is cordoned for the
+	// maintenance of the nodepool specified by the value
+	AnnotationCordonedByNodePool = "nodepool.openyurt.io/cordoned-by"
+
+	// AnnotationMigrateToNodePool asks the nodepool controller to migrate the
+	// node to the nodepool specified by the value, the node is cordoned and
+	// drained from the pool-scoped workloads before joining the new pool
+	AnnotationMigrateToNodePool = "nodepool.openyurt.io/migrate-to"
+
+	// AnnotationAllowedNodePools restricts the pods of the namespace to the
+	// comma separated nodepools and their descendants, it is set on namespaces
+	AnnotationAllowedNodePools = "nodepool.openyurt.io/allowed-nodepools"
+
+	// AnnotationInjectPoolContext asks to expose the nodepool of the pod to
+	// its containers, the value is either "labels" or "env". The pool name
+	// and type are set as the pod labels, and "env" also exposes them to the
+	// containers as environment variables through the downward API if the
+	// pool is known when the pod is created, i.e. the pod is pinned to a
+	// nodepool or created with the nodeName
+	AnnotationInjectPoolContext = "nodepool.openyurt.io/inject-pool-context"
+
+	// AnnotationInjectPoolAnnotations lists the comma separated annotation
+	// keys of the nodepool that are copied to the pod with the pool context
+	AnnotationInjectPoolAnnotations = "nodepool.openyurt.io/inject-pool-annotations"
+
+	// LabelNodePoolType indicates the type of the nodepool that the pod is
+	// running in, it is set with the pool context
+	LabelNodePoolType = "apps.openyurt.io/nodepool-type"
+
+	// LabelNodePoolLeader marks the node elected as the leader of its nodepool
+	LabelNodePoolLeader = "apps.openyurt.io/nodepool-leader"
+
+	// LabelEdgeWorker indicates whether the node is an edge node, it is set
+	// based on the type of the nodepool that the node belongs to
+	LabelEdgeWorker = "openyurt.io/is-edge-worker"
+
+	// AnnotationNodeAutonomy indicates whether the pods on the node should be
+	// kept running when the node is disconnected from the cloud
+	AnnotationNodeAutonomy = "node.beta.openyurt.io/autonomy"
+
 	// DefaultCloudNodePoolName defines the name of the default cloud nodepool
 	DefaultCloudNodePoolName = "default-nodepool"
 
 	// DefaultEdgeNodePoolName defines the name of the default edge nodepool
 	DefaultEdgeNodePoolName = "default-edge-nodepool"
 
-	// ServiceTopologyKey is the toplogy key that will be attached to node,
-	// the value will be the name of the nodepool
+	// ServiceTopologyKey is the well-known toplogy key that can be used to
+	// publish the name of the nodepool on its nodes
 	ServiceTopologyKey = "topology.kubernetes.io/zone"
 )
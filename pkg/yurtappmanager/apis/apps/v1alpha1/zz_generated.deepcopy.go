@@ -24,6 +24,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -94,6 +95,21 @@ func (in *IngressPool) DeepCopy() *IngressPool {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeMigrationStatus) DeepCopyInto(out *NodeMigrationStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeMigrationStatus.
+func (in *NodeMigrationStatus) DeepCopy() *NodeMigrationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeMigrationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodePool) DeepCopyInto(out *NodePool) {
 	*out = *in
@@ -121,6 +137,47 @@ func (in *NodePool) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolCondition) DeepCopyInto(out *NodePoolCondition) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolCondition.
+func (in *NodePoolCondition) DeepCopy() *NodePoolCondition {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolCondition)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolHealthPolicy) DeepCopyInto(out *NodePoolHealthPolicy) {
+	*out = *in
+	if in.MinReadyNodes != nil {
+		in, out := &in.MinReadyNodes, &out.MinReadyNodes
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnreadyNodes != nil {
+		in, out := &in.MaxUnreadyNodes, &out.MaxUnreadyNodes
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolHealthPolicy.
+func (in *NodePoolHealthPolicy) DeepCopy() *NodePoolHealthPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolHealthPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodePoolList) DeepCopyInto(out *NodePoolList) {
 	*out = *in
@@ -153,6 +210,154 @@ func (in *NodePoolList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolMaintenance) DeepCopyInto(out *NodePoolMaintenance) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolMaintenance.
+func (in *NodePoolMaintenance) DeepCopy() *NodePoolMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolMaintenanceStatus) DeepCopyInto(out *NodePoolMaintenanceStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolMaintenanceStatus.
+func (in *NodePoolMaintenanceStatus) DeepCopy() *NodePoolMaintenanceStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolMaintenanceStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolMembershipChange) DeepCopyInto(out *NodePoolMembershipChange) {
+	*out = *in
+	in.Time.DeepCopyInto(&out.Time)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolMembershipChange.
+func (in *NodePoolMembershipChange) DeepCopy() *NodePoolMembershipChange {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolMembershipChange)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolNamespaceQuota) DeepCopyInto(out *NodePoolNamespaceQuota) {
+	*out = *in
+	if in.Hard != nil {
+		in, out := &in.Hard, &out.Hard
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolNamespaceQuota.
+func (in *NodePoolNamespaceQuota) DeepCopy() *NodePoolNamespaceQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolNamespaceQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolNamespaceQuotaStatus) DeepCopyInto(out *NodePoolNamespaceQuotaStatus) {
+	*out = *in
+	if in.Used != nil {
+		in, out := &in.Used, &out.Used
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolNamespaceQuotaStatus.
+func (in *NodePoolNamespaceQuotaStatus) DeepCopy() *NodePoolNamespaceQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolNamespaceQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolQuota) DeepCopyInto(out *NodePoolQuota) {
+	*out = *in
+	if in.Hard != nil {
+		in, out := &in.Hard, &out.Hard
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]NodePoolNamespaceQuota, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolQuota.
+func (in *NodePoolQuota) DeepCopy() *NodePoolQuota {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolQuota)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodePoolQuotaStatus) DeepCopyInto(out *NodePoolQuotaStatus) {
+	*out = *in
+	if in.Used != nil {
+		in, out := &in.Used, &out.Used
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]NodePoolNamespaceQuotaStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolQuotaStatus.
+func (in *NodePoolQuotaStatus) DeepCopy() *NodePoolQuotaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodePoolQuotaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NodePoolSpec) DeepCopyInto(out *NodePoolSpec) {
 	*out = *in
@@ -182,6 +387,28 @@ func (in *NodePoolSpec) DeepCopyInto(out *NodePoolSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.HealthPolicy != nil {
+		in, out := &in.HealthPolicy, &out.HealthPolicy
+		*out = new(NodePoolHealthPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(NodePoolMaintenance)
+		**out = **in
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(NodePoolQuota)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImageRegistryMirrors != nil {
+		in, out := &in.ImageRegistryMirrors, &out.ImageRegistryMirrors
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolSpec.
@@ -202,6 +429,54 @@ func (in *NodePoolStatus) DeepCopyInto(out *NodePoolStatus) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Capacity != nil {
+		in, out := &in.Capacity, &out.Capacity
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.Allocatable != nil {
+		in, out := &in.Allocatable, &out.Allocatable
+		*out = make(corev1.ResourceList, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val.DeepCopy()
+		}
+	}
+	if in.ChildNodePools != nil {
+		in, out := &in.ChildNodePools, &out.ChildNodePools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]NodePoolCondition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(NodePoolMaintenanceStatus)
+		**out = **in
+	}
+	if in.Quota != nil {
+		in, out := &in.Quota, &out.Quota
+		*out = new(NodePoolQuotaStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RecentChanges != nil {
+		in, out := &in.RecentChanges, &out.RecentChanges
+		*out = make([]NodePoolMembershipChange, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Migrations != nil {
+		in, out := &in.Migrations, &out.Migrations
+		*out = make([]NodeMigrationStatus, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodePoolStatus.
@@ -230,6 +505,21 @@ func (in *Pool) DeepCopyInto(out *Pool) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Weight != nil {
+		in, out := &in.Weight, &out.Weight
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MinReplicas != nil {
+		in, out := &in.MinReplicas, &out.MinReplicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxReplicas != nil {
+		in, out := &in.MaxReplicas, &out.MaxReplicas
+		*out = new(int32)
+		**out = **in
+	}
 	if in.Patch != nil {
 		in, out := &in.Patch, &out.Patch
 		*out = new(runtime.RawExtension)
@@ -247,6 +537,150 @@ func (in *Pool) DeepCopy() *Pool {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolImagePrePull) DeepCopyInto(out *PoolImagePrePull) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolImagePrePull.
+func (in *PoolImagePrePull) DeepCopy() *PoolImagePrePull {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolImagePrePull)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PoolImagePrePull) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolImagePrePullList) DeepCopyInto(out *PoolImagePrePullList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PoolImagePrePull, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolImagePrePullList.
+func (in *PoolImagePrePullList) DeepCopy() *PoolImagePrePullList {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolImagePrePullList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PoolImagePrePullList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolImagePrePullNodeStatus) DeepCopyInto(out *PoolImagePrePullNodeStatus) {
+	*out = *in
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolImagePrePullNodeStatus.
+func (in *PoolImagePrePullNodeStatus) DeepCopy() *PoolImagePrePullNodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolImagePrePullNodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolImagePrePullSpec) DeepCopyInto(out *PoolImagePrePullSpec) {
+	*out = *in
+	if in.Images != nil {
+		in, out := &in.Images, &out.Images
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodePools != nil {
+		in, out := &in.NodePools, &out.NodePools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NodePoolSelector != nil {
+		in, out := &in.NodePoolSelector, &out.NodePoolSelector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.ActiveDeadlineSeconds != nil {
+		in, out := &in.ActiveDeadlineSeconds, &out.ActiveDeadlineSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolImagePrePullSpec.
+func (in *PoolImagePrePullSpec) DeepCopy() *PoolImagePrePullSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolImagePrePullSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PoolImagePrePullStatus) DeepCopyInto(out *PoolImagePrePullStatus) {
+	*out = *in
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]PoolImagePrePullNodeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PoolImagePrePullStatus.
+func (in *PoolImagePrePullStatus) DeepCopy() *PoolImagePrePullStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PoolImagePrePullStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *StatefulSetTemplateSpec) DeepCopyInto(out *StatefulSetTemplateSpec) {
 	*out = *in
@@ -329,6 +763,36 @@ func (in *UnitedDeploymentCondition) DeepCopy() *UnitedDeploymentCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitedDeploymentFailoverPolicy) DeepCopyInto(out *UnitedDeploymentFailoverPolicy) {
+	*out = *in
+	if in.FallbackPools != nil {
+		in, out := &in.FallbackPools, &out.FallbackPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingTimeoutSeconds != nil {
+		in, out := &in.PendingTimeoutSeconds, &out.PendingTimeoutSeconds
+		*out = new(int32)
+		**out = **in
+	}
+	if in.HoldDownSeconds != nil {
+		in, out := &in.HoldDownSeconds, &out.HoldDownSeconds
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitedDeploymentFailoverPolicy.
+func (in *UnitedDeploymentFailoverPolicy) DeepCopy() *UnitedDeploymentFailoverPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitedDeploymentFailoverPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UnitedDeploymentList) DeepCopyInto(out *UnitedDeploymentList) {
 	*out = *in
@@ -361,6 +825,37 @@ func (in *UnitedDeploymentList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitedDeploymentPoolFailover) DeepCopyInto(out *UnitedDeploymentPoolFailover) {
+	*out = *in
+	in.LastTransitionTime.DeepCopyInto(&out.LastTransitionTime)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitedDeploymentPoolFailover.
+func (in *UnitedDeploymentPoolFailover) DeepCopy() *UnitedDeploymentPoolFailover {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitedDeploymentPoolFailover)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitedDeploymentReplicasDistribution) DeepCopyInto(out *UnitedDeploymentReplicasDistribution) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitedDeploymentReplicasDistribution.
+func (in *UnitedDeploymentReplicasDistribution) DeepCopy() *UnitedDeploymentReplicasDistribution {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitedDeploymentReplicasDistribution)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *UnitedDeploymentSpec) DeepCopyInto(out *UnitedDeploymentSpec) {
 	*out = *in
@@ -369,6 +864,16 @@ func (in *UnitedDeploymentSpec) DeepCopyInto(out *UnitedDeploymentSpec) {
 		*out = new(v1.LabelSelector)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	if in.ReplicasDistribution != nil {
+		in, out := &in.ReplicasDistribution, &out.ReplicasDistribution
+		*out = new(UnitedDeploymentReplicasDistribution)
+		**out = **in
+	}
 	in.WorkloadTemplate.DeepCopyInto(&out.WorkloadTemplate)
 	in.Topology.DeepCopyInto(&out.Topology)
 	if in.RevisionHistoryLimit != nil {
@@ -376,6 +881,16 @@ func (in *UnitedDeploymentSpec) DeepCopyInto(out *UnitedDeploymentSpec) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.UpdateStrategy != nil {
+		in, out := &in.UpdateStrategy, &out.UpdateStrategy
+		*out = new(UnitedDeploymentUpdateStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.FailoverPolicy != nil {
+		in, out := &in.FailoverPolicy, &out.FailoverPolicy
+		*out = new(UnitedDeploymentFailoverPolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitedDeploymentSpec.
@@ -410,6 +925,18 @@ func (in *UnitedDeploymentStatus) DeepCopyInto(out *UnitedDeploymentStatus) {
 			(*out)[key] = val
 		}
 	}
+	if in.UpdateStatus != nil {
+		in, out := &in.UpdateStatus, &out.UpdateStatus
+		*out = new(UnitedDeploymentUpdateStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PoolFailovers != nil {
+		in, out := &in.PoolFailovers, &out.PoolFailovers
+		*out = make([]UnitedDeploymentPoolFailover, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitedDeploymentStatus.
@@ -422,6 +949,82 @@ func (in *UnitedDeploymentStatus) DeepCopy() *UnitedDeploymentStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitedDeploymentUpdateBatch) DeepCopyInto(out *UnitedDeploymentUpdateBatch) {
+	*out = *in
+	if in.Pools != nil {
+		in, out := &in.Pools, &out.Pools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitedDeploymentUpdateBatch.
+func (in *UnitedDeploymentUpdateBatch) DeepCopy() *UnitedDeploymentUpdateBatch {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitedDeploymentUpdateBatch)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitedDeploymentUpdateStatus) DeepCopyInto(out *UnitedDeploymentUpdateStatus) {
+	*out = *in
+	if in.UpdatedPools != nil {
+		in, out := &in.UpdatedPools, &out.UpdatedPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ReadyPools != nil {
+		in, out := &in.ReadyPools, &out.ReadyPools
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastBatchReadyTime != nil {
+		in, out := &in.LastBatchReadyTime, &out.LastBatchReadyTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitedDeploymentUpdateStatus.
+func (in *UnitedDeploymentUpdateStatus) DeepCopy() *UnitedDeploymentUpdateStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitedDeploymentUpdateStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnitedDeploymentUpdateStrategy) DeepCopyInto(out *UnitedDeploymentUpdateStrategy) {
+	*out = *in
+	if in.Batches != nil {
+		in, out := &in.Batches, &out.Batches
+		*out = make([]UnitedDeploymentUpdateBatch, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MaxUnavailablePools != nil {
+		in, out := &in.MaxUnavailablePools, &out.MaxUnavailablePools
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnitedDeploymentUpdateStrategy.
+func (in *UnitedDeploymentUpdateStrategy) DeepCopy() *UnitedDeploymentUpdateStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(UnitedDeploymentUpdateStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkloadTemplate) DeepCopyInto(out *WorkloadTemplate) {
 	*out = *in
@@ -27,6 +27,7 @@ import (
 type AppsV1alpha1Interface interface {
 	RESTClient() rest.Interface
 	NodePoolsGetter
+	PoolImagePrePullsGetter
 	UnitedDeploymentsGetter
 	YurtAppDaemonsGetter
 	YurtIngressesGetter
@@ -41,6 +42,10 @@ func (c *AppsV1alpha1Client) NodePools() NodePoolInterface {
 	return newNodePools(c)
 }
 
+func (c *AppsV1alpha1Client) PoolImagePrePulls(namespace string) PoolImagePrePullInterface {
+	return newPoolImagePrePulls(c, namespace)
+}
+
 func (c *AppsV1alpha1Client) UnitedDeployments(namespace string) UnitedDeploymentInterface {
 	return newUnitedDeployments(c, namespace)
 }
@@ -32,6 +32,10 @@ func (c *FakeAppsV1alpha1) NodePools() v1alpha1.NodePoolInterface {
 	return &FakeNodePools{c}
 }
 
+func (c *FakeAppsV1alpha1) PoolImagePrePulls(namespace string) v1alpha1.PoolImagePrePullInterface {
+	return &FakePoolImagePrePulls{c, namespace}
+}
+
 func (c *FakeAppsV1alpha1) UnitedDeployments(namespace string) v1alpha1.UnitedDeploymentInterface {
 	return &FakeUnitedDeployments{c, namespace}
 }
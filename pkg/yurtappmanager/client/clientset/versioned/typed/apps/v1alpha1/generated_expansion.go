@@ -20,6 +20,8 @@ package v1alpha1
 
 type NodePoolExpansion interface{}
 
+type PoolImagePrePullExpansion interface{}
+
 type UnitedDeploymentExpansion interface{}
 
 type YurtAppDaemonExpansion interface{}
@@ -26,6 +26,8 @@ import (
 type Interface interface {
 	// NodePools returns a NodePoolInformer.
 	NodePools() NodePoolInformer
+	// PoolImagePrePulls returns a PoolImagePrePullInformer.
+	PoolImagePrePulls() PoolImagePrePullInformer
 	// UnitedDeployments returns a UnitedDeploymentInformer.
 	UnitedDeployments() UnitedDeploymentInformer
 	// YurtAppDaemons returns a YurtAppDaemonInformer.
@@ -50,6 +52,11 @@ func (v *version) NodePools() NodePoolInformer {
 	return &nodePoolInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
 }
 
+// PoolImagePrePulls returns a PoolImagePrePullInformer.
+func (v *version) PoolImagePrePulls() PoolImagePrePullInformer {
+	return &poolImagePrePullInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
+}
+
 // UnitedDeployments returns a UnitedDeploymentInformer.
 func (v *version) UnitedDeployments() UnitedDeploymentInformer {
 	return &unitedDeploymentInformer{factory: v.factory, namespace: v.namespace, tweakListOptions: v.tweakListOptions}
@@ -55,6 +55,8 @@ func (f *sharedInformerFactory) ForResource(resource schema.GroupVersionResource
 	// Group=apps.openyurt.io, Version=v1alpha1
 	case v1alpha1.SchemeGroupVersion.WithResource("nodepools"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().NodePools().Informer()}, nil
+	case v1alpha1.SchemeGroupVersion.WithResource("poolimageprepulls"):
+		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().PoolImagePrePulls().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("uniteddeployments"):
 		return &genericInformer{resource: resource.GroupResource(), informer: f.Apps().V1alpha1().UnitedDeployments().Informer()}, nil
 	case v1alpha1.SchemeGroupVersion.WithResource("yurtappdaemons"):
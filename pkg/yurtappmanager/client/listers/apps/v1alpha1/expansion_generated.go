@@ -22,6 +22,14 @@ package v1alpha1
 // NodePoolLister.
 type NodePoolListerExpansion interface{}
 
+// PoolImagePrePullListerExpansion allows custom methods to be added to
+// PoolImagePrePullLister.
+type PoolImagePrePullListerExpansion interface{}
+
+// PoolImagePrePullNamespaceListerExpansion allows custom methods to be added to
+// PoolImagePrePullNamespaceLister.
+type PoolImagePrePullNamespaceListerExpansion interface{}
+
 // UnitedDeploymentListerExpansion allows custom methods to be added to
 // UnitedDeploymentLister.
 type UnitedDeploymentListerExpansion interface{}
@@ -19,4 +19,12 @@ package constant
 const (
 	// ContextKeyCreateDefaultPool indicate whether creating the default nodepools
 	ContextKeyCreateDefaultPool = "CreateDefaultPool"
+
+	// ContextKeyNodePoolTopologyKey indicates the label key used to publish
+	// the nodepool name on the member nodes
+	ContextKeyNodePoolTopologyKey = "NodePoolTopologyKey"
+
+	// ContextKeyNodePoolLeaseNamespace indicates the namespace of the Leases
+	// that record the leader nodes of the nodepools
+	ContextKeyNodePoolLeaseNamespace = "NodePoolLeaseNamespace"
 )
@@ -25,6 +25,8 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/nodepool"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/poolcontext"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/poolimageprepull"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/uniteddeployment"
 	yurtappdaemon "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappdaemon"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtingress"
@@ -33,7 +35,7 @@ import (
 var controllerAddFuncs []func(manager.Manager, context.Context) error
 
 func init() {
-	controllerAddFuncs = append(controllerAddFuncs, uniteddeployment.Add, nodepool.Add, yurtappdaemon.Add, yurtingress.Add)
+	controllerAddFuncs = append(controllerAddFuncs, uniteddeployment.Add, nodepool.Add, yurtappdaemon.Add, yurtingress.Add, poolcontext.Add, poolimageprepull.Add)
 }
 
 func SetupWithManager(m manager.Manager, ctx context.Context) error {
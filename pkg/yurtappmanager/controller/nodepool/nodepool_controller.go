@@ -27,8 +27,11 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	kubeclientset "k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
@@ -36,14 +39,17 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	extclient "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/client"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/constant"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/gate"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
 )
 
 const controllerName = "nodepool-controller"
@@ -55,14 +61,22 @@ type NodePoolReconciler struct {
 	client.Client
 	Scheme *runtime.Scheme
 
+	kubeClient        kubeclientset.Interface
 	recorder          record.EventRecorder
 	createDefaultPool bool
+	topologyKey       string
+	leaseNamespace    string
+	leases            *leaseCache
 }
 
 type NodePoolRelatedAttributes struct {
 	Labels      map[string]string `json:"labels,omitempty"`
 	Annotations map[string]string `json:"annotations,omitempty"`
 	Taints      []corev1.Taint    `json:"taints,omitempty"`
+	// OriginalLabels records the values of the node labels that are
+	// overwritten by the pool, they are restored when the node leaves the
+	// pool or the pool stops setting them
+	OriginalLabels map[string]string `json:"originalLabels,omitempty"`
 }
 
 // Add creates a new NodePool Controller and adds it to the Manager with default RBAC.
@@ -77,16 +91,31 @@ func Add(mgr manager.Manager, ctx context.Context) error {
 	if !ok {
 		return errors.New("fail to assert interface to bool for command line option createDefaultPool")
 	}
-	return add(mgr, newReconciler(mgr, cdp))
+	inf = ctx.Value(constant.ContextKeyNodePoolTopologyKey)
+	tk, ok := inf.(string)
+	if !ok {
+		return errors.New("fail to assert interface to string for command line option nodePoolTopologyKey")
+	}
+	inf = ctx.Value(constant.ContextKeyNodePoolLeaseNamespace)
+	ln, ok := inf.(string)
+	if !ok {
+		return errors.New("fail to assert interface to string for command line option nodePoolLeaseNamespace")
+	}
+	return add(mgr, newReconciler(mgr, cdp, tk, ln))
 }
 
 // newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager, createDefaultPool bool) reconcile.Reconciler {
+func newReconciler(mgr manager.Manager, createDefaultPool bool,
+	topologyKey, leaseNamespace string) reconcile.Reconciler {
 	return &NodePoolReconciler{
 		Client:            mgr.GetClient(),
 		Scheme:            mgr.GetScheme(),
+		kubeClient:        extclient.GetGenericClient().KubeClient,
 		recorder:          mgr.GetEventRecorderFor(controllerName),
 		createDefaultPool: createDefaultPool,
+		topologyKey:       topologyKey,
+		leaseNamespace:    leaseNamespace,
+		leases:            newLeaseCache(),
 	}
 }
 
@@ -106,10 +135,14 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return errors.New("fail to assert interface to NodePoolReconciler")
 	}
 
+	// the NodePool handler refreshes the selectors that the Node handler
+	// resolves the nodepools of the nodes with
+	selectors := newNodePoolSelectorCache()
+
 	// Watch for changes to NodePool
 	err = c.Watch(&source.Kind{
 		Type: &appsv1alpha1.NodePool{}},
-		&handler.EnqueueRequestForObject{})
+		&EnqueueNodePoolForNodePool{Client: npr.Client, selectors: selectors})
 	if err != nil {
 		return err
 	}
@@ -117,11 +150,22 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 	// Watch for changes to Node
 	err = c.Watch(&source.Kind{
 		Type: &corev1.Node{}},
-		&EnqueueNodePoolForNode{})
+		&EnqueueNodePoolForNode{Client: npr.Client, selectors: selectors}, nodePredicate)
 	if err != nil {
 		return err
 	}
 
+	if npr.leaseNamespace != "" {
+		// renew the Leases of the pool leaders periodically
+		err = mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			wait.UntilWithContext(ctx, npr.renewLeaderLeases, leaderLeaseRenewInterval)
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+	}
+
 	if npr.createDefaultPool {
 		// register a node controller with the underlying informer of the manager
 		go createDefaultNodePool(mgr.GetClient())
@@ -167,37 +211,85 @@ func createDefaultNodePool(client client.Client) {
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=core,resources=pods/eviction,verbs=create
 // +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=events,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;create;update
 
-func (r *NodePoolReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *NodePoolReconciler) Reconcile(_ context.Context, req ctrl.Request) (_ ctrl.Result, err error) {
 	ctx := context.Background()
+	defer func() {
+		if err != nil {
+			nodePoolReconcileErrors.WithLabelValues(req.Name).Inc()
+		}
+	}()
+
 	var nodePool appsv1alpha1.NodePool
 	// try to reconcile the NodePool object
 	if err := r.Get(ctx, req.NamespacedName, &nodePool); err != nil {
+		if apierrors.IsNotFound(err) {
+			deleteNodePoolMetrics(req.Name)
+		}
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	var desiredNodeList corev1.NodeList
-	if err := r.List(ctx, &desiredNodeList, client.MatchingLabels(map[string]string{
-		appsv1alpha1.LabelDesiredNodePool: nodePool.GetName(),
-	})); err != nil {
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+	// handle the nodepool deletion
+	if !nodePool.DeletionTimestamp.IsZero() {
+		return r.cleanupNodePool(ctx, &nodePool)
 	}
 
-	var currentNodeList corev1.NodeList
-	if err := r.List(ctx, &currentNodeList, client.MatchingLabels(map[string]string{
-		appsv1alpha1.LabelCurrentNodePool: nodePool.GetName(),
-	})); err != nil {
-		return ctrl.Result{}, client.IgnoreNotFound(err)
+	// add finalizer if not exist
+	if !controllerutil.ContainsFinalizer(&nodePool, appsv1alpha1.NodePoolFinalizer) {
+		controllerutil.AddFinalizer(&nodePool, appsv1alpha1.NodePoolFinalizer)
+		if err := r.Update(ctx, &nodePool); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	var poolList appsv1alpha1.NodePoolList
+	if err := r.List(ctx, &poolList); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// the member nodes inherit the attributes of the ancestor pools
+	attrs := nodePoolRelatedAttrs(
+		nodepoolutil.InheritAttributes(&nodePool, poolList.Items), r.topologyKey)
+
+	// 0. move the nodes that are migrating to the pool forward
+	migrations, err := r.conciliateMigrations(ctx, &nodePool, poolList.Items)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// nodes switched by the migrations are up to date, skip them in case the
+	// cache is not synced yet
+	switchedNodes := make(map[string]struct{})
+	// fromPools records the pools that the joining nodes come from
+	fromPools := make(map[string]string)
+	for _, migration := range migrations {
+		if migration.Phase == appsv1alpha1.MigrationSwitching {
+			switchedNodes[migration.Node] = struct{}{}
+			fromPools[migration.Node] = migration.FromNodePool
+		}
+	}
+
+	desiredNodes, err := r.listDesiredNodes(ctx, &nodePool, poolList.Items)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	currentNodes, err := r.listNodesByIndex(ctx,
+		fieldindex.IndexNameForNodeCurrentNodePool, nodePool.GetName())
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
 	// 1. handle the event of removing node out of the pool
-	// nodes in currentNodeList but not in the desiredNodeList, will be
+	// nodes in currentNodes but not in the desiredNodes, will be
 	// removed from the pool
 	var removedNodes []corev1.Node
-	for _, mNode := range currentNodeList.Items {
+	for _, mNode := range currentNodes {
 		var found bool
-		for _, dNode := range desiredNodeList.Items {
+		for _, dNode := range desiredNodes {
 			if mNode.GetName() == dNode.GetName() {
 				found = true
 				break
@@ -212,55 +304,337 @@ func (r *NodePoolReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctr
 		if err := removePoolRelatedAttrs(&rNode); err != nil {
 			return ctrl.Result{}, err
 		}
-		if err := r.Update(ctx, &rNode); err != nil {
+		if err := r.updateNode(ctx, nodePool.GetName(), &rNode); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
 
+	// elect the leader among the ready member nodes
+	leader, err := r.conciliateLeader(ctx, &nodePool, desiredNodes)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	var (
 		readyNode    int32
 		notReadyNode int32
 		nodes        []string
+		capacity     = corev1.ResourceList{}
+		allocatable  = corev1.ResourceList{}
 	)
 
 	// 2. handle the event of adding node to the pool and the event of
 	// updating node pool attributes
-	for _, node := range desiredNodeList.Items {
+	for i, node := range desiredNodes {
 		nodes = append(nodes, node.GetName())
 		if isNodeReady(node) {
 			readyNode += 1
+			addResourceList(capacity, node.Status.Capacity)
+			addResourceList(allocatable, node.Status.Allocatable)
 		} else {
 			notReadyNode += 1
 		}
+		if _, exist := switchedNodes[node.GetName()]; exist {
+			continue
+		}
 
-		attrUpdated, err := conciliatePoolRelatedAttrs(&node,
-			NodePoolRelatedAttributes{
-				Labels:      nodePool.Spec.Labels,
-				Annotations: nodePool.Spec.Annotations,
-				Taints:      nodePool.Spec.Taints,
-			})
+		attrUpdated, err := conciliatePoolRelatedAttrs(&node, attrs)
 		if err != nil {
 			return ctrl.Result{}, err
 		}
+		typeLabelUpdated := conciliateNodeTypeLabel(&node, &nodePool)
+		leaderLabelUpdated := conciliateLeaderLabel(&node, leader)
+		// cordon the node if the pool is under maintenance, the migrating
+		// nodes are left to the migration
+		var cordonUpdated bool
+		switch {
+		case isNodeMigrating(&node):
+		case nodePool.Spec.Maintenance != nil:
+			cordonUpdated = cordonNode(&node, nodePool.GetName())
+		default:
+			cordonUpdated = uncordonNode(&node)
+		}
 		var ownerLabelUpdated bool
-		if node.Labels[appsv1alpha1.LabelCurrentNodePool] != nodePool.GetName() {
+		fromPool := node.Labels[appsv1alpha1.LabelCurrentNodePool]
+		if fromPool != nodePool.GetName() {
 			ownerLabelUpdated = true
+			fromPools[node.GetName()] = fromPool
 			if len(node.Labels) == 0 {
 				node.Labels = make(map[string]string)
 			}
 			node.Labels[appsv1alpha1.LabelCurrentNodePool] = nodePool.GetName()
 		}
 
-		if attrUpdated || ownerLabelUpdated {
-			if err := r.Update(ctx, &node); err != nil {
+		if attrUpdated || typeLabelUpdated || leaderLabelUpdated || cordonUpdated || ownerLabelUpdated {
+			if err := r.updateNode(ctx, nodePool.GetName(), &node); err != nil {
 				klog.Errorf("Update Node %s error %v", node.Name, err)
 				return ctrl.Result{}, err
 			}
 		}
+		if attrUpdated && !ownerLabelUpdated {
+			r.recordNodeAttrsApplied(&nodePool, &node)
+		}
+		desiredNodes[i] = node
+	}
+
+	// record the nodes joining and leaving the pool
+	changes := r.conciliateMembershipChanges(ctx, &nodePool, desiredNodes, fromPools, poolList.Items)
+
+	// 3. drain the nodes if the pool is under maintenance
+	maintenance, err := r.conciliateMaintenance(ctx, &nodePool, desiredNodes)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 4. calculate the resources used by the pods if the pool has a quota
+	quota, err := r.conciliateQuota(ctx, &nodePool)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// 5. always update the node pool status if necessary
+	recordNodePoolMetrics(&nodePool, readyNode, notReadyNode)
+	result, err := conciliateNodePoolStatus(r.Client, r.recorder, &observedNodePoolStatus{
+		readyNode:    readyNode,
+		notReadyNode: notReadyNode,
+		nodes:        nodes,
+		capacity:     capacity,
+		allocatable:  allocatable,
+		leader:       leader,
+		maintenance:  maintenance,
+		quota:        quota,
+		migrations:   migrations,
+		changes:      membershipChangeStatuses(changes),
+	}, poolList.Items, &nodePool)
+	if err != nil {
+		return result, err
+	}
+	r.recordMembershipChanges(&nodePool, changes)
+	// the shortest interval wins
+	switch {
+	case len(migrations) != 0 || (maintenance != nil &&
+		maintenance.Phase == appsv1alpha1.MaintenanceInProgress):
+		result.RequeueAfter = maintenanceRequeueInterval
+	case quota != nil:
+		result.RequeueAfter = quotaSyncInterval
+	}
+	return result, nil
+}
+
+// cleanupNodePool removes the pool related attributes from all member nodes
+// before the nodepool is deleted. Nodes that still have the desired-nodepool
+// label pointing to the pool are handled based on the pool DeletionPolicy
+func (r *NodePoolReconciler) cleanupNodePool(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(nodePool, appsv1alpha1.NodePoolFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	// abort the migrations to the pool, so that the nodes will not be
+	// cordoned forever
+	if err := r.abortMigrations(ctx, nodePool); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	desiredNodes, err := r.listNodesByIndex(ctx,
+		fieldindex.IndexNameForNodeDesiredNodePool, nodePool.GetName())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	policy := nodePool.Spec.DeletionPolicy
+	if policy == appsv1alpha1.BlockDeletion && len(desiredNodes) != 0 {
+		// the pool will be enqueued again once the desired-nodepool label
+		// of its nodes are removed
+		r.recorder.Eventf(nodePool, corev1.EventTypeWarning, "DeletionBlocked",
+			"%d nodes still have the %s label pointing to the pool",
+			len(desiredNodes), appsv1alpha1.LabelDesiredNodePool)
+		return ctrl.Result{}, nil
+	}
+
+	currentNodes, err := r.listNodesByIndex(ctx,
+		fieldindex.IndexNameForNodeCurrentNodePool, nodePool.GetName())
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// a node may be in both lists, so we merge them to update it only once
+	nodes := make(map[string]corev1.Node)
+	for _, node := range currentNodes {
+		nodes[node.GetName()] = node
+	}
+	if policy == appsv1alpha1.RemoveDesiredLabel {
+		for _, node := range desiredNodes {
+			if _, exist := nodes[node.GetName()]; !exist {
+				nodes[node.GetName()] = node
+			}
+		}
+	}
+
+	for _, node := range nodes {
+		isMember := node.Labels[appsv1alpha1.LabelCurrentNodePool] == nodePool.GetName()
+		if isMember {
+			if err := removePoolRelatedAttrs(&node); err != nil {
+				return ctrl.Result{}, err
+			}
+			delete(node.Labels, appsv1alpha1.LabelCurrentNodePool)
+		}
+		if policy == appsv1alpha1.RemoveDesiredLabel &&
+			node.Labels[appsv1alpha1.LabelDesiredNodePool] == nodePool.GetName() {
+			delete(node.Labels, appsv1alpha1.LabelDesiredNodePool)
+		}
+		if err := r.updateNode(ctx, nodePool.GetName(), &node); err != nil {
+			klog.Errorf("Update Node %s error %v", node.Name, err)
+			return ctrl.Result{}, err
+		}
+		if isMember {
+			// the status is not updated as the pool is being deleted, the
+			// node has left once it is updated
+			r.recordMembershipChanges(nodePool, []membershipChange{nodeLeft(node.GetName(), &node, "")})
+		}
 	}
 
-	// 3. always update the node pool status if necessary
-	return conciliateNodePoolStatus(r.Client, readyNode, notReadyNode, nodes, &nodePool)
+	controllerutil.RemoveFinalizer(nodePool, appsv1alpha1.NodePoolFinalizer)
+	if err := r.Update(ctx, nodePool); err != nil {
+		return ctrl.Result{}, err
+	}
+	deleteNodePoolMetrics(nodePool.GetName())
+	return ctrl.Result{}, nil
+}
+
+// listDesiredNodes returns nodes that should belong to the nodePool, which
+// include nodes that have the desired-nodepool label pointing to the pool and
+// nodes that match the pool selector and are not claimed by other pools
+func (r *NodePoolReconciler) listDesiredNodes(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool,
+	pools []appsv1alpha1.NodePool) ([]corev1.Node, error) {
+	desiredNodes, err := r.listNodesByIndex(ctx,
+		fieldindex.IndexNameForNodeDesiredNodePool, nodePool.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	if selectorSpecificity(nodePool) == 0 {
+		return desiredNodes, nil
+	}
+	selector, err := nodePoolSelector(nodePool)
+	if err != nil {
+		return nil, err
+	}
+
+	// the selector can't be passed to the List, as the labels applied by the
+	// pools are not matched
+	var nodeList corev1.NodeList
+	if err := r.List(ctx, &nodeList); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return append(desiredNodes, selectDesiredNodes(nodePool, selector, nodeList.Items, pools)...), nil
+}
+
+// selectDesiredNodes returns the nodes without the desired-nodepool label
+// that are selected by the pool and resolved to the pool. The selector is
+// matched against the selectable labels of the nodes as resolveNodePool does,
+// so that the labels applied by the pools are not taken into account
+func selectDesiredNodes(nodePool *appsv1alpha1.NodePool, selector labels.Selector,
+	nodes []corev1.Node, pools []appsv1alpha1.NodePool) []corev1.Node {
+	var selected []corev1.Node
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Labels[appsv1alpha1.LabelDesiredNodePool] != "" {
+			// nodes with the desired label are listed by the index
+			continue
+		}
+		if !selector.Matches(selectableLabels(node)) {
+			continue
+		}
+		if resolveNodePool(node, pools) == nodePool.GetName() {
+			selected = append(selected, *node)
+		}
+	}
+	return selected
+}
+
+// nodePoolRelatedAttrs returns the attributes that the nodepool applies to
+// its member nodes. Besides the attributes in the NodePool.Spec, the pool name
+// is published under the topologyKey if it is not empty, and the autonomy
+// annotation is added if it is enabled for the Edge pool
+func nodePoolRelatedAttrs(nodePool *appsv1alpha1.NodePool,
+	topologyKey string) NodePoolRelatedAttributes {
+	labels := nodePool.Spec.Labels
+	if topologyKey != "" {
+		labels = make(map[string]string, len(nodePool.Spec.Labels)+1)
+		for k, v := range nodePool.Spec.Labels {
+			labels[k] = v
+		}
+		labels[topologyKey] = nodePool.GetName()
+	}
+	annotations := nodePool.Spec.Annotations
+	if typeAttributesEnabled(nodePool) && nodePool.Spec.Type == appsv1alpha1.Edge &&
+		nodePool.Spec.Autonomy {
+		annotations = make(map[string]string, len(nodePool.Spec.Annotations)+1)
+		for k, v := range nodePool.Spec.Annotations {
+			annotations[k] = v
+		}
+		annotations[appsv1alpha1.AnnotationNodeAutonomy] = "true"
+	}
+	return NodePoolRelatedAttributes{
+		Labels:      labels,
+		Annotations: annotations,
+		Taints:      nodePool.Spec.Taints,
+	}
+}
+
+// typeAttributesEnabled checks if the pool sets the attributes derived from
+// its type on the member nodes
+func typeAttributesEnabled(nodePool *appsv1alpha1.NodePool) bool {
+	return nodePool.Spec.Type != "" && !nodePool.Spec.DisableTypeAttributes
+}
+
+// conciliateNodeTypeLabel sets the `openyurt.io/is-edge-worker` label of the
+// node based on the pool type, it returns true if the node is changed.
+// N.B. unlike the other pool related attributes, the label is not removed
+// when the node leaves the pool, as it describes the node itself and may be
+// used by the pool selectors
+func conciliateNodeTypeLabel(node *corev1.Node, nodePool *appsv1alpha1.NodePool) bool {
+	if !typeAttributesEnabled(nodePool) {
+		return false
+	}
+	isEdgeWorker := "false"
+	if nodePool.Spec.Type == appsv1alpha1.Edge {
+		isEdgeWorker = "true"
+	}
+	if v, exist := node.Labels[appsv1alpha1.LabelEdgeWorker]; exist && v == isEdgeWorker {
+		return false
+	}
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	node.Labels[appsv1alpha1.LabelEdgeWorker] = isEdgeWorker
+	return true
+}
+
+// updateNode updates the node and records the duration of the update
+func (r *NodePoolReconciler) updateNode(ctx context.Context,
+	poolName string, node *corev1.Node) error {
+	start := time.Now()
+	defer func() {
+		nodeUpdateDuration.WithLabelValues(poolName).Observe(time.Since(start).Seconds())
+	}()
+	return r.Update(ctx, node)
+}
+
+// listNodesByIndex lists nodes whose nodepool label, which is specified by
+// the field index, pointing to the pool. The field index avoids scanning all
+// nodes in the cache
+func (r *NodePoolReconciler) listNodesByIndex(ctx context.Context,
+	index, poolName string) ([]corev1.Node, error) {
+	var nodeList corev1.NodeList
+	if err := r.List(ctx, &nodeList, client.MatchingFields{
+		index: poolName,
+	}); err != nil {
+		return nil, err
+	}
+	return nodeList.Items, nil
 }
 
 // removePoolRelatedAttrs removes attributes(label/annotation/taint) that
@@ -268,6 +642,10 @@ func (r *NodePoolReconciler) Reconcile(_ context.Context, req ctrl.Request) (ctr
 func removePoolRelatedAttrs(node *corev1.Node) error {
 	var npra NodePoolRelatedAttributes
 
+	// the node should not be kept unschedulable by the pool it leaves
+	uncordonNode(node)
+	delete(node.Labels, appsv1alpha1.LabelNodePoolLeader)
+
 	if _, exist := node.Annotations[appsv1alpha1.AnnotationPrevAttrs]; !exist {
 		return nil
 	}
@@ -279,7 +657,12 @@ func removePoolRelatedAttrs(node *corev1.Node) error {
 	}
 
 	for lk, lv := range npra.Labels {
-		if node.Labels[lk] == lv {
+		if node.Labels[lk] != lv {
+			continue
+		}
+		if ov, exist := npra.OriginalLabels[lk]; exist {
+			node.Labels[lk] = ov
+		} else {
 			delete(node.Labels, lk)
 		}
 	}
@@ -310,6 +693,7 @@ func conciliatePoolRelatedAttrs(node *corev1.Node,
 	var attrUpdated bool
 	preAttrs, exist := node.Annotations[appsv1alpha1.AnnotationPrevAttrs]
 	if !exist {
+		npra.OriginalLabels = originalLabels(node, NodePoolRelatedAttributes{}, npra)
 		node.Labels = mergeMap(node.Labels, npra.Labels)
 		node.Annotations = mergeMap(node.Annotations, npra.Annotations)
 		for _, npt := range npra.Taints {
@@ -332,9 +716,15 @@ func conciliatePoolRelatedAttrs(node *corev1.Node,
 	if err := json.Unmarshal([]byte(preAttrs), &preNpra); err != nil {
 		return attrUpdated, err
 	}
+	npra.OriginalLabels = originalLabels(node, preNpra, npra)
 	if !reflect.DeepEqual(preNpra, npra) {
 		// pool related attributes will be updated
 		conciliateLabels(node, preNpra.Labels, npra.Labels)
+		for k, v := range preNpra.OriginalLabels {
+			if _, exist := npra.Labels[k]; !exist {
+				node.Labels[k] = v
+			}
+		}
 		conciliateAnnotations(node, preNpra.Annotations, npra.Annotations)
 		conciliateTaints(node, preNpra.Taints, npra.Taints)
 		if err := cachePrevPoolAttrs(node, npra); err != nil {
@@ -345,6 +735,30 @@ func conciliatePoolRelatedAttrs(node *corev1.Node,
 	return attrUpdated, nil
 }
 
+// originalLabels returns the values of the node labels that are overwritten
+// by the pool. The values recorded when the pool started setting the labels
+// are kept, and the labels already set by the pool are not recorded again
+func originalLabels(node *corev1.Node, preNpra,
+	npra NodePoolRelatedAttributes) map[string]string {
+	var originals map[string]string
+	for k, v := range npra.Labels {
+		ov, exist := preNpra.OriginalLabels[k]
+		if !exist {
+			if _, managed := preNpra.Labels[k]; managed {
+				continue
+			}
+			if ov, exist = node.Labels[k]; !exist || ov == v {
+				continue
+			}
+		}
+		if originals == nil {
+			originals = make(map[string]string)
+		}
+		originals[k] = ov
+	}
+	return originals
+}
+
 // conciliateLabels will update the node's label that related to the nodepool
 func conciliateLabels(node *corev1.Node, oldLabels, newLabels map[string]string) {
 	// 1. remove labels from the node if they have been removed from the
@@ -392,37 +806,141 @@ func conciliateTaints(node *corev1.Node, oldTaints, newTaints []corev1.Taint) {
 	}
 }
 
+// observedNodePoolStatus is the state of the pool observed by the
+// reconciliation, which the status of the pool is conciliated with
+type observedNodePoolStatus struct {
+	readyNode    int32
+	notReadyNode int32
+	nodes        []string
+	capacity     corev1.ResourceList
+	allocatable  corev1.ResourceList
+	leader       string
+	maintenance  *appsv1alpha1.NodePoolMaintenanceStatus
+	quota        *appsv1alpha1.NodePoolQuotaStatus
+	migrations   []appsv1alpha1.NodeMigrationStatus
+	changes      []appsv1alpha1.NodePoolMembershipChange
+}
+
 // conciliateNodePoolStatus will update the nodepool status
 func conciliateNodePoolStatus(cli client.Client,
-	readyNode,
-	notReadyNode int32,
-	nodes []string,
+	recorder record.EventRecorder,
+	observed *observedNodePoolStatus,
+	pools []appsv1alpha1.NodePool,
 	nodePool *appsv1alpha1.NodePool) (ctrl.Result, error) {
-	var updateNodePool bool
-	if readyNode != nodePool.Status.ReadyNodeNum {
-		nodePool.Status.ReadyNodeNum = readyNode
-		updateNodePool = true
-	}
+	oldStatus := nodePool.Status.DeepCopy()
+	updateNodePool := conciliateMemberStatus(&nodePool.Status, observed)
+	updateNodePool = conciliateProgressStatus(&nodePool.Status, observed) || updateNodePool
+	updateNodePool = conciliateHierarchyStatus(nodePool, pools, observed) || updateNodePool
 
-	if notReadyNode != nodePool.Status.UnreadyNodeNum {
-		nodePool.Status.UnreadyNodeNum = notReadyNode
+	// update the conditions on demand, the nodes of the descendant pools are
+	// taken into account
+	calculateNodePoolConditions(nodePool, &nodePool.Status,
+		nodePool.Status.TotalReadyNodeNum, nodePool.Status.TotalUnreadyNodeNum)
+	if !reflect.DeepEqual(oldStatus.Conditions, nodePool.Status.Conditions) {
 		updateNodePool = true
 	}
 
-	// update the node list on demand
-	sort.Strings(nodes)
-	sort.Strings(nodePool.Status.Nodes)
-	if !reflect.DeepEqual(nodes, nodePool.Status.Nodes) {
-		nodePool.Status.Nodes = nodes
-		updateNodePool = true
-	}
 	// update the nodepool on demand
 	if updateNodePool {
-		return ctrl.Result{}, cli.Status().Update(context.Background(), nodePool)
+		if err := cli.Status().Update(context.Background(), nodePool); err != nil {
+			return ctrl.Result{}, err
+		}
+		recordNodePoolConditionTransitions(recorder, nodePool, oldStatus, &nodePool.Status)
 	}
 	return ctrl.Result{}, nil
 }
 
+// conciliateMemberStatus updates the member nodes, their resources, the
+// leader and the membership changes of the status on demand, it returns true
+// if the status is changed
+func conciliateMemberStatus(status *appsv1alpha1.NodePoolStatus,
+	observed *observedNodePoolStatus) bool {
+	var updated bool
+	if observed.readyNode != status.ReadyNodeNum {
+		status.ReadyNodeNum = observed.readyNode
+		updated = true
+	}
+
+	if observed.notReadyNode != status.UnreadyNodeNum {
+		status.UnreadyNodeNum = observed.notReadyNode
+		updated = true
+	}
+
+	// update the node list on demand
+	sort.Strings(observed.nodes)
+	sort.Strings(status.Nodes)
+	if !reflect.DeepEqual(observed.nodes, status.Nodes) {
+		status.Nodes = observed.nodes
+		updated = true
+	}
+
+	// update the aggregated resources on demand
+	if !resourceListEqual(observed.capacity, status.Capacity) {
+		status.Capacity = observed.capacity
+		updated = true
+	}
+	if !resourceListEqual(observed.allocatable, status.Allocatable) {
+		status.Allocatable = observed.allocatable
+		updated = true
+	}
+
+	// update the leader on demand
+	if observed.leader != status.LeaderNode {
+		status.LeaderNode = observed.leader
+		updated = true
+	}
+
+	// record the membership changes
+	if len(observed.changes) != 0 {
+		status.RecentChanges = appendMembershipChanges(status.RecentChanges, observed.changes)
+		updated = true
+	}
+	return updated
+}
+
+// conciliateProgressStatus updates the progress of the maintenance, the quota
+// usage and the progress of the migrations on demand, it returns true if the
+// status is changed
+func conciliateProgressStatus(status *appsv1alpha1.NodePoolStatus,
+	observed *observedNodePoolStatus) bool {
+	var updated bool
+	if !reflect.DeepEqual(observed.maintenance, status.Maintenance) {
+		status.Maintenance = observed.maintenance
+		updated = true
+	}
+
+	if !quotaStatusEqual(observed.quota, status.Quota) {
+		status.Quota = observed.quota
+		updated = true
+	}
+
+	if !reflect.DeepEqual(observed.migrations, status.Migrations) {
+		status.Migrations = observed.migrations
+		updated = true
+	}
+	return updated
+}
+
+// conciliateHierarchyStatus rolls up the node counts of the descendant pools
+// on demand, it returns true if the status is changed
+func conciliateHierarchyStatus(nodePool *appsv1alpha1.NodePool,
+	pools []appsv1alpha1.NodePool, observed *observedNodePoolStatus) bool {
+	var updated bool
+	children, totalReadyNode, totalNotReadyNode := rollupNodePoolStatus(
+		nodePool, pools, observed.readyNode, observed.notReadyNode)
+	if !reflect.DeepEqual(children, nodePool.Status.ChildNodePools) {
+		nodePool.Status.ChildNodePools = children
+		updated = true
+	}
+	if totalReadyNode != nodePool.Status.TotalReadyNodeNum ||
+		totalNotReadyNode != nodePool.Status.TotalUnreadyNodeNum {
+		nodePool.Status.TotalReadyNodeNum = totalReadyNode
+		nodePool.Status.TotalUnreadyNodeNum = totalNotReadyNode
+		updated = true
+	}
+	return updated
+}
+
 // containTaint checks if `taint` is in `taints`, if yes it will return
 // the index of the taint and true, otherwise, it will return 0 and false.
 // N.B. the uniqueness of the taint is based on both key and effect pair
@@ -452,6 +970,33 @@ func mergeMap(m1, m2 map[string]string) map[string]string {
 	return m1
 }
 
+// addResourceList adds the resources in `rl` to `total`
+func addResourceList(total, rl corev1.ResourceList) {
+	for name, quantity := range rl {
+		if value, exist := total[name]; exist {
+			value.Add(quantity)
+			total[name] = value
+			continue
+		}
+		total[name] = quantity.DeepCopy()
+	}
+}
+
+// resourceListEqual checks if two ResourceLists contain the same resources
+// with the same quantities
+func resourceListEqual(rl1, rl2 corev1.ResourceList) bool {
+	if len(rl1) != len(rl2) {
+		return false
+	}
+	for name, q1 := range rl1 {
+		q2, exist := rl2[name]
+		if !exist || q1.Cmp(q2) != 0 {
+			return false
+		}
+	}
+	return true
+}
+
 // removeTaint removes `taint` from `taints` if exist
 func removeTaint(taint corev1.Taint, taints []corev1.Taint) []corev1.Taint {
 	for i := 0; i < len(taints); i++ {
@@ -481,6 +1026,9 @@ func cachePrevPoolAttrs(node *corev1.Node,
 // addNodePoolToWorkQueue adds the nodepool the reconciler's workqueue
 func addNodePoolToWorkQueue(npName string,
 	q workqueue.RateLimitingInterface) {
+	if npName == "" {
+		return
+	}
 	q.Add(reconcile.Request{
 		NamespacedName: types.NamespacedName{Name: npName},
 	})
@@ -22,7 +22,10 @@ import (
 	"testing"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
 )
 
 const (
@@ -668,3 +671,454 @@ func TestRemoveTaint(t *testing.T) {
 		t.Run(st.name, tf)
 	}
 }
+
+func TestAddResourceList(t *testing.T) {
+	tests := []struct {
+		name   string
+		total  corev1.ResourceList
+		rl     corev1.ResourceList
+		expect corev1.ResourceList
+	}{
+		{
+			"add to empty list",
+			corev1.ResourceList{},
+			corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+			corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("2"),
+				corev1.ResourceMemory: resource.MustParse("4Gi"),
+			},
+		},
+		{
+			"add existing and extended resources",
+			corev1.ResourceList{
+				corev1.ResourceCPU:  resource.MustParse("1500m"),
+				corev1.ResourcePods: resource.MustParse("110"),
+			},
+			corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("2"),
+				corev1.ResourcePods:             resource.MustParse("110"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("20Gi"),
+				"nvidia.com/gpu":                resource.MustParse("1"),
+			},
+			corev1.ResourceList{
+				corev1.ResourceCPU:              resource.MustParse("3500m"),
+				corev1.ResourcePods:             resource.MustParse("220"),
+				corev1.ResourceEphemeralStorage: resource.MustParse("20Gi"),
+				"nvidia.com/gpu":                resource.MustParse("1"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				addResourceList(st.total, st.rl)
+				if !resourceListEqual(st.total, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, st.total)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, st.total)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestResourceListEqual(t *testing.T) {
+	tests := []struct {
+		name   string
+		rl1    corev1.ResourceList
+		rl2    corev1.ResourceList
+		expect bool
+	}{
+		{
+			"nil and empty lists",
+			nil,
+			corev1.ResourceList{},
+			true,
+		},
+		{
+			"same quantities in different formats",
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1000m")},
+			true,
+		},
+		{
+			"different quantities",
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+			false,
+		},
+		{
+			"different resources",
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("1")},
+			false,
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := resourceListEqual(st.rl1, st.rl2)
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestNodePoolRelatedAttrs(t *testing.T) {
+	np := &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+		Spec: appsv1alpha1.NodePoolSpec{
+			Labels: map[string]string{"foo": "bar"},
+		},
+	}
+	tests := []struct {
+		name        string
+		topologyKey string
+		expect      map[string]string
+	}{
+		{
+			"topology key is disabled",
+			"",
+			map[string]string{"foo": "bar"},
+		},
+		{
+			"pool name is published under the topology key",
+			appsv1alpha1.ServiceTopologyKey,
+			map[string]string{
+				"foo":                           "bar",
+				appsv1alpha1.ServiceTopologyKey: "hangzhou",
+			},
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := nodePoolRelatedAttrs(np, st.topologyKey).Labels
+				if !reflect.DeepEqual(get, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+
+	if _, exist := np.Spec.Labels[appsv1alpha1.ServiceTopologyKey]; exist {
+		t.Errorf("the labels of the nodepool spec should not be changed")
+	}
+}
+
+func TestConciliateNodeTypeLabel(t *testing.T) {
+	tests := []struct {
+		name          string
+		pool          *appsv1alpha1.NodePool
+		labels        map[string]string
+		expectUpdated bool
+		expect        string
+	}{
+		{
+			"edge pool",
+			&appsv1alpha1.NodePool{Spec: appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Edge}},
+			nil,
+			true,
+			"true",
+		},
+		{
+			"cloud pool",
+			&appsv1alpha1.NodePool{Spec: appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Cloud}},
+			map[string]string{appsv1alpha1.LabelEdgeWorker: "true"},
+			true,
+			"false",
+		},
+		{
+			"label is up to date",
+			&appsv1alpha1.NodePool{Spec: appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Edge}},
+			map[string]string{appsv1alpha1.LabelEdgeWorker: "true"},
+			false,
+			"true",
+		},
+		{
+			"type attributes are disabled",
+			&appsv1alpha1.NodePool{Spec: appsv1alpha1.NodePoolSpec{
+				Type:                  appsv1alpha1.Cloud,
+				DisableTypeAttributes: true,
+			}},
+			map[string]string{appsv1alpha1.LabelEdgeWorker: "true"},
+			false,
+			"true",
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: st.labels}}
+				updated := conciliateNodeTypeLabel(node, st.pool)
+				get := node.Labels[appsv1alpha1.LabelEdgeWorker]
+				if updated != st.expectUpdated || get != st.expect {
+					t.Fatalf("\t%s\texpect %v(%v), but get %v(%v)", failed,
+						st.expect, st.expectUpdated, get, updated)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestNodePoolRelatedAttrsAutonomy(t *testing.T) {
+	np := &appsv1alpha1.NodePool{
+		Spec: appsv1alpha1.NodePoolSpec{
+			Type:        appsv1alpha1.Edge,
+			Autonomy:    true,
+			Annotations: map[string]string{"foo": "bar"},
+		},
+	}
+	expect := map[string]string{
+		"foo":                               "bar",
+		appsv1alpha1.AnnotationNodeAutonomy: "true",
+	}
+	if get := nodePoolRelatedAttrs(np, "").Annotations; !reflect.DeepEqual(get, expect) {
+		t.Errorf("expect %v, but get %v", expect, get)
+	}
+
+	np.Spec.DisableTypeAttributes = true
+	expect = map[string]string{"foo": "bar"}
+	if get := nodePoolRelatedAttrs(np, "").Annotations; !reflect.DeepEqual(get, expect) {
+		t.Errorf("expect %v, but get %v", expect, get)
+	}
+}
+
+func TestOriginalLabelsRestored(t *testing.T) {
+	np := &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+		Spec: appsv1alpha1.NodePoolSpec{
+			Labels: map[string]string{"foo": "bar"},
+		},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node1",
+			Labels: map[string]string{
+				appsv1alpha1.ServiceTopologyKey: "cn-hangzhou-a",
+				"foo":                           "baz",
+			},
+		},
+	}
+
+	// the node joins the pool, its zone and foo labels are overwritten
+	if _, err := conciliatePoolRelatedAttrs(node, nodePoolRelatedAttrs(np, appsv1alpha1.ServiceTopologyKey)); err != nil {
+		t.Fatalf("fail to conciliate the pool attributes: %v", err)
+	}
+	expect := map[string]string{appsv1alpha1.ServiceTopologyKey: "hangzhou", "foo": "bar"}
+	if !reflect.DeepEqual(node.Labels, expect) {
+		t.Fatalf("expect labels %v after joining the pool, but get %v", expect, node.Labels)
+	}
+
+	// the pool stops publishing its name, the zone is restored
+	if _, err := conciliatePoolRelatedAttrs(node, nodePoolRelatedAttrs(np, "")); err != nil {
+		t.Fatalf("fail to conciliate the pool attributes: %v", err)
+	}
+	expect = map[string]string{appsv1alpha1.ServiceTopologyKey: "cn-hangzhou-a", "foo": "bar"}
+	if !reflect.DeepEqual(node.Labels, expect) {
+		t.Fatalf("expect labels %v after disabling the topology key, but get %v", expect, node.Labels)
+	}
+
+	// the node leaves the pool, the foo label is restored
+	if err := removePoolRelatedAttrs(node); err != nil {
+		t.Fatalf("fail to remove the pool attributes: %v", err)
+	}
+	expect = map[string]string{appsv1alpha1.ServiceTopologyKey: "cn-hangzhou-a", "foo": "baz"}
+	if !reflect.DeepEqual(node.Labels, expect) {
+		t.Errorf("expect labels %v after leaving the pool, but get %v", expect, node.Labels)
+	}
+}
+
+func newObservedNodePoolStatus() *observedNodePoolStatus {
+	return &observedNodePoolStatus{
+		readyNode:   2,
+		nodes:       []string{"node-b", "node-a"},
+		capacity:    corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("4")},
+		allocatable: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("2")},
+		leader:      "node-a",
+	}
+}
+
+func TestConciliateMemberStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		observe       func(observed *observedNodePoolStatus)
+		expectUpdated bool
+		expectNodes   []string
+		expectLeader  string
+	}{
+		{
+			"nothing changed",
+			func(observed *observedNodePoolStatus) {},
+			false,
+			[]string{"node-a", "node-b"},
+			"node-a",
+		},
+		{
+			"node joined",
+			func(observed *observedNodePoolStatus) {
+				observed.readyNode = 3
+				observed.nodes = append(observed.nodes, "node-c")
+				observed.changes = []appsv1alpha1.NodePoolMembershipChange{
+					{Node: "node-c", Type: appsv1alpha1.NodeJoined},
+				}
+			},
+			true,
+			[]string{"node-a", "node-b", "node-c"},
+			"node-a",
+		},
+		{
+			"leader changed",
+			func(observed *observedNodePoolStatus) {
+				observed.leader = "node-b"
+			},
+			true,
+			[]string{"node-a", "node-b"},
+			"node-b",
+		},
+		{
+			"allocatable changed",
+			func(observed *observedNodePoolStatus) {
+				observed.allocatable = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("3")}
+			},
+			true,
+			[]string{"node-a", "node-b"},
+			"node-a",
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				status := &appsv1alpha1.NodePoolStatus{}
+				conciliateMemberStatus(status, newObservedNodePoolStatus())
+				observed := newObservedNodePoolStatus()
+				st.observe(observed)
+				updated := conciliateMemberStatus(status, observed)
+				if updated != st.expectUpdated {
+					t.Fatalf("\t%s\texpect updated %v, but get %v", failed, st.expectUpdated, updated)
+				}
+				if !reflect.DeepEqual(status.Nodes, st.expectNodes) || status.LeaderNode != st.expectLeader {
+					t.Fatalf("\t%s\texpect nodes %v and leader %s, but get %v and %s", failed,
+						st.expectNodes, st.expectLeader, status.Nodes, status.LeaderNode)
+				}
+				if len(status.RecentChanges) != len(observed.changes) {
+					t.Fatalf("\t%s\texpect %d recent changes, but get %d", failed,
+						len(observed.changes), len(status.RecentChanges))
+				}
+				t.Logf("\t%s\texpect updated %v, get %v", succeed, st.expectUpdated, updated)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestConciliateProgressStatus(t *testing.T) {
+	maintenance := &appsv1alpha1.NodePoolMaintenanceStatus{
+		Phase:         appsv1alpha1.MaintenanceCompleted,
+		CordonedNodes: 2,
+	}
+	tests := []struct {
+		name          string
+		status        *appsv1alpha1.NodePoolStatus
+		observed      *observedNodePoolStatus
+		expectUpdated bool
+	}{
+		{
+			"no progress",
+			&appsv1alpha1.NodePoolStatus{},
+			&observedNodePoolStatus{},
+			false,
+		},
+		{
+			"maintenance started",
+			&appsv1alpha1.NodePoolStatus{},
+			&observedNodePoolStatus{maintenance: maintenance},
+			true,
+		},
+		{
+			"maintenance unchanged",
+			&appsv1alpha1.NodePoolStatus{Maintenance: maintenance.DeepCopy()},
+			&observedNodePoolStatus{maintenance: maintenance},
+			false,
+		},
+		{
+			"migration finished",
+			&appsv1alpha1.NodePoolStatus{
+				Migrations: []appsv1alpha1.NodeMigrationStatus{{Node: "node-a"}},
+			},
+			&observedNodePoolStatus{},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				updated := conciliateProgressStatus(st.status, st.observed)
+				if updated != st.expectUpdated {
+					t.Fatalf("\t%s\texpect updated %v, but get %v", failed, st.expectUpdated, updated)
+				}
+				if !reflect.DeepEqual(st.status.Maintenance, st.observed.maintenance) ||
+					!reflect.DeepEqual(st.status.Migrations, st.observed.migrations) {
+					t.Fatalf("\t%s\texpect the observed progress, but get %v", failed, st.status)
+				}
+				t.Logf("\t%s\texpect updated %v, get %v", succeed, st.expectUpdated, updated)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestConciliateHierarchyStatus(t *testing.T) {
+	pools := []appsv1alpha1.NodePool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "region"}},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "site-a"},
+			Spec:       appsv1alpha1.NodePoolSpec{Parent: "region"},
+			Status:     appsv1alpha1.NodePoolStatus{TotalReadyNodeNum: 3, TotalUnreadyNodeNum: 1},
+		},
+	}
+	observed := &observedNodePoolStatus{readyNode: 1}
+	region := pools[0].DeepCopy()
+	if !conciliateHierarchyStatus(region, pools, observed) {
+		t.Fatalf("\t%s\texpect the rolled up status to be updated", failed)
+	}
+	if !reflect.DeepEqual(region.Status.ChildNodePools, []string{"site-a"}) ||
+		region.Status.TotalReadyNodeNum != 4 || region.Status.TotalUnreadyNodeNum != 1 {
+		t.Fatalf("\t%s\tunexpected rolled up status %v", failed, region.Status)
+	}
+	if conciliateHierarchyStatus(region, pools, observed) {
+		t.Fatalf("\t%s\texpect the rolled up status not to be updated again", failed)
+	}
+	t.Logf("\t%s\tnode counts are rolled up on demand", succeed)
+}
@@ -0,0 +1,204 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/record"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+var (
+	defaultMinReadyNodes   = intstr.FromString("50%")
+	defaultMaxUnreadyNodes = intstr.FromInt(0)
+)
+
+// NewNodePoolCondition creates a new NodePool condition.
+func NewNodePoolCondition(condType appsv1alpha1.NodePoolConditionType, status corev1.ConditionStatus, reason, message string) *appsv1alpha1.NodePoolCondition {
+	return &appsv1alpha1.NodePoolCondition{
+		Type:               condType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+		Reason:             reason,
+		Message:            message,
+	}
+}
+
+// GetNodePoolCondition returns the condition with the provided type.
+func GetNodePoolCondition(status appsv1alpha1.NodePoolStatus, condType appsv1alpha1.NodePoolConditionType) *appsv1alpha1.NodePoolCondition {
+	for i := range status.Conditions {
+		c := status.Conditions[i]
+		if c.Type == condType {
+			return &c
+		}
+	}
+	return nil
+}
+
+// SetNodePoolCondition updates the NodePool to include the provided condition. If the condition that
+// we are about to add already exists and has the same status, reason and message then we are not going to update.
+func SetNodePoolCondition(status *appsv1alpha1.NodePoolStatus, condition *appsv1alpha1.NodePoolCondition) {
+	currentCond := GetNodePoolCondition(*status, condition.Type)
+	if currentCond != nil && currentCond.Status == condition.Status &&
+		currentCond.Reason == condition.Reason && currentCond.Message == condition.Message {
+		return
+	}
+
+	if currentCond != nil && currentCond.Status == condition.Status {
+		condition.LastTransitionTime = currentCond.LastTransitionTime
+	}
+	newConditions := filterOutNodePoolCondition(status.Conditions, condition.Type)
+	status.Conditions = append(newConditions, *condition)
+}
+
+func filterOutNodePoolCondition(conditions []appsv1alpha1.NodePoolCondition, condType appsv1alpha1.NodePoolConditionType) []appsv1alpha1.NodePoolCondition {
+	var newConditions []appsv1alpha1.NodePoolCondition
+	for _, c := range conditions {
+		if c.Type == condType {
+			continue
+		}
+		newConditions = append(newConditions, c)
+	}
+	return newConditions
+}
+
+// getHealthThresholds returns the minimum number of ready nodes and the
+// maximum number of unready nodes of the pool, based on its HealthPolicy
+func getHealthThresholds(np *appsv1alpha1.NodePool, total int) (int, int, error) {
+	minReady, maxUnready := defaultMinReadyNodes, defaultMaxUnreadyNodes
+	if hp := np.Spec.HealthPolicy; hp != nil {
+		if hp.MinReadyNodes != nil {
+			minReady = *hp.MinReadyNodes
+		}
+		if hp.MaxUnreadyNodes != nil {
+			maxUnready = *hp.MaxUnreadyNodes
+		}
+	}
+
+	minReadyNum, err := intstr.GetValueFromIntOrPercent(&minReady, total, true)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid minReadyNodes: %v", err)
+	}
+	maxUnreadyNum, err := intstr.GetValueFromIntOrPercent(&maxUnready, total, false)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid maxUnreadyNodes: %v", err)
+	}
+	return minReadyNum, maxUnreadyNum, nil
+}
+
+// calculateNodePoolConditions sets the Ready, Degraded and AllNodesUnreachable
+// conditions of the nodepool status based on the number of ready and unready
+// nodes in the pool
+func calculateNodePoolConditions(np *appsv1alpha1.NodePool,
+	status *appsv1alpha1.NodePoolStatus, readyNode, notReadyNode int32) {
+	total := int(readyNode + notReadyNode)
+	minReady, maxUnready, err := getHealthThresholds(np, total)
+	if err != nil {
+		msg := err.Error()
+		SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolReady, corev1.ConditionUnknown, "InvalidHealthPolicy", msg))
+		SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolDegraded, corev1.ConditionUnknown, "InvalidHealthPolicy", msg))
+	} else {
+		switch {
+		case total == 0:
+			SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolReady, corev1.ConditionFalse, "NoNodes", "there is no node in the pool"))
+		case int(readyNode) >= minReady:
+			SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolReady, corev1.ConditionTrue, "EnoughReadyNodes",
+				fmt.Sprintf("%d of %d nodes are ready, at least %d are required", readyNode, total, minReady)))
+		default:
+			SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolReady, corev1.ConditionFalse, "NotEnoughReadyNodes",
+				fmt.Sprintf("%d of %d nodes are ready, at least %d are required", readyNode, total, minReady)))
+		}
+
+		if int(notReadyNode) > maxUnready {
+			SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolDegraded, corev1.ConditionTrue, "TooManyUnreadyNodes",
+				fmt.Sprintf("%d of %d nodes are unready, at most %d are allowed", notReadyNode, total, maxUnready)))
+		} else {
+			SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolDegraded, corev1.ConditionFalse, "AsExpected",
+				fmt.Sprintf("%d of %d nodes are unready, at most %d are allowed", notReadyNode, total, maxUnready)))
+		}
+	}
+
+	if total > 0 && readyNode == 0 {
+		SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolAllNodesUnreachable, corev1.ConditionTrue, "NoReadyNodes",
+			fmt.Sprintf("none of the %d nodes is ready", total)))
+	} else {
+		SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolAllNodesUnreachable, corev1.ConditionFalse, "AsExpected", ""))
+	}
+}
+
+// isNodePoolConditionHealthy tells if the condition indicates a healthy pool
+func isNodePoolConditionHealthy(cond appsv1alpha1.NodePoolCondition) bool {
+	if cond.Type == appsv1alpha1.NodePoolReady {
+		return cond.Status == corev1.ConditionTrue
+	}
+	return cond.Status == corev1.ConditionFalse
+}
+
+// recordNodePoolConditionTransitions emits an event for every condition whose
+// status is changed from the oldStatus to the newStatus
+func recordNodePoolConditionTransitions(recorder record.EventRecorder,
+	np *appsv1alpha1.NodePool, oldStatus, newStatus *appsv1alpha1.NodePoolStatus) {
+	if recorder == nil {
+		return
+	}
+	for _, cond := range newStatus.Conditions {
+		oldCond := GetNodePoolCondition(*oldStatus, cond.Type)
+		if oldCond == nil || oldCond.Status == cond.Status {
+			continue
+		}
+		eventType := corev1.EventTypeNormal
+		if !isNodePoolConditionHealthy(cond) {
+			eventType = corev1.EventTypeWarning
+		}
+		recorder.Eventf(np, eventType, cond.Reason,
+			"NodePool condition %s changed from %s to %s: %s",
+			cond.Type, oldCond.Status, cond.Status, cond.Message)
+	}
+}
+
+// rollupNodePoolStatus returns the child pools of the pool, and the number of
+// ready and unready nodes in the pool and all its descendants, based on the
+// rolled up status of the child pools. Children that are also ancestors of
+// the pool are skipped, so that a cycle will not inflate the counts
+func rollupNodePoolStatus(np *appsv1alpha1.NodePool, pools []appsv1alpha1.NodePool,
+	readyNode, notReadyNode int32) ([]string, int32, int32) {
+	ancestors := make(map[string]bool)
+	for _, a := range nodepoolutil.Ancestors(np, pools) {
+		ancestors[a.GetName()] = true
+	}
+	children := nodepoolutil.Children(np.GetName(), pools)
+	childSet := make(map[string]bool, len(children))
+	for _, c := range children {
+		childSet[c] = true
+	}
+
+	totalReady, totalNotReady := readyNode, notReadyNode
+	for _, p := range pools {
+		if !childSet[p.GetName()] || ancestors[p.GetName()] {
+			continue
+		}
+		totalReady += p.Status.TotalReadyNodeNum
+		totalNotReady += p.Status.TotalUnreadyNodeNum
+	}
+	return children, totalReady, totalNotReady
+}
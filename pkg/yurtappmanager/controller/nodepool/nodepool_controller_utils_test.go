@@ -0,0 +1,189 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestCalculateNodePoolConditions(t *testing.T) {
+	minReady := intstr.FromInt(1)
+	maxUnready := intstr.FromString("50%")
+	tests := []struct {
+		name         string
+		healthPolicy *appsv1alpha1.NodePoolHealthPolicy
+		readyNode    int32
+		notReadyNode int32
+		expect       map[appsv1alpha1.NodePoolConditionType]corev1.ConditionStatus
+	}{
+		{
+			"empty pool",
+			nil,
+			0,
+			0,
+			map[appsv1alpha1.NodePoolConditionType]corev1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:               corev1.ConditionFalse,
+				appsv1alpha1.NodePoolDegraded:            corev1.ConditionFalse,
+				appsv1alpha1.NodePoolAllNodesUnreachable: corev1.ConditionFalse,
+			},
+		},
+		{
+			"all nodes are ready",
+			nil,
+			3,
+			0,
+			map[appsv1alpha1.NodePoolConditionType]corev1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:               corev1.ConditionTrue,
+				appsv1alpha1.NodePoolDegraded:            corev1.ConditionFalse,
+				appsv1alpha1.NodePoolAllNodesUnreachable: corev1.ConditionFalse,
+			},
+		},
+		{
+			"default thresholds with one unready node",
+			nil,
+			2,
+			1,
+			map[appsv1alpha1.NodePoolConditionType]corev1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:               corev1.ConditionTrue,
+				appsv1alpha1.NodePoolDegraded:            corev1.ConditionTrue,
+				appsv1alpha1.NodePoolAllNodesUnreachable: corev1.ConditionFalse,
+			},
+		},
+		{
+			"default thresholds with most nodes unready",
+			nil,
+			1,
+			2,
+			map[appsv1alpha1.NodePoolConditionType]corev1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:               corev1.ConditionFalse,
+				appsv1alpha1.NodePoolDegraded:            corev1.ConditionTrue,
+				appsv1alpha1.NodePoolAllNodesUnreachable: corev1.ConditionFalse,
+			},
+		},
+		{
+			"custom thresholds",
+			&appsv1alpha1.NodePoolHealthPolicy{
+				MinReadyNodes:   &minReady,
+				MaxUnreadyNodes: &maxUnready,
+			},
+			1,
+			1,
+			map[appsv1alpha1.NodePoolConditionType]corev1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:               corev1.ConditionTrue,
+				appsv1alpha1.NodePoolDegraded:            corev1.ConditionFalse,
+				appsv1alpha1.NodePoolAllNodesUnreachable: corev1.ConditionFalse,
+			},
+		},
+		{
+			"all nodes are unreachable",
+			nil,
+			0,
+			2,
+			map[appsv1alpha1.NodePoolConditionType]corev1.ConditionStatus{
+				appsv1alpha1.NodePoolReady:               corev1.ConditionFalse,
+				appsv1alpha1.NodePoolDegraded:            corev1.ConditionTrue,
+				appsv1alpha1.NodePoolAllNodesUnreachable: corev1.ConditionTrue,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				np := &appsv1alpha1.NodePool{
+					Spec: appsv1alpha1.NodePoolSpec{HealthPolicy: st.healthPolicy},
+				}
+				calculateNodePoolConditions(np, &np.Status, st.readyNode, st.notReadyNode)
+				for condType, expect := range st.expect {
+					cond := GetNodePoolCondition(np.Status, condType)
+					if cond == nil {
+						t.Fatalf("\t%s\tcondition %s is not set", failed, condType)
+					}
+					if cond.Status != expect {
+						t.Fatalf("\t%s\texpect condition %s to be %v, but get %v",
+							failed, condType, expect, cond.Status)
+					}
+				}
+				t.Logf("\t%s\texpect %v", succeed, st.expect)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestSetNodePoolCondition(t *testing.T) {
+	status := &appsv1alpha1.NodePoolStatus{}
+	SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolReady,
+		corev1.ConditionTrue, "EnoughReadyNodes", ""))
+	transitionTime := GetNodePoolCondition(*status, appsv1alpha1.NodePoolReady).LastTransitionTime
+
+	// the transition time should be kept if the status is not changed
+	SetNodePoolCondition(status, NewNodePoolCondition(appsv1alpha1.NodePoolReady,
+		corev1.ConditionTrue, "EnoughReadyNodes", "changed message"))
+	cond := GetNodePoolCondition(*status, appsv1alpha1.NodePoolReady)
+	if len(status.Conditions) != 1 || cond.Message != "changed message" ||
+		!cond.LastTransitionTime.Equal(&transitionTime) {
+		t.Fatalf("\t%s\tunexpected conditions %v", failed, status.Conditions)
+	}
+	t.Logf("\t%s\tconditions %v", succeed, status.Conditions)
+}
+
+func TestRollupNodePoolStatus(t *testing.T) {
+	newPool := func(name, parent string, ready, notReady int32) appsv1alpha1.NodePool {
+		return appsv1alpha1.NodePool{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       appsv1alpha1.NodePoolSpec{Parent: parent},
+			Status: appsv1alpha1.NodePoolStatus{
+				TotalReadyNodeNum:   ready,
+				TotalUnreadyNodeNum: notReady,
+			},
+		}
+	}
+	pools := []appsv1alpha1.NodePool{
+		newPool("region", "", 0, 0),
+		newPool("site-a", "region", 3, 1),
+		newPool("site-b", "region", 2, 0),
+		newPool("rack", "site-a", 3, 1),
+	}
+
+	children, ready, notReady := rollupNodePoolStatus(&pools[0], pools, 1, 1)
+	if len(children) != 2 || children[0] != "site-a" || children[1] != "site-b" {
+		t.Fatalf("\t%s\tunexpected children %v", failed, children)
+	}
+	if ready != 6 || notReady != 2 {
+		t.Fatalf("\t%s\texpect 6 ready and 2 unready nodes, but get %d and %d",
+			failed, ready, notReady)
+	}
+
+	// a child that is also an ancestor should not be counted
+	pools[0].Spec.Parent = "rack"
+	_, ready, notReady = rollupNodePoolStatus(&pools[3], pools, 3, 1)
+	if ready != 3 || notReady != 1 {
+		t.Fatalf("\t%s\texpect the cycle to be skipped, but get %d and %d",
+			failed, ready, notReady)
+	}
+	t.Logf("\t%s\tnode counts are rolled up", succeed)
+}
@@ -17,17 +17,104 @@ limitations under the License.
 package nodepool
 
 import (
+	"context"
 	"reflect"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/util/workqueue"
 	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
 )
 
-type EnqueueNodePoolForNode struct{}
+// nodeStatusSyncDelay delays the reconciliation triggered by the status
+// changes of the member nodes, so that changes of many nodes, e.g. a whole
+// site going offline, are coalesced into a single status update of the pool
+const nodeStatusSyncDelay = time.Second
+
+// nodePredicate filters out the node updates that affect no nodepool, e.g.
+// the heartbeats and the changes of the irrelevant annotations
+var nodePredicate = predicate.Funcs{
+	UpdateFunc: func(evt event.UpdateEvent) bool {
+		oldNode, ok := evt.ObjectOld.(*corev1.Node)
+		if !ok {
+			return true
+		}
+		newNode, ok := evt.ObjectNew.(*corev1.Node)
+		if !ok {
+			return true
+		}
+		// any label may change the membership through the pool selectors
+		return !reflect.DeepEqual(oldNode.Labels, newNode.Labels) ||
+			nodePoolRelatedAttrsChanged(oldNode, newNode) ||
+			nodeStatusChanged(oldNode, newNode)
+	},
+}
+
+// nodePoolRelatedAttrsChanged checks if the attributes maintained by the
+// nodepool controller are changed
+func nodePoolRelatedAttrsChanged(oldNode, newNode *corev1.Node) bool {
+	for _, k := range []string{
+		appsv1alpha1.LabelCurrentNodePool,
+		appsv1alpha1.LabelDesiredNodePool,
+		appsv1alpha1.LabelEdgeWorker,
+	} {
+		if oldNode.Labels[k] != newNode.Labels[k] {
+			return true
+		}
+	}
+	for _, k := range []string{
+		appsv1alpha1.AnnotationPrevAttrs,
+		appsv1alpha1.AnnotationCordonedByNodePool,
+		appsv1alpha1.AnnotationNodeAutonomy,
+		appsv1alpha1.AnnotationMigrateToNodePool,
+	} {
+		if oldNode.Annotations[k] != newNode.Annotations[k] {
+			return true
+		}
+	}
+	return oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable ||
+		!reflect.DeepEqual(oldNode.Spec.Taints, newNode.Spec.Taints)
+}
+
+// nodeStatusChanged checks if the node changes affect the nodepool status,
+// i.e. the readiness and the resources of the node
+func nodeStatusChanged(oldNode, newNode *corev1.Node) bool {
+	return isNodeReady(*oldNode) != isNodeReady(*newNode) ||
+		!resourceListEqual(oldNode.Status.Capacity, newNode.Status.Capacity) ||
+		!resourceListEqual(oldNode.Status.Allocatable, newNode.Status.Allocatable)
+}
+
+type EnqueueNodePoolForNode struct {
+	client.Client
+	selectors *nodePoolSelectorCache
+}
+
+// desiredNodePool returns the name of the nodepool that the node should
+// belong to, based on the desired-nodepool label and the pool selectors. The
+// NodePools are only listed to load the selector cache on the first use
+func (e *EnqueueNodePoolForNode) desiredNodePool(node *corev1.Node) string {
+	if np := node.Labels[appsv1alpha1.LabelDesiredNodePool]; np != "" {
+		return np
+	}
+	if !e.selectors.isLoaded() {
+		var poolList appsv1alpha1.NodePoolList
+		if err := e.List(context.TODO(), &poolList); err != nil {
+			klog.Errorf("fail to list nodepools for node(%s): %v",
+				node.GetName(), err)
+			return ""
+		}
+		e.selectors.load(poolList.Items)
+	}
+	return e.selectors.resolve(node)
+}
 
 // Create implements EventHandler
 func (e *EnqueueNodePoolForNode) Create(evt event.CreateEvent,
@@ -39,7 +126,10 @@ func (e *EnqueueNodePoolForNode) Create(evt event.CreateEvent,
 	}
 	klog.V(5).Infof("will enqueue nodepool as node(%s) has been created",
 		node.GetName())
-	if np, exist := node.Labels[appsv1alpha1.LabelDesiredNodePool]; exist {
+	if target := node.Annotations[appsv1alpha1.AnnotationMigrateToNodePool]; target != "" {
+		addNodePoolToWorkQueue(target, q)
+	}
+	if np := e.desiredNodePool(node); np != "" {
 		addNodePoolToWorkQueue(np, q)
 		return
 	}
@@ -63,9 +153,17 @@ func (e *EnqueueNodePoolForNode) Update(evt event.UpdateEvent,
 	}
 	klog.V(5).Infof("will enqueue nodepool as node(%s) has been updated",
 		newNode.GetName())
-	newNp := newNode.Labels[appsv1alpha1.LabelDesiredNodePool]
+	newNp := e.desiredNodePool(newNode)
 	oldNp := oldNode.Labels[appsv1alpha1.LabelCurrentNodePool]
 
+	// the migration is driven by the target pool
+	if target := newNode.Annotations[appsv1alpha1.AnnotationMigrateToNodePool]; target != "" &&
+		target != newNp {
+		klog.V(5).Infof("will enqueue target pool(%s) for migrating node(%s)",
+			target, newNode.GetName())
+		addNodePoolToWorkQueue(target, q)
+	}
+
 	if newNp != oldNp {
 		if newNp == "" {
 			// remove node from old pool
@@ -90,25 +188,24 @@ func (e *EnqueueNodePoolForNode) Update(evt event.UpdateEvent,
 		return
 	}
 
-	if isNodeReady(*newNode) != isNodeReady(*oldNode) {
-		// if the newNode and oldNode status are different
-		klog.V(5).Infof("node phase has been changed,"+
-			" will enqueue pool(%s) for node(%s)", newNp, newNode.GetName())
-		addNodePoolToWorkQueue(newNp, q)
-		return
-	}
-
-	if !reflect.DeepEqual(newNode.Labels, oldNode.Labels) ||
-		!reflect.DeepEqual(newNode.Annotations, oldNode.Annotations) ||
-		!reflect.DeepEqual(newNode.Spec.Taints, oldNode.Spec.Taints) {
-		// if node's labels, annotations or taints are updated
-		// TODO only consider the pool realted attributes
+	if nodePoolRelatedAttrsChanged(oldNode, newNode) {
 		klog.V(5).Infof("nodepool related attributes has been changed,"+
 			" will enqueue pool(%s) for node(%s)",
 			newNp, newNode.GetName())
 		addNodePoolToWorkQueue(newNp, q)
+		return
 	}
 
+	if nodeStatusChanged(oldNode, newNode) {
+		// if the newNode and oldNode status are different
+		klog.V(5).Infof("node status has been changed,"+
+			" will enqueue pool(%s) for node(%s)", newNp, newNode.GetName())
+		if newNp != "" {
+			q.AddAfter(reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: newNp},
+			}, nodeStatusSyncDelay)
+		}
+	}
 }
 
 // Delete implements EventHandler
@@ -136,3 +233,97 @@ func (e *EnqueueNodePoolForNode) Generic(evt event.GenericEvent,
 	q workqueue.RateLimitingInterface) {
 	return
 }
+
+// inheritableAttrsChanged checks if the changes of the pool affect its
+// descendant pools
+func inheritableAttrsChanged(oldNp, newNp *appsv1alpha1.NodePool) bool {
+	return oldNp.Spec.Parent != newNp.Spec.Parent ||
+		!reflect.DeepEqual(oldNp.Spec.Labels, newNp.Spec.Labels) ||
+		!reflect.DeepEqual(oldNp.Spec.Annotations, newNp.Spec.Annotations) ||
+		!reflect.DeepEqual(oldNp.Spec.Taints, newNp.Spec.Taints)
+}
+
+// EnqueueNodePoolForNodePool enqueues the pool itself, its parent, whose
+// status rolls up the pool status, and its descendants, which inherit the
+// pool attributes. It also refreshes the selector cache shared with the node
+// handler
+type EnqueueNodePoolForNodePool struct {
+	client.Client
+	selectors *nodePoolSelectorCache
+}
+
+// Create implements EventHandler
+func (e *EnqueueNodePoolForNodePool) Create(evt event.CreateEvent,
+	q workqueue.RateLimitingInterface) {
+	np, ok := evt.Object.(*appsv1alpha1.NodePool)
+	if !ok {
+		klog.Error("fail to assert runtime Object to v1alpha1.NodePool")
+		return
+	}
+	e.selectors.update(np)
+	addNodePoolToWorkQueue(np.GetName(), q)
+	addNodePoolToWorkQueue(np.Spec.Parent, q)
+	e.addDescendantsToWorkQueue(np.GetName(), q)
+}
+
+// Update implements EventHandler
+func (e *EnqueueNodePoolForNodePool) Update(evt event.UpdateEvent,
+	q workqueue.RateLimitingInterface) {
+	newNp, ok := evt.ObjectNew.(*appsv1alpha1.NodePool)
+	if !ok {
+		klog.Errorf("fail to assert runtime Object(%s) to v1alpha1.NodePool",
+			evt.ObjectNew.GetName())
+		return
+	}
+	oldNp, ok := evt.ObjectOld.(*appsv1alpha1.NodePool)
+	if !ok {
+		klog.Errorf("fail to assert runtime Object(%s) to v1alpha1.NodePool",
+			evt.ObjectOld.GetName())
+		return
+	}
+	e.selectors.update(newNp)
+	addNodePoolToWorkQueue(newNp.GetName(), q)
+	addNodePoolToWorkQueue(newNp.Spec.Parent, q)
+	if oldNp.Spec.Parent != newNp.Spec.Parent {
+		addNodePoolToWorkQueue(oldNp.Spec.Parent, q)
+	}
+	if inheritableAttrsChanged(oldNp, newNp) {
+		klog.V(5).Infof("inheritable attributes of pool(%s) has been changed,"+
+			" will enqueue its descendants", newNp.GetName())
+		e.addDescendantsToWorkQueue(newNp.GetName(), q)
+	}
+}
+
+// Delete implements EventHandler
+func (e *EnqueueNodePoolForNodePool) Delete(evt event.DeleteEvent,
+	q workqueue.RateLimitingInterface) {
+	np, ok := evt.Object.(*appsv1alpha1.NodePool)
+	if !ok {
+		klog.Error("fail to assert runtime Object to v1alpha1.NodePool")
+		return
+	}
+	e.selectors.delete(np.GetName())
+	addNodePoolToWorkQueue(np.GetName(), q)
+	addNodePoolToWorkQueue(np.Spec.Parent, q)
+	e.addDescendantsToWorkQueue(np.GetName(), q)
+}
+
+// Generic implements EventHandler
+func (e *EnqueueNodePoolForNodePool) Generic(evt event.GenericEvent,
+	q workqueue.RateLimitingInterface) {
+	return
+}
+
+// addDescendantsToWorkQueue adds all pools under the pool to the workqueue
+func (e *EnqueueNodePoolForNodePool) addDescendantsToWorkQueue(npName string,
+	q workqueue.RateLimitingInterface) {
+	var poolList appsv1alpha1.NodePoolList
+	if err := e.List(context.TODO(), &poolList); err != nil {
+		klog.Errorf("fail to list nodepools for the descendants of pool(%s): %v",
+			npName, err)
+		return
+	}
+	for _, d := range nodepoolutil.Descendants(npName, poolList.Items) {
+		addNodePoolToWorkQueue(d, q)
+	}
+}
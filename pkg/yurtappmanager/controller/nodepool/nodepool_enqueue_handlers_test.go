@@ -0,0 +1,129 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newPredicateNode(ready corev1.ConditionStatus) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node",
+			Labels: map[string]string{
+				appsv1alpha1.LabelCurrentNodePool: "hangzhou",
+			},
+			Annotations: map[string]string{
+				"node.alpha.kubernetes.io/ttl": "0",
+			},
+		},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: ready},
+			},
+			Allocatable: corev1.ResourceList{
+				corev1.ResourceCPU: resource.MustParse("2"),
+			},
+		},
+	}
+}
+
+func TestNodePredicate(t *testing.T) {
+	tests := []struct {
+		name   string
+		update func(node *corev1.Node)
+		expect bool
+	}{
+		{
+			"heartbeat",
+			func(node *corev1.Node) {
+				node.Status.Conditions[0].LastHeartbeatTime = metav1.Now()
+			},
+			false,
+		},
+		{
+			"irrelevant annotation",
+			func(node *corev1.Node) {
+				node.Annotations["node.alpha.kubernetes.io/ttl"] = "30"
+			},
+			false,
+		},
+		{
+			"label change",
+			func(node *corev1.Node) {
+				node.Labels["site"] = "hangzhou-1"
+			},
+			true,
+		},
+		{
+			"readiness change",
+			func(node *corev1.Node) {
+				node.Status.Conditions[0].Status = corev1.ConditionFalse
+			},
+			true,
+		},
+		{
+			"allocatable change",
+			func(node *corev1.Node) {
+				node.Status.Allocatable[corev1.ResourceCPU] = resource.MustParse("4")
+			},
+			true,
+		},
+		{
+			"cordoned",
+			func(node *corev1.Node) {
+				node.Spec.Unschedulable = true
+			},
+			true,
+		},
+		{
+			"pool related annotation change",
+			func(node *corev1.Node) {
+				node.Annotations[appsv1alpha1.AnnotationPrevAttrs] = "{}"
+			},
+			true,
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				oldNode := newPredicateNode(corev1.ConditionTrue)
+				newNode := oldNode.DeepCopy()
+				st.update(newNode)
+				get := nodePredicate.Update(event.UpdateEvent{
+					ObjectOld: oldNode,
+					ObjectNew: newNode,
+				})
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/klog"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// maxRecentChanges is the maximum number of membership changes kept in the
+// NodePool status
+const maxRecentChanges = 20
+
+// diffMembership returns the sorted nodes that joined and left the pool since
+// the member nodes were last recorded in the status of the pool
+func diffMembership(np *appsv1alpha1.NodePool, nodes []string) (joined, left []string) {
+	previous, current := sets.NewString(np.Status.Nodes...), sets.NewString(nodes...)
+	return current.Difference(previous).List(), previous.Difference(current).List()
+}
+
+// previousNodePool returns the pool that the node joining np comes from. The
+// nodepool label of the node is used if it is not updated yet, otherwise the
+// pool still listing the node as a member or recording its leave for np is
+// looked up, as the pools may be reconciled in any order
+func previousNodePool(np *appsv1alpha1.NodePool, node, fromPool string,
+	pools []appsv1alpha1.NodePool) string {
+	if fromPool != "" && fromPool != np.GetName() {
+		return fromPool
+	}
+	for i := range pools {
+		pool := &pools[i]
+		if pool.GetName() == np.GetName() {
+			continue
+		}
+		if sets.NewString(pool.Status.Nodes...).Has(node) {
+			return pool.GetName()
+		}
+		for j := len(pool.Status.RecentChanges) - 1; j >= 0; j-- {
+			change := pool.Status.RecentChanges[j]
+			if change.Node != node {
+				continue
+			}
+			if change.Type == appsv1alpha1.NodeLeft && change.PeerNodePool == np.GetName() {
+				return pool.GetName()
+			}
+			break
+		}
+	}
+	return ""
+}
+
+// membershipChange is a membership change of the pool and the node it is
+// about, the node is nil if it has been deleted
+type membershipChange struct {
+	appsv1alpha1.NodePoolMembershipChange
+	node *corev1.Node
+}
+
+// conciliateMembershipChanges returns the nodes joining and leaving the pool
+// by diffing the member nodes against the status of the pool, so that every
+// pool records its own changes no matter which pool is reconciled first. The
+// changes are found again if the status fails to be updated, so their events
+// are only emitted by recordMembershipChanges once the status is updated.
+// fromPools maps the nodes to the nodepool label they had before joining the
+// pool
+func (r *NodePoolReconciler) conciliateMembershipChanges(ctx context.Context,
+	np *appsv1alpha1.NodePool, members []corev1.Node, fromPools map[string]string,
+	pools []appsv1alpha1.NodePool) []membershipChange {
+	nodes := make([]string, 0, len(members))
+	nameToNode := make(map[string]*corev1.Node, len(members))
+	for i := range members {
+		nodes = append(nodes, members[i].GetName())
+		nameToNode[members[i].GetName()] = &members[i]
+	}
+
+	var changes []membershipChange
+	joined, left := diffMembership(np, nodes)
+	for _, name := range joined {
+		changes = append(changes, nodeJoined(nameToNode[name],
+			previousNodePool(np, name, fromPools[name], pools)))
+	}
+	for _, name := range left {
+		var node corev1.Node
+		if err := r.Get(ctx, types.NamespacedName{Name: name}, &node); err != nil {
+			if !apierrors.IsNotFound(err) {
+				klog.Errorf("fail to get node %s that left nodepool %s: %v", name, np.GetName(), err)
+			}
+			changes = append(changes, nodeLeft(name, nil, ""))
+			continue
+		}
+		changes = append(changes, nodeLeft(name, &node, resolveNodePool(&node, pools)))
+	}
+	return changes
+}
+
+// nodeJoined returns the membership change of the node joining the pool
+func nodeJoined(node *corev1.Node, fromPool string) membershipChange {
+	return membershipChange{
+		NodePoolMembershipChange: appsv1alpha1.NodePoolMembershipChange{
+			Node:         node.GetName(),
+			Type:         appsv1alpha1.NodeJoined,
+			PeerNodePool: fromPool,
+			Time:         metav1.Now(),
+		},
+		node: node,
+	}
+}
+
+// nodeLeft returns the membership change of the node leaving the pool, the
+// node is nil if it has been deleted
+func nodeLeft(nodeName string, node *corev1.Node, toPool string) membershipChange {
+	return membershipChange{
+		NodePoolMembershipChange: appsv1alpha1.NodePoolMembershipChange{
+			Node:         nodeName,
+			Type:         appsv1alpha1.NodeLeft,
+			PeerNodePool: toPool,
+			Time:         metav1.Now(),
+		},
+		node: node,
+	}
+}
+
+// membershipChangeStatuses returns the changes to be recorded in the status
+// of the pool
+func membershipChangeStatuses(changes []membershipChange) []appsv1alpha1.NodePoolMembershipChange {
+	var statuses []appsv1alpha1.NodePoolMembershipChange
+	for i := range changes {
+		statuses = append(statuses, changes[i].NodePoolMembershipChange)
+	}
+	return statuses
+}
+
+// recordMembershipChanges emits events for the nodes joining and leaving the
+// pool, it is called once the changes are recorded in the status of the pool
+func (r *NodePoolReconciler) recordMembershipChanges(np *appsv1alpha1.NodePool,
+	changes []membershipChange) {
+	for i := range changes {
+		change := &changes[i]
+		switch {
+		case change.Type == appsv1alpha1.NodeJoined && change.PeerNodePool == "":
+			r.recorder.Eventf(np, corev1.EventTypeNormal, "NodeJoined",
+				"node %s joined the pool", change.Node)
+		case change.Type == appsv1alpha1.NodeJoined:
+			r.recorder.Eventf(np, corev1.EventTypeNormal, "NodeJoined",
+				"node %s joined the pool from nodepool %s", change.Node, change.PeerNodePool)
+		case change.PeerNodePool == "":
+			r.recorder.Eventf(np, corev1.EventTypeNormal, "NodeLeft",
+				"node %s left the pool", change.Node)
+		default:
+			r.recorder.Eventf(np, corev1.EventTypeNormal, "NodeLeft",
+				"node %s left the pool for nodepool %s", change.Node, change.PeerNodePool)
+		}
+		if change.node == nil {
+			continue
+		}
+		if change.Type == appsv1alpha1.NodeJoined {
+			r.recorder.Eventf(change.node, corev1.EventTypeNormal, "JoinedNodePool",
+				"node joined nodepool %s, the pool attributes are applied", np.GetName())
+		} else {
+			r.recorder.Eventf(change.node, corev1.EventTypeNormal, "LeftNodePool",
+				"node left nodepool %s, the pool attributes are reverted", np.GetName())
+		}
+	}
+}
+
+// recordNodeAttrsApplied emits events for the pool attributes being
+// re-applied to a member node, e.g. after the NodePool.Spec is changed
+func (r *NodePoolReconciler) recordNodeAttrsApplied(np *appsv1alpha1.NodePool,
+	node *corev1.Node) {
+	r.recorder.Eventf(np, corev1.EventTypeNormal, "NodeAttributesApplied",
+		"the pool attributes are re-applied to node %s", node.GetName())
+	r.recorder.Eventf(node, corev1.EventTypeNormal, "NodePoolAttributesApplied",
+		"the attributes of nodepool %s are re-applied", np.GetName())
+}
+
+// appendMembershipChanges appends the changes to the recent ones, and drops
+// the oldest ones if there are more than maxRecentChanges
+func appendMembershipChanges(recent,
+	changes []appsv1alpha1.NodePoolMembershipChange) []appsv1alpha1.NodePoolMembershipChange {
+	merged := make([]appsv1alpha1.NodePoolMembershipChange, 0, len(recent)+len(changes))
+	merged = append(merged, recent...)
+	merged = append(merged, changes...)
+	if len(merged) > maxRecentChanges {
+		merged = merged[len(merged)-maxRecentChanges:]
+	}
+	return merged
+}
@@ -0,0 +1,225 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newMembershipChanges(start, n int) []appsv1alpha1.NodePoolMembershipChange {
+	var changes []appsv1alpha1.NodePoolMembershipChange
+	for i := start; i < start+n; i++ {
+		changes = append(changes, appsv1alpha1.NodePoolMembershipChange{
+			Node: fmt.Sprintf("node-%d", i),
+			Type: appsv1alpha1.NodeJoined,
+		})
+	}
+	return changes
+}
+
+func TestAppendMembershipChanges(t *testing.T) {
+	tests := []struct {
+		name        string
+		recent      []appsv1alpha1.NodePoolMembershipChange
+		changes     []appsv1alpha1.NodePoolMembershipChange
+		expectLen   int
+		expectFirst string
+		expectLast  string
+	}{
+		{
+			"append to empty list",
+			nil,
+			newMembershipChanges(0, 2),
+			2,
+			"node-0",
+			"node-1",
+		},
+		{
+			"oldest changes are dropped",
+			newMembershipChanges(0, maxRecentChanges),
+			newMembershipChanges(maxRecentChanges, 3),
+			maxRecentChanges,
+			"node-3",
+			fmt.Sprintf("node-%d", maxRecentChanges+2),
+		},
+		{
+			"too many new changes",
+			nil,
+			newMembershipChanges(0, maxRecentChanges+1),
+			maxRecentChanges,
+			"node-1",
+			fmt.Sprintf("node-%d", maxRecentChanges),
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := appendMembershipChanges(st.recent, st.changes)
+				if len(get) != st.expectLen ||
+					get[0].Node != st.expectFirst ||
+					get[len(get)-1].Node != st.expectLast {
+					t.Fatalf("\t%s\texpect %d changes from %s to %s, but get %v",
+						failed, st.expectLen, st.expectFirst, st.expectLast, get)
+				}
+				t.Logf("\t%s\texpect %d changes, get %d", succeed, st.expectLen, len(get))
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestDiffMembership(t *testing.T) {
+	np := &appsv1alpha1.NodePool{
+		Status: appsv1alpha1.NodePoolStatus{Nodes: []string{"node-a", "node-b"}},
+	}
+	joined, left := diffMembership(np, []string{"node-c", "node-b"})
+	if !reflect.DeepEqual(joined, []string{"node-c"}) {
+		t.Errorf("expect node-c joined, but get %v", joined)
+	}
+	if !reflect.DeepEqual(left, []string{"node-a"}) {
+		t.Errorf("expect node-a left, but get %v", left)
+	}
+}
+
+func TestPreviousNodePool(t *testing.T) {
+	np := &appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}}
+	tests := []struct {
+		name     string
+		fromPool string
+		peer     appsv1alpha1.NodePoolStatus
+		expect   string
+	}{
+		{
+			"nodepool label of the node",
+			"beijing",
+			appsv1alpha1.NodePoolStatus{},
+			"beijing",
+		},
+		{
+			"peer pool still lists the node",
+			"",
+			appsv1alpha1.NodePoolStatus{Nodes: []string{"node-a"}},
+			"beijing",
+		},
+		{
+			"peer pool has recorded the leave",
+			"hangzhou",
+			appsv1alpha1.NodePoolStatus{RecentChanges: []appsv1alpha1.NodePoolMembershipChange{
+				{Node: "node-a", Type: appsv1alpha1.NodeLeft, PeerNodePool: "hangzhou"},
+			}},
+			"beijing",
+		},
+		{
+			"peer pool has recorded the leave for another pool",
+			"",
+			appsv1alpha1.NodePoolStatus{RecentChanges: []appsv1alpha1.NodePoolMembershipChange{
+				{Node: "node-a", Type: appsv1alpha1.NodeLeft, PeerNodePool: "shanghai"},
+			}},
+			"",
+		},
+		{
+			"node joined the peer pool again",
+			"",
+			appsv1alpha1.NodePoolStatus{RecentChanges: []appsv1alpha1.NodePoolMembershipChange{
+				{Node: "node-a", Type: appsv1alpha1.NodeLeft, PeerNodePool: "hangzhou"},
+				{Node: "node-a", Type: appsv1alpha1.NodeJoined},
+			}},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			pools := []appsv1alpha1.NodePool{
+				*np,
+				{ObjectMeta: metav1.ObjectMeta{Name: "beijing"}, Status: st.peer},
+			}
+			if get := previousNodePool(np, "node-a", st.fromPool, pools); get != st.expect {
+				t.Errorf("expect previous nodepool %q, but get %q", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestRecordMembershipChanges(t *testing.T) {
+	np := &appsv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}}
+	node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node-a"}}
+	tests := []struct {
+		name   string
+		change membershipChange
+		expect []string
+	}{
+		{
+			"node joined from another pool",
+			nodeJoined(node, "beijing"),
+			[]string{
+				"Normal NodeJoined node node-a joined the pool from nodepool beijing",
+				"Normal JoinedNodePool node joined nodepool hangzhou, the pool attributes are applied",
+			},
+		},
+		{
+			"node left the pool",
+			nodeLeft("node-a", node, ""),
+			[]string{
+				"Normal NodeLeft node node-a left the pool",
+				"Normal LeftNodePool node left nodepool hangzhou, the pool attributes are reverted",
+			},
+		},
+		{
+			"deleted node left the pool",
+			nodeLeft("node-a", nil, ""),
+			[]string{"Normal NodeLeft node node-a left the pool"},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				recorder := record.NewFakeRecorder(len(st.expect) + 1)
+				r := &NodePoolReconciler{recorder: recorder}
+				r.recordMembershipChanges(np, []membershipChange{st.change})
+				close(recorder.Events)
+				var get []string
+				for event := range recorder.Events {
+					get = append(get, event)
+				}
+				if !reflect.DeepEqual(get, st.expect) {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -0,0 +1,309 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+const (
+	// leaderLeaseDuration is the duration of the pool Lease, the Lease is
+	// renewed by the controller on behalf of the leader node
+	leaderLeaseDuration = 40 * time.Second
+	// leaderLeaseRenewInterval is the interval to renew the pool Leases
+	leaderLeaseRenewInterval = 10 * time.Second
+)
+
+// leaseCache keeps the pool Leases last written by the controller, so that
+// the reconciliation and the renew loop don't read the Leases from the
+// apiserver every time, as the controller is the only writer of the Leases.
+// An entry is dropped when the Lease fails to be updated, e.g. on a conflict,
+// and the Lease is read from the apiserver again next time
+type leaseCache struct {
+	sync.Mutex
+	leases map[string]*coordinationv1.Lease
+}
+
+func newLeaseCache() *leaseCache {
+	return &leaseCache{leases: map[string]*coordinationv1.Lease{}}
+}
+
+// get returns a copy of the cached Lease, or nil if it is not cached
+func (c *leaseCache) get(name string) *coordinationv1.Lease {
+	c.Lock()
+	defer c.Unlock()
+	if lease, exist := c.leases[name]; exist {
+		return lease.DeepCopy()
+	}
+	return nil
+}
+
+// set caches a copy of the Lease
+func (c *leaseCache) set(lease *coordinationv1.Lease) {
+	c.Lock()
+	defer c.Unlock()
+	c.leases[lease.GetName()] = lease.DeepCopy()
+}
+
+// delete drops the cached Lease
+func (c *leaseCache) delete(name string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.leases, name)
+}
+
+// getLeaderLease returns the Lease of the pool from the lease cache, it is
+// read from the apiserver on a cache miss
+func (r *NodePoolReconciler) getLeaderLease(ctx context.Context, name string) (*coordinationv1.Lease, error) {
+	if lease := r.leases.get(name); lease != nil {
+		return lease, nil
+	}
+	lease, err := r.kubeClient.CoordinationV1().Leases(r.leaseNamespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	r.leases.set(lease)
+	return lease, nil
+}
+
+// updateLeaderLease updates the Lease of the pool and caches the result, the
+// cached Lease is dropped if the update fails
+func (r *NodePoolReconciler) updateLeaderLease(ctx context.Context, lease *coordinationv1.Lease) error {
+	updated, err := r.kubeClient.CoordinationV1().Leases(r.leaseNamespace).Update(ctx, lease, metav1.UpdateOptions{})
+	if err != nil {
+		r.leases.delete(lease.GetName())
+		return err
+	}
+	r.leases.set(updated)
+	return nil
+}
+
+// leaderLeaseName returns the name of the Lease of the pool
+func leaderLeaseName(poolName string) string {
+	return "nodepool-" + poolName
+}
+
+// selectLeader keeps the current leader as long as it is a ready member node,
+// otherwise it picks the first ready node by name. It returns an empty string
+// if there is no ready node in the pool
+func selectLeader(current string, nodes []corev1.Node) string {
+	var candidates []string
+	for _, node := range nodes {
+		if node.DeletionTimestamp != nil || !isNodeReady(node) {
+			continue
+		}
+		if node.GetName() == current {
+			return current
+		}
+		candidates = append(candidates, node.GetName())
+	}
+	if len(candidates) == 0 {
+		return ""
+	}
+	sort.Strings(candidates)
+	return candidates[0]
+}
+
+// conciliateLeaderLabel marks the node if it is the leader of the pool, and
+// unmarks it otherwise, it returns true if the node is changed
+func conciliateLeaderLabel(node *corev1.Node, leader string) bool {
+	_, marked := node.Labels[appsv1alpha1.LabelNodePoolLeader]
+	if node.GetName() != leader {
+		if marked {
+			delete(node.Labels, appsv1alpha1.LabelNodePoolLeader)
+		}
+		return marked
+	}
+	if marked {
+		return false
+	}
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	node.Labels[appsv1alpha1.LabelNodePoolLeader] = "true"
+	return true
+}
+
+// conciliateLeader elects the leader node of the pool and records it in the
+// Lease of the pool, the Lease is owned by the pool so that it is garbage
+// collected with the pool. It returns the name of the leader node, or an
+// empty string if the election is disabled or there is no ready node
+func (r *NodePoolReconciler) conciliateLeader(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool, nodes []corev1.Node) (string, error) {
+	if r.leaseNamespace == "" {
+		return "", nil
+	}
+	name := leaderLeaseName(nodePool.GetName())
+	lease, err := r.getLeaderLease(ctx, name)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return "", err
+	}
+
+	now := metav1.NewMicroTime(time.Now())
+	if apierrors.IsNotFound(err) {
+		leader := selectLeader("", nodes)
+		lease = &coordinationv1.Lease{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: r.leaseNamespace,
+				OwnerReferences: []metav1.OwnerReference{*metav1.NewControllerRef(nodePool,
+					appsv1alpha1.GroupVersion.WithKind("NodePool"))},
+			},
+			Spec: coordinationv1.LeaseSpec{
+				LeaseDurationSeconds: leaseDurationSeconds(),
+			},
+		}
+		if leader != "" {
+			lease.Spec.HolderIdentity = &leader
+			lease.Spec.AcquireTime = &now
+			lease.Spec.RenewTime = &now
+		}
+		created, err := r.kubeClient.CoordinationV1().Leases(r.leaseNamespace).Create(ctx, lease, metav1.CreateOptions{})
+		if err != nil {
+			return "", err
+		}
+		r.leases.set(created)
+		r.recordLeaderChanged(nodePool, "", leader)
+		return leader, nil
+	}
+
+	var current string
+	if lease.Spec.HolderIdentity != nil {
+		current = *lease.Spec.HolderIdentity
+	}
+	leader := selectLeader(current, nodes)
+	switch {
+	case leader != current:
+		transitions := int32(1)
+		if lease.Spec.LeaseTransitions != nil {
+			transitions += *lease.Spec.LeaseTransitions
+		}
+		lease.Spec.LeaseTransitions = &transitions
+		if leader == "" {
+			lease.Spec.HolderIdentity = nil
+			lease.Spec.AcquireTime = nil
+			lease.Spec.RenewTime = nil
+		} else {
+			lease.Spec.HolderIdentity = &leader
+			lease.Spec.AcquireTime = &now
+			lease.Spec.RenewTime = &now
+		}
+	case leader != "" && (lease.Spec.RenewTime == nil ||
+		now.Sub(lease.Spec.RenewTime.Time) >= leaderLeaseRenewInterval):
+		lease.Spec.RenewTime = &now
+	default:
+		return leader, nil
+	}
+	lease.Spec.LeaseDurationSeconds = leaseDurationSeconds()
+	if err := r.updateLeaderLease(ctx, lease); err != nil {
+		return "", err
+	}
+	if leader != current {
+		r.recordLeaderChanged(nodePool, current, leader)
+	}
+	return leader, nil
+}
+
+// renewLeaderLeases renews the Leases of all pools that have a leader node,
+// it runs periodically besides the reconciliation, which only elects the
+// leaders when the pools or their nodes change
+func (r *NodePoolReconciler) renewLeaderLeases(ctx context.Context) {
+	var poolList appsv1alpha1.NodePoolList
+	if err := r.List(ctx, &poolList); err != nil {
+		klog.Errorf("could not list nodepools to renew the leases, %v", err)
+		return
+	}
+	for i := range poolList.Items {
+		nodePool := &poolList.Items[i]
+		if nodePool.DeletionTimestamp != nil || nodePool.Status.LeaderNode == "" {
+			continue
+		}
+		if err := r.renewLeaderLease(ctx, nodePool); err != nil {
+			klog.Errorf("could not renew the lease of nodepool %s, %v", nodePool.GetName(), err)
+		}
+	}
+}
+
+// renewLeaderLease renews the Lease of the pool as long as the leader node is
+// ready, otherwise the Lease expires until the pool elects another leader
+func (r *NodePoolReconciler) renewLeaderLease(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool) error {
+	leader := nodePool.Status.LeaderNode
+	node := corev1.Node{}
+	if err := r.Get(ctx, types.NamespacedName{Name: leader}, &node); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if node.DeletionTimestamp != nil || !isNodeReady(node) {
+		return nil
+	}
+
+	lease, err := r.getLeaderLease(ctx, leaderLeaseName(nodePool.GetName()))
+	if err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !renewLease(lease, leader, metav1.NewMicroTime(time.Now())) {
+		return nil
+	}
+	return r.updateLeaderLease(ctx, lease)
+}
+
+// renewLease renews the Lease if it is held by the leader, it returns true if
+// the Lease is changed
+func renewLease(lease *coordinationv1.Lease, leader string, now metav1.MicroTime) bool {
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != leader {
+		return false
+	}
+	lease.Spec.RenewTime = &now
+	lease.Spec.LeaseDurationSeconds = leaseDurationSeconds()
+	return true
+}
+
+// recordLeaderChanged emits an event against the pool when the leader changes
+func (r *NodePoolReconciler) recordLeaderChanged(nodePool *appsv1alpha1.NodePool,
+	oldLeader, newLeader string) {
+	if oldLeader == newLeader {
+		return
+	}
+	klog.V(4).Infof("the leader of nodepool %s changes from %q to %q",
+		nodePool.GetName(), oldLeader, newLeader)
+	if newLeader == "" {
+		r.recorder.Eventf(nodePool, corev1.EventTypeWarning, "LeaderLost",
+			"no ready node can be elected as the leader, the previous leader is %s", oldLeader)
+		return
+	}
+	r.recorder.Eventf(nodePool, corev1.EventTypeNormal, "LeaderElected",
+		"node %s is elected as the leader", newLeader)
+}
+
+// leaseDurationSeconds returns the duration of the pool Lease in seconds
+func leaseDurationSeconds() *int32 {
+	seconds := int32(leaderLeaseDuration / time.Second)
+	return &seconds
+}
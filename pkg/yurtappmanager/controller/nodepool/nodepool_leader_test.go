@@ -0,0 +1,175 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newLeaderCandidate(name string, ready corev1.ConditionStatus) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{
+				{Type: corev1.NodeReady, Status: ready},
+			},
+		},
+	}
+}
+
+func TestSelectLeader(t *testing.T) {
+	nodes := []corev1.Node{
+		newLeaderCandidate("node-c", corev1.ConditionTrue),
+		newLeaderCandidate("node-b", corev1.ConditionTrue),
+		newLeaderCandidate("node-a", corev1.ConditionFalse),
+	}
+	tests := []struct {
+		name    string
+		current string
+		nodes   []corev1.Node
+		expect  string
+	}{
+		{"ready leader is kept", "node-c", nodes, "node-c"},
+		{"unready leader is replaced", "node-a", nodes, "node-b"},
+		{"leader that left the pool is replaced", "node-d", nodes, "node-b"},
+		{"no leader without ready node", "node-a", nodes[2:], ""},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if get := selectLeader(st.current, st.nodes); get != st.expect {
+				t.Errorf("expect leader %q, but get %q", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestConciliateLeaderLabel(t *testing.T) {
+	node := newLeaderCandidate("node-a", corev1.ConditionTrue)
+	if !conciliateLeaderLabel(&node, "node-a") ||
+		node.Labels[appsv1alpha1.LabelNodePoolLeader] != "true" {
+		t.Fatalf("expect the leader to be marked, but get %v", node.Labels)
+	}
+	if conciliateLeaderLabel(&node, "node-a") {
+		t.Errorf("expect the marked leader not to be changed")
+	}
+	if !conciliateLeaderLabel(&node, "node-b") {
+		t.Fatalf("expect the previous leader to be unmarked")
+	}
+	if _, exist := node.Labels[appsv1alpha1.LabelNodePoolLeader]; exist {
+		t.Errorf("expect the leader label to be removed, but get %v", node.Labels)
+	}
+}
+
+func TestRenewLease(t *testing.T) {
+	leader := "node-a"
+	other := "node-b"
+	renewed := metav1.NewMicroTime(time.Now())
+	tests := []struct {
+		name   string
+		holder *string
+		expect bool
+	}{
+		{"lease held by the leader is renewed", &leader, true},
+		{"lease held by another node is kept", &other, false},
+		{"lease without holder is kept", nil, false},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			lease := &coordinationv1.Lease{Spec: coordinationv1.LeaseSpec{HolderIdentity: st.holder}}
+			if get := renewLease(lease, leader, renewed); get != st.expect {
+				t.Fatalf("expect renewed %v, but get %v", st.expect, get)
+			}
+			if st.expect && (lease.Spec.RenewTime == nil || !lease.Spec.RenewTime.Equal(&renewed)) {
+				t.Errorf("expect the renew time %v, but get %v", renewed, lease.Spec.RenewTime)
+			}
+			if !st.expect && lease.Spec.RenewTime != nil {
+				t.Errorf("expect the lease not to be renewed, but get %v", lease.Spec.RenewTime)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestGetLeaderLease(t *testing.T) {
+	holder := "node-a"
+	lease := &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{Name: leaderLeaseName("hangzhou"), Namespace: "kube-system"},
+		Spec:       coordinationv1.LeaseSpec{HolderIdentity: &holder},
+	}
+	kubeClient := fake.NewSimpleClientset(lease)
+	r := &NodePoolReconciler{kubeClient: kubeClient, leaseNamespace: "kube-system", leases: newLeaseCache()}
+	ctx := context.TODO()
+	countGets := func() int {
+		var gets int
+		for _, action := range kubeClient.Actions() {
+			if action.GetVerb() == "get" {
+				gets++
+			}
+		}
+		return gets
+	}
+
+	get, err := r.getLeaderLease(ctx, lease.GetName())
+	if err != nil {
+		t.Fatalf("fail to get the lease, %v", err)
+	}
+	if !renewLease(get, holder, metav1.NewMicroTime(time.Now())) {
+		t.Fatalf("expect the lease to be renewed")
+	}
+	if err := r.updateLeaderLease(ctx, get); err != nil {
+		t.Fatalf("fail to update the lease, %v", err)
+	}
+	cached, err := r.getLeaderLease(ctx, lease.GetName())
+	if err != nil {
+		t.Fatalf("fail to get the cached lease, %v", err)
+	}
+	if cached.Spec.RenewTime == nil {
+		t.Errorf("expect the cached lease to be renewed")
+	}
+	if gets := countGets(); gets != 1 {
+		t.Errorf("expect the lease to be read from the apiserver once, but get %d", gets)
+	}
+
+	// the cached lease is dropped when the update fails
+	if err := kubeClient.CoordinationV1().Leases("kube-system").Delete(ctx, lease.GetName(), metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("fail to delete the lease, %v", err)
+	}
+	if err := r.updateLeaderLease(ctx, cached); err == nil {
+		t.Fatalf("expect the update of the deleted lease to fail")
+	}
+	if r.leases.get(lease.GetName()) != nil {
+		t.Errorf("expect the cached lease to be dropped")
+	}
+}
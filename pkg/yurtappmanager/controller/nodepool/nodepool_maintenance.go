@@ -0,0 +1,187 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+)
+
+// maintenanceRequeueInterval is the interval to check the drain progress, as
+// the controller doesn't watch pods
+const maintenanceRequeueInterval = 10 * time.Second
+
+// cordonNode marks the node as unschedulable for the maintenance of the pool,
+// it returns true if the node is changed. Nodes cordoned by others are left
+// alone, so that they will not be uncordoned when the maintenance ends
+func cordonNode(node *corev1.Node, poolName string) bool {
+	by, cordonedByPool := node.Annotations[appsv1alpha1.AnnotationCordonedByNodePool]
+	if node.Spec.Unschedulable && (!cordonedByPool || by == poolName) {
+		return false
+	}
+	node.Spec.Unschedulable = true
+	if node.Annotations == nil {
+		node.Annotations = make(map[string]string)
+	}
+	node.Annotations[appsv1alpha1.AnnotationCordonedByNodePool] = poolName
+	return true
+}
+
+// uncordonNode marks the node as schedulable if it is cordoned by a nodepool,
+// it returns true if the node is changed
+func uncordonNode(node *corev1.Node) bool {
+	if _, exist := node.Annotations[appsv1alpha1.AnnotationCordonedByNodePool]; !exist {
+		return false
+	}
+	delete(node.Annotations, appsv1alpha1.AnnotationCordonedByNodePool)
+	node.Spec.Unschedulable = false
+	return true
+}
+
+// isPodEvictable checks if the pod should be evicted when draining the node,
+// terminated pods, mirror pods and pods managed by DaemonSets are skipped
+func isPodEvictable(pod *corev1.Pod) bool {
+	if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+		return false
+	}
+	if _, exist := pod.Annotations[corev1.MirrorPodAnnotationKey]; exist {
+		return false
+	}
+	if ref := metav1.GetControllerOf(pod); ref != nil && ref.Kind == "DaemonSet" {
+		return false
+	}
+	return true
+}
+
+// isPodUnmanaged checks if the pod is not managed by a controller, such a pod
+// is not recreated elsewhere once evicted, so it is not evicted by the drain
+// and is left for the user to delete
+func isPodUnmanaged(pod *corev1.Pod) bool {
+	return metav1.GetControllerOf(pod) == nil
+}
+
+// maintenanceMessage explains why the pods are not evicted, or returns an
+// empty string if all the pods are evicted
+func maintenanceMessage(blocked, unmanaged []string) string {
+	var messages []string
+	if len(blocked) != 0 {
+		messages = append(messages, fmt.Sprintf("fail to evict pods %v, they may be protected by PodDisruptionBudgets", blocked))
+	}
+	if len(unmanaged) != 0 {
+		messages = append(messages, fmt.Sprintf("pods %v are not managed by a controller and are not evicted, delete them to complete the drain", unmanaged))
+	}
+	return strings.Join(messages, "; ")
+}
+
+// conciliateMaintenance evicts pods from the member nodes if required and
+// returns the progress of the maintenance. Nodes are expected to be cordoned
+// already, so that the evicted pods will not be scheduled back. The pods not
+// managed by a controller are not evicted, they are counted as pending pods
+// until they are deleted
+func (r *NodePoolReconciler) conciliateMaintenance(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool,
+	nodes []corev1.Node) (*appsv1alpha1.NodePoolMaintenanceStatus, error) {
+	if nodePool.Spec.Maintenance == nil {
+		return nil, nil
+	}
+
+	status := &appsv1alpha1.NodePoolMaintenanceStatus{}
+	var blocked, unmanaged []string
+	for _, node := range nodes {
+		if node.Spec.Unschedulable {
+			status.CordonedNodes++
+		}
+		if !nodePool.Spec.Maintenance.Drain {
+			continue
+		}
+
+		var podList corev1.PodList
+		if err := r.List(ctx, &podList, client.MatchingFields{
+			fieldindex.IndexNameForPodNodeName: node.GetName(),
+		}); err != nil {
+			return nil, err
+		}
+
+		var pending int32
+		for i := range podList.Items {
+			pod := &podList.Items[i]
+			if !isPodEvictable(pod) {
+				continue
+			}
+			pending++
+			if pod.DeletionTimestamp != nil {
+				continue
+			}
+			if isPodUnmanaged(pod) {
+				unmanaged = append(unmanaged, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+				continue
+			}
+			if err := r.evictPod(ctx, pod); err != nil {
+				if apierrors.IsNotFound(err) {
+					pending--
+					continue
+				}
+				klog.Errorf("fail to evict pod %s/%s from node %s: %v",
+					pod.Namespace, pod.Name, node.GetName(), err)
+				blocked = append(blocked, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+				continue
+			}
+			r.recorder.Eventf(nodePool, corev1.EventTypeNormal, "EvictPod",
+				"evict pod %s/%s from node %s", pod.Namespace, pod.Name, node.GetName())
+		}
+		status.PendingPods += pending
+		if pending == 0 {
+			status.DrainedNodes++
+		}
+	}
+
+	status.Phase = appsv1alpha1.MaintenanceCompleted
+	if int(status.CordonedNodes) != len(nodes) ||
+		(nodePool.Spec.Maintenance.Drain && status.PendingPods != 0) {
+		status.Phase = appsv1alpha1.MaintenanceInProgress
+	}
+	if len(unmanaged) != 0 {
+		r.recorder.Eventf(nodePool, corev1.EventTypeWarning, "UnmanagedPods",
+			"pods %v are not managed by a controller and are not evicted", unmanaged)
+	}
+	status.Message = maintenanceMessage(blocked, unmanaged)
+	return status, nil
+}
+
+// evictPod evicts the pod through the eviction API, which respects the
+// PodDisruptionBudgets of the pod
+func (r *NodePoolReconciler) evictPod(ctx context.Context, pod *corev1.Pod) error {
+	eviction := &policyv1beta1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pod.Name,
+			Namespace: pod.Namespace,
+		},
+	}
+	return r.kubeClient.PolicyV1beta1().Evictions(pod.Namespace).Evict(ctx, eviction)
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestCordonNode(t *testing.T) {
+	tests := []struct {
+		name                string
+		node                *corev1.Node
+		expectUpdated       bool
+		expectCordonedBy    string
+		expectUnschedulable bool
+	}{
+		{
+			"schedulable node is cordoned",
+			&corev1.Node{},
+			true,
+			"foo",
+			true,
+		},
+		{
+			"node cordoned by others is left alone",
+			&corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}},
+			false,
+			"",
+			true,
+		},
+		{
+			"node cordoned by the pool is not changed",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{appsv1alpha1.AnnotationCordonedByNodePool: "foo"},
+				},
+				Spec: corev1.NodeSpec{Unschedulable: true},
+			},
+			false,
+			"foo",
+			true,
+		},
+		{
+			"node cordoned by the previous pool is taken over",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{appsv1alpha1.AnnotationCordonedByNodePool: "bar"},
+				},
+				Spec: corev1.NodeSpec{Unschedulable: true},
+			},
+			true,
+			"foo",
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				updated := cordonNode(st.node, "foo")
+				if updated != st.expectUpdated {
+					t.Fatalf("\t%s\texpect updated %v, but get %v", failed, st.expectUpdated, updated)
+				}
+				by := st.node.Annotations[appsv1alpha1.AnnotationCordonedByNodePool]
+				if by != st.expectCordonedBy || st.node.Spec.Unschedulable != st.expectUnschedulable {
+					t.Fatalf("\t%s\texpect cordoned by %q(%v), but get %q(%v)", failed,
+						st.expectCordonedBy, st.expectUnschedulable, by, st.node.Spec.Unschedulable)
+				}
+				t.Logf("\t%s\texpect updated %v, get %v", succeed, st.expectUpdated, updated)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestUncordonNode(t *testing.T) {
+	cordonedByOthers := &corev1.Node{Spec: corev1.NodeSpec{Unschedulable: true}}
+	if uncordonNode(cordonedByOthers) || !cordonedByOthers.Spec.Unschedulable {
+		t.Errorf("node cordoned by others should not be uncordoned")
+	}
+
+	cordonedByPool := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{appsv1alpha1.AnnotationCordonedByNodePool: "foo"},
+		},
+		Spec: corev1.NodeSpec{Unschedulable: true},
+	}
+	if !uncordonNode(cordonedByPool) || cordonedByPool.Spec.Unschedulable {
+		t.Errorf("node cordoned by the pool should be uncordoned")
+	}
+	if _, exist := cordonedByPool.Annotations[appsv1alpha1.AnnotationCordonedByNodePool]; exist {
+		t.Errorf("the %s annotation should be removed", appsv1alpha1.AnnotationCordonedByNodePool)
+	}
+}
+
+func TestIsPodEvictable(t *testing.T) {
+	isController := true
+	tests := []struct {
+		name   string
+		pod    *corev1.Pod
+		expect bool
+	}{
+		{
+			"running pod",
+			&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodRunning}},
+			true,
+		},
+		{
+			"succeeded pod",
+			&corev1.Pod{Status: corev1.PodStatus{Phase: corev1.PodSucceeded}},
+			false,
+		},
+		{
+			"mirror pod",
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{corev1.MirrorPodAnnotationKey: "foo"},
+				},
+			},
+			false,
+		},
+		{
+			"daemonset pod",
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "DaemonSet", Name: "foo", Controller: &isController},
+					},
+				},
+			},
+			false,
+		},
+		{
+			"replicaset pod",
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "ReplicaSet", Name: "foo", Controller: &isController},
+					},
+				},
+			},
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := isPodEvictable(st.pod)
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestIsPodUnmanaged(t *testing.T) {
+	isController := true
+	tests := []struct {
+		name   string
+		pod    *corev1.Pod
+		expect bool
+	}{
+		{
+			"bare pod",
+			&corev1.Pod{},
+			true,
+		},
+		{
+			"pod owned without controller",
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{{Kind: "ConfigMap", Name: "foo"}},
+				},
+			},
+			true,
+		},
+		{
+			"replicaset pod",
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					OwnerReferences: []metav1.OwnerReference{
+						{Kind: "ReplicaSet", Name: "foo", Controller: &isController},
+					},
+				},
+			},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := isPodUnmanaged(st.pod)
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestMaintenanceMessage(t *testing.T) {
+	if get := maintenanceMessage(nil, nil); get != "" {
+		t.Errorf("expect no message, but get %q", get)
+	}
+	expect := "fail to evict pods [default/a], they may be protected by PodDisruptionBudgets; " +
+		"pods [default/b] are not managed by a controller and are not evicted, delete them to complete the drain"
+	if get := maintenanceMessage([]string{"default/a"}, []string{"default/b"}); get != expect {
+		t.Errorf("expect message %q, but get %q", expect, get)
+	}
+}
@@ -0,0 +1,227 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"encoding/json"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/klog"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// selectorSpecificity returns the number of requirements of the
+// NodePool.Spec.Selector
+func selectorSpecificity(np *appsv1alpha1.NodePool) int {
+	if np.Spec.Selector == nil {
+		return 0
+	}
+	return len(np.Spec.Selector.MatchLabels) +
+		len(np.Spec.Selector.MatchExpressions)
+}
+
+// nodePoolSelector converts the NodePool.Spec.Selector to a labels.Selector.
+// N.B. a nil or empty selector selects nothing, as a pool that selects every
+// node in the cluster is never what the user wants
+func nodePoolSelector(np *appsv1alpha1.NodePool) (labels.Selector, error) {
+	if selectorSpecificity(np) == 0 {
+		return labels.Nothing(), nil
+	}
+	return metav1.LabelSelectorAsSelector(np.Spec.Selector)
+}
+
+// selectableLabels returns the node labels that will be matched against the
+// NodePool selectors. Labels that are maintained by the nodepool controller,
+// i.e. the nodepool labels and the labels added from the NodePool.Spec.Labels,
+// are excluded, otherwise a node would be held by its pool forever
+func selectableLabels(node *corev1.Node) labels.Set {
+	set := labels.Set{}
+	for k, v := range node.Labels {
+		set[k] = v
+	}
+	delete(set, appsv1alpha1.LabelCurrentNodePool)
+	delete(set, appsv1alpha1.LabelDesiredNodePool)
+
+	preAttrs, exist := node.Annotations[appsv1alpha1.AnnotationPrevAttrs]
+	if !exist {
+		return set
+	}
+	var npra NodePoolRelatedAttributes
+	if err := json.Unmarshal([]byte(preAttrs), &npra); err != nil {
+		klog.Errorf("fail to unmarshal the previous attributes of node(%s): %v",
+			node.GetName(), err)
+		return set
+	}
+	for k, v := range npra.Labels {
+		if set[k] != v {
+			continue
+		}
+		if ov, exist := npra.OriginalLabels[k]; exist {
+			set[k] = ov
+		} else {
+			delete(set, k)
+		}
+	}
+	return set
+}
+
+// poolSelector is the parsed selector of a NodePool
+type poolSelector struct {
+	name        string
+	selector    labels.Selector
+	specificity int
+}
+
+// newPoolSelector parses the selector of the NodePool, it returns false if
+// the NodePool is being deleted or its selector is invalid
+func newPoolSelector(np *appsv1alpha1.NodePool) (poolSelector, bool) {
+	if np.DeletionTimestamp != nil {
+		return poolSelector{}, false
+	}
+	selector, err := nodePoolSelector(np)
+	if err != nil {
+		klog.Errorf("invalid selector of nodepool(%s): %v", np.GetName(), err)
+		return poolSelector{}, false
+	}
+	return poolSelector{
+		name:        np.GetName(),
+		selector:    selector,
+		specificity: selectorSpecificity(np),
+	}, true
+}
+
+// resolveNodePool returns the name of the nodepool that the node should
+// belong to, or an empty string if the node doesn't belong to any pool.
+// The pool is decided by the following rules, in order:
+// 1. the pool specified by the `apps.openyurt.io/desired-nodepool` label;
+// 2. the pool the node currently belongs to, if its selector still matches;
+// 3. the matching pool with the most selector requirements;
+// 4. the matching pool with the lexicographically smallest name.
+func resolveNodePool(node *corev1.Node, pools []appsv1alpha1.NodePool) string {
+	selectors := make([]poolSelector, 0, len(pools))
+	for i := range pools {
+		if ps, ok := newPoolSelector(&pools[i]); ok {
+			selectors = append(selectors, ps)
+		}
+	}
+	return matchNodePool(node, selectors)
+}
+
+// matchNodePool resolves the nodepool of the node against the parsed
+// selectors, following the rules of resolveNodePool
+func matchNodePool(node *corev1.Node, selectors []poolSelector) string {
+	if np := node.Labels[appsv1alpha1.LabelDesiredNodePool]; np != "" {
+		return np
+	}
+
+	nodeLabels := selectableLabels(node)
+	var matched *poolSelector
+	for i := range selectors {
+		ps := &selectors[i]
+		if !ps.selector.Matches(nodeLabels) {
+			continue
+		}
+		if ps.name == node.Labels[appsv1alpha1.LabelCurrentNodePool] {
+			return ps.name
+		}
+		if matched == nil ||
+			ps.specificity > matched.specificity ||
+			(ps.specificity == matched.specificity && ps.name < matched.name) {
+			matched = ps
+		}
+	}
+
+	if matched == nil {
+		return ""
+	}
+	return matched.name
+}
+
+// nodePoolSelectorCache caches the parsed selectors of the NodePools, so that
+// the nodepool of a node is resolved on the node events without listing and
+// parsing all the NodePools. It is loaded on the first use and refreshed on
+// the NodePool events
+type nodePoolSelectorCache struct {
+	sync.RWMutex
+	loaded    bool
+	selectors map[string]poolSelector
+}
+
+func newNodePoolSelectorCache() *nodePoolSelectorCache {
+	return &nodePoolSelectorCache{selectors: map[string]poolSelector{}}
+}
+
+// isLoaded checks if the cache has been loaded with all the NodePools
+func (c *nodePoolSelectorCache) isLoaded() bool {
+	c.RLock()
+	defer c.RUnlock()
+	return c.loaded
+}
+
+// load adds the NodePools to the cache, the selectors refreshed by the
+// NodePool events in the meantime are kept
+func (c *nodePoolSelectorCache) load(pools []appsv1alpha1.NodePool) {
+	c.Lock()
+	defer c.Unlock()
+	if c.loaded {
+		return
+	}
+	for i := range pools {
+		if _, exist := c.selectors[pools[i].GetName()]; exist {
+			continue
+		}
+		if ps, ok := newPoolSelector(&pools[i]); ok {
+			c.selectors[ps.name] = ps
+		}
+	}
+	c.loaded = true
+}
+
+// update refreshes the selector of the NodePool, the NodePool being deleted
+// is removed
+func (c *nodePoolSelectorCache) update(np *appsv1alpha1.NodePool) {
+	c.Lock()
+	defer c.Unlock()
+	if ps, ok := newPoolSelector(np); ok {
+		c.selectors[ps.name] = ps
+		return
+	}
+	delete(c.selectors, np.GetName())
+}
+
+// delete removes the selector of the NodePool
+func (c *nodePoolSelectorCache) delete(name string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.selectors, name)
+}
+
+// resolve returns the nodepool that the node should belong to, following
+// the rules of resolveNodePool
+func (c *nodePoolSelectorCache) resolve(node *corev1.Node) string {
+	c.RLock()
+	selectors := make([]poolSelector, 0, len(c.selectors))
+	for _, ps := range c.selectors {
+		selectors = append(selectors, ps)
+	}
+	c.RUnlock()
+	return matchNodePool(node, selectors)
+}
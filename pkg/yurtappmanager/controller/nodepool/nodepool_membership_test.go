@@ -0,0 +1,324 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newSelectorPool(name string, selector *metav1.LabelSelector) appsv1alpha1.NodePool {
+	return appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       appsv1alpha1.NodePoolSpec{Selector: selector},
+	}
+}
+
+func TestResolveNodePool(t *testing.T) {
+	pools := []appsv1alpha1.NodePool{
+		newSelectorPool("region", &metav1.LabelSelector{
+			MatchLabels: map[string]string{"region": "hangzhou"},
+		}),
+		newSelectorPool("site", &metav1.LabelSelector{
+			MatchLabels: map[string]string{"region": "hangzhou"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      "site",
+					Operator: metav1.LabelSelectorOpIn,
+					Values:   []string{"site-a", "site-b"},
+				},
+			},
+		}),
+		newSelectorPool("site-alias", &metav1.LabelSelector{
+			MatchLabels: map[string]string{"region": "hangzhou"},
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{
+					Key:      "site",
+					Operator: metav1.LabelSelectorOpExists,
+				},
+			},
+		}),
+		newSelectorPool("default", &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				appsv1alpha1.LabelCurrentNodePool: "default",
+			},
+		}),
+		newSelectorPool("empty", &metav1.LabelSelector{}),
+		newSelectorPool("foo", &metav1.LabelSelector{
+			MatchLabels: map[string]string{"foo": "bar"},
+		}),
+	}
+
+	tests := []struct {
+		name   string
+		node   *corev1.Node
+		expect string
+	}{
+		{
+			"desired label takes precedence",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						appsv1alpha1.LabelDesiredNodePool: "foo",
+						"region":                          "hangzhou",
+					},
+				},
+			},
+			"foo",
+		},
+		{
+			"no pool matches",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"region": "beijing"},
+				},
+			},
+			"",
+		},
+		{
+			"single pool matches",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{"region": "hangzhou"},
+				},
+			},
+			"region",
+		},
+		{
+			"the most specific pool wins and ties are broken by name",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"region": "hangzhou",
+						"site":   "site-a",
+					},
+				},
+			},
+			"site",
+		},
+		{
+			"stay in the current pool if it still matches",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"region":                          "hangzhou",
+						"site":                            "site-a",
+						appsv1alpha1.LabelCurrentNodePool: "region",
+					},
+				},
+			},
+			"region",
+		},
+		{
+			"controller managed labels are not matched",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						appsv1alpha1.LabelCurrentNodePool: "default",
+					},
+				},
+			},
+			"",
+		},
+		{
+			"labels added by the pool are not matched",
+			&corev1.Node{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{
+						"foo":                             "bar",
+						appsv1alpha1.LabelCurrentNodePool: "foo",
+					},
+					Annotations: map[string]string{
+						appsv1alpha1.AnnotationPrevAttrs: `{"labels":{"foo":"bar"}}`,
+					},
+				},
+			},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := resolveNodePool(st.node, pools)
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestNodePoolSelectorCache(t *testing.T) {
+	siteSelector := func(site string) *metav1.LabelSelector {
+		return &metav1.LabelSelector{MatchLabels: map[string]string{"site": site}}
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Labels: map[string]string{"site": "site-a"},
+		},
+	}
+	deleting := newSelectorPool("site-b", siteSelector("site-a"))
+	deleting.DeletionTimestamp = &metav1.Time{}
+
+	tests := []struct {
+		name   string
+		change func(c *nodePoolSelectorCache)
+		expect string
+	}{
+		{
+			"loaded pool",
+			func(c *nodePoolSelectorCache) {},
+			"site-a",
+		},
+		{
+			"pool created after loading",
+			func(c *nodePoolSelectorCache) {
+				p := newSelectorPool("hangzhou", &metav1.LabelSelector{
+					MatchLabels: map[string]string{"site": "site-a"},
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "site", Operator: metav1.LabelSelectorOpExists},
+					},
+				})
+				c.update(&p)
+			},
+			"hangzhou",
+		},
+		{
+			"pool selector updated",
+			func(c *nodePoolSelectorCache) {
+				p := newSelectorPool("site-a", siteSelector("site-c"))
+				c.update(&p)
+			},
+			"",
+		},
+		{
+			"pool deleted",
+			func(c *nodePoolSelectorCache) {
+				c.delete("site-a")
+			},
+			"",
+		},
+		{
+			"pool being deleted",
+			func(c *nodePoolSelectorCache) {
+				c.update(&deleting)
+			},
+			"site-a",
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				c := newNodePoolSelectorCache()
+				c.load([]appsv1alpha1.NodePool{
+					newSelectorPool("site-a", siteSelector("site-a")),
+					deleting,
+				})
+				st.change(c)
+				get := c.resolve(node)
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestSelectDesiredNodes(t *testing.T) {
+	siteA := newSelectorPool("site-a", &metav1.LabelSelector{
+		MatchLabels: map[string]string{"site": "site-a"},
+	})
+	pools := []appsv1alpha1.NodePool{siteA, newSelectorPool("relabel", nil)}
+	selector, err := nodePoolSelector(&siteA)
+	if err != nil {
+		t.Fatalf("invalid selector, %v", err)
+	}
+	newNode := func(name, site, currentPool, prevAttrs string) corev1.Node {
+		node := corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: map[string]string{"site": site, appsv1alpha1.LabelCurrentNodePool: currentPool},
+			},
+		}
+		if prevAttrs != "" {
+			node.Annotations = map[string]string{appsv1alpha1.AnnotationPrevAttrs: prevAttrs}
+		}
+		return node
+	}
+	tests := []struct {
+		name   string
+		node   corev1.Node
+		expect bool
+	}{
+		{
+			"node with the selected label",
+			newNode("node", "site-a", "", ""),
+			true,
+		},
+		{
+			"selected label applied by a pool",
+			newNode("node", "site-a", "relabel", `{"labels":{"site":"site-a"}}`),
+			false,
+		},
+		{
+			"selected label overwritten by a pool",
+			newNode("node", "site-b", "relabel", `{"labels":{"site":"site-b"},"originalLabels":{"site":"site-a"}}`),
+			true,
+		},
+		{
+			"node with the desired label",
+			func() corev1.Node {
+				node := newNode("node", "site-a", "", "")
+				node.Labels[appsv1alpha1.LabelDesiredNodePool] = "site-a"
+				return node
+			}(),
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := len(selectDesiredNodes(&siteA, selector, []corev1.Node{st.node}, pools)) == 1
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+const (
+	metricsNamespace = "yurt_app_manager"
+	metricsSubsystem = "nodepool"
+)
+
+var (
+	nodePoolReadyNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "ready_nodes",
+		Help:      "Number of ready nodes in the nodepool.",
+	}, []string{"nodepool", "type"})
+
+	nodePoolUnreadyNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "unready_nodes",
+		Help:      "Number of unready nodes in the nodepool.",
+	}, []string{"nodepool", "type"})
+
+	nodePoolNodes = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "nodes",
+		Help:      "Number of member nodes in the nodepool.",
+	}, []string{"nodepool", "type"})
+
+	nodePoolReconcileErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "reconcile_errors_total",
+		Help:      "Total number of reconciliation errors per nodepool.",
+	}, []string{"nodepool"})
+
+	nodeUpdateDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Subsystem: metricsSubsystem,
+		Name:      "node_update_duration_seconds",
+		Help:      "Duration in seconds of updating the nodepool related attributes of nodes.",
+		Buckets:   prometheus.ExponentialBuckets(0.005, 2, 12),
+	}, []string{"nodepool"})
+)
+
+// nodePoolTypes records the type of every nodepool that has metrics, so that
+// the metrics with the stale type can be deleted once the pool type changes
+var (
+	nodePoolTypesLock sync.Mutex
+	nodePoolTypes     = make(map[string]string)
+)
+
+func init() {
+	metrics.Registry.MustRegister(
+		nodePoolReadyNodes,
+		nodePoolUnreadyNodes,
+		nodePoolNodes,
+		nodePoolReconcileErrors,
+		nodeUpdateDuration,
+	)
+}
+
+// recordNodePoolMetrics exports the number of nodes in the nodepool
+func recordNodePoolMetrics(np *appsv1alpha1.NodePool, readyNode, notReadyNode int32) {
+	poolType := string(np.Spec.Type)
+
+	nodePoolTypesLock.Lock()
+	if oldType, exist := nodePoolTypes[np.GetName()]; exist && oldType != poolType {
+		deleteNodePoolGauges(np.GetName(), oldType)
+	}
+	nodePoolTypes[np.GetName()] = poolType
+	nodePoolTypesLock.Unlock()
+
+	nodePoolReadyNodes.WithLabelValues(np.GetName(), poolType).Set(float64(readyNode))
+	nodePoolUnreadyNodes.WithLabelValues(np.GetName(), poolType).Set(float64(notReadyNode))
+	nodePoolNodes.WithLabelValues(np.GetName(), poolType).Set(float64(readyNode + notReadyNode))
+}
+
+// deleteNodePoolMetrics deletes all metrics of the nodepool
+func deleteNodePoolMetrics(poolName string) {
+	nodePoolTypesLock.Lock()
+	if poolType, exist := nodePoolTypes[poolName]; exist {
+		deleteNodePoolGauges(poolName, poolType)
+		delete(nodePoolTypes, poolName)
+	}
+	nodePoolTypesLock.Unlock()
+
+	nodePoolReconcileErrors.DeleteLabelValues(poolName)
+	nodeUpdateDuration.DeleteLabelValues(poolName)
+}
+
+func deleteNodePoolGauges(poolName, poolType string) {
+	nodePoolReadyNodes.DeleteLabelValues(poolName, poolType)
+	nodePoolUnreadyNodes.DeleteLabelValues(poolName, poolType)
+	nodePoolNodes.DeleteLabelValues(poolName, poolType)
+}
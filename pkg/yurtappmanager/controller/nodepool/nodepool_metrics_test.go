@@ -0,0 +1,110 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// gatherNodePoolSeries gathers the nodepool metrics through a private
+// registry, and returns the series of the pool in the form of
+// <metric>{<type>} <value>, the value is only set for the gauges
+func gatherNodePoolSeries(t *testing.T, poolName string) []string {
+	reg := prometheus.NewPedanticRegistry()
+	reg.MustRegister(nodePoolReadyNodes, nodePoolUnreadyNodes, nodePoolNodes,
+		nodePoolReconcileErrors, nodeUpdateDuration)
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("fail to gather the metrics: %v", err)
+	}
+
+	var series []string
+	for _, family := range families {
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, lp := range m.GetLabel() {
+				labels[lp.GetName()] = lp.GetValue()
+			}
+			if labels["nodepool"] != poolName {
+				continue
+			}
+			s := fmt.Sprintf("%s{%s}", family.GetName(), labels["type"])
+			if m.GetGauge() != nil {
+				s = fmt.Sprintf("%s %v", s, m.GetGauge().GetValue())
+			}
+			series = append(series, s)
+		}
+	}
+	sort.Strings(series)
+	return series
+}
+
+func TestRecordNodePoolMetrics(t *testing.T) {
+	np := &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-record"},
+		Spec:       appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Edge},
+	}
+	defer deleteNodePoolMetrics(np.GetName())
+
+	recordNodePoolMetrics(np, 2, 1)
+	expect := []string{
+		"yurt_app_manager_nodepool_nodes{Edge} 3",
+		"yurt_app_manager_nodepool_ready_nodes{Edge} 2",
+		"yurt_app_manager_nodepool_unready_nodes{Edge} 1",
+	}
+	if get := gatherNodePoolSeries(t, np.GetName()); !reflect.DeepEqual(get, expect) {
+		t.Fatalf("expect series %v, but get %v", expect, get)
+	}
+
+	// the series of the stale type are deleted once the pool type changes
+	np.Spec.Type = appsv1alpha1.Cloud
+	recordNodePoolMetrics(np, 3, 0)
+	expect = []string{
+		"yurt_app_manager_nodepool_nodes{Cloud} 3",
+		"yurt_app_manager_nodepool_ready_nodes{Cloud} 3",
+		"yurt_app_manager_nodepool_unready_nodes{Cloud} 0",
+	}
+	if get := gatherNodePoolSeries(t, np.GetName()); !reflect.DeepEqual(get, expect) {
+		t.Errorf("expect series %v after changing the pool type, but get %v", expect, get)
+	}
+}
+
+func TestDeleteNodePoolMetrics(t *testing.T) {
+	np := &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "metrics-delete"},
+		Spec:       appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Edge},
+	}
+	recordNodePoolMetrics(np, 1, 1)
+	nodePoolReconcileErrors.WithLabelValues(np.GetName()).Inc()
+	nodeUpdateDuration.WithLabelValues(np.GetName()).Observe(0.1)
+	if get := gatherNodePoolSeries(t, np.GetName()); len(get) != 5 {
+		t.Fatalf("expect 5 series of the pool, but get %v", get)
+	}
+
+	deleteNodePoolMetrics(np.GetName())
+	if get := gatherNodePoolSeries(t, np.GetName()); len(get) != 0 {
+		t.Errorf("expect no series once the pool is removed, but get %v", get)
+	}
+}
@@ -0,0 +1,209 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+// isNodeMigrating checks if the node is migrating to another pool, the
+// schedulability of a migrating node is managed by the migration
+func isNodeMigrating(node *corev1.Node) bool {
+	return node.Annotations[appsv1alpha1.AnnotationMigrateToNodePool] != ""
+}
+
+// isPoolPinnedPod checks if the pod is pinned to the pool by its nodeSelector
+// or required node affinity, e.g. the pods of the UnitedDeployment and the
+// YurtAppDaemon, and needs to be moved away before the node leaves the pool.
+// N.B. the pool-name label of the UnitedDeployment pods is the name of the
+// UnitedDeployment pool, which may differ from the name of the NodePool
+func isPoolPinnedPod(pod *corev1.Pod, poolName string) bool {
+	return isPodEvictable(pod) && nodepoolutil.PinnedNodePool(pod) == poolName
+}
+
+// listPoolPinnedPods lists the pods of the pool-scoped workloads, i.e. the
+// UnitedDeployment and the YurtAppDaemon, that are pinned to the node by the
+// scheduling constraints on the pool
+func (r *NodePoolReconciler) listPoolPinnedPods(ctx context.Context,
+	node *corev1.Node, poolName string) ([]corev1.Pod, error) {
+	var podList corev1.PodList
+	if err := r.List(ctx, &podList, client.MatchingFields{
+		fieldindex.IndexNameForPodNodeName: node.GetName(),
+	}); err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for i := range podList.Items {
+		if isPoolPinnedPod(&podList.Items[i], poolName) {
+			pods = append(pods, podList.Items[i])
+		}
+	}
+	return pods, nil
+}
+
+// conciliateMigrations moves the nodes whose migrate-to annotation pointing to
+// the pool into the pool, it returns the progress of the unfinished
+// migrations, the nodes switched to the pool are in the Switching phase
+func (r *NodePoolReconciler) conciliateMigrations(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool,
+	pools []appsv1alpha1.NodePool) ([]appsv1alpha1.NodeMigrationStatus, error) {
+	nodes, err := r.listNodesByIndex(ctx,
+		fieldindex.IndexNameForNodeMigrateToNodePool, nodePool.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	var migrations []appsv1alpha1.NodeMigrationStatus
+	for i := range nodes {
+		migration, err := r.migrateNode(ctx, nodePool, &nodes[i], pools)
+		if err != nil {
+			return nil, err
+		}
+		if migration != nil {
+			migrations = append(migrations, *migration)
+		}
+	}
+	return migrations, nil
+}
+
+// migrateNode moves the migration of the node forward by one phase:
+// 1. cordon the node, so that the evicted pods will not come back;
+// 2. evict the pods of the pool-scoped workloads in the old pool;
+// 3. switch the node from the old pool to the new pool;
+// 4. uncordon the node and remove the migrate-to annotation.
+// It returns nil status once the migration is finished
+func (r *NodePoolReconciler) migrateNode(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool, node *corev1.Node,
+	pools []appsv1alpha1.NodePool) (*appsv1alpha1.NodeMigrationStatus, error) {
+	fromPool := node.Labels[appsv1alpha1.LabelCurrentNodePool]
+	migration := &appsv1alpha1.NodeMigrationStatus{
+		Node:         node.GetName(),
+		FromNodePool: fromPool,
+	}
+
+	// 4. the node has joined the pool
+	if fromPool == nodePool.GetName() {
+		if nodePool.Spec.Maintenance == nil {
+			uncordonNode(node)
+		}
+		delete(node.Annotations, appsv1alpha1.AnnotationMigrateToNodePool)
+		if err := r.updateNode(ctx, nodePool.GetName(), node); err != nil {
+			return nil, err
+		}
+		r.recorder.Eventf(node, corev1.EventTypeNormal, "MigrationCompleted",
+			"node is migrated to nodepool %s", nodePool.GetName())
+		return nil, nil
+	}
+
+	// 1. cordon the node, nodes cordoned by the administrator are kept as is
+	// and will not be uncordoned after the migration
+	by := node.Annotations[appsv1alpha1.AnnotationCordonedByNodePool]
+	if !node.Spec.Unschedulable || (by != "" && by != nodePool.GetName()) {
+		migration.Phase = appsv1alpha1.MigrationCordoning
+		cordonNode(node, nodePool.GetName())
+		if err := r.updateNode(ctx, nodePool.GetName(), node); err != nil {
+			return nil, err
+		}
+		r.recorder.Eventf(node, corev1.EventTypeNormal, "MigrationStarted",
+			"node is migrating from nodepool %s to nodepool %s", fromPool, nodePool.GetName())
+		return migration, nil
+	}
+
+	// 2. evict the pods that are pinned to the node by the old pool
+	if fromPool != "" {
+		pods, err := r.listPoolPinnedPods(ctx, node, fromPool)
+		if err != nil {
+			return nil, err
+		}
+		if len(pods) != 0 {
+			migration.Phase = appsv1alpha1.MigrationDraining
+			migration.PendingPods = int32(len(pods))
+			var blocked []string
+			for i := range pods {
+				pod := &pods[i]
+				if pod.DeletionTimestamp != nil {
+					continue
+				}
+				if err := r.evictPod(ctx, pod); err != nil && !apierrors.IsNotFound(err) {
+					klog.Errorf("fail to evict pod %s/%s from node %s: %v",
+						pod.Namespace, pod.Name, node.GetName(), err)
+					blocked = append(blocked, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+				}
+			}
+			if len(blocked) != 0 {
+				migration.Message = fmt.Sprintf("fail to evict pods %v, they may be protected by PodDisruptionBudgets", blocked)
+			}
+			return migration, nil
+		}
+	}
+
+	// 3. switch the node to the new pool, the node is kept cordoned until
+	// the next phase
+	migration.Phase = appsv1alpha1.MigrationSwitching
+	if err := removePoolRelatedAttrs(node); err != nil {
+		return nil, err
+	}
+	if _, err := conciliatePoolRelatedAttrs(node, nodePoolRelatedAttrs(
+		nodepoolutil.InheritAttributes(nodePool, pools), r.topologyKey)); err != nil {
+		return nil, err
+	}
+	conciliateNodeTypeLabel(node, nodePool)
+	cordonNode(node, nodePool.GetName())
+	if node.Labels == nil {
+		node.Labels = make(map[string]string)
+	}
+	node.Labels[appsv1alpha1.LabelDesiredNodePool] = nodePool.GetName()
+	node.Labels[appsv1alpha1.LabelCurrentNodePool] = nodePool.GetName()
+	if err := r.updateNode(ctx, nodePool.GetName(), node); err != nil {
+		return nil, err
+	}
+	return migration, nil
+}
+
+// abortMigrations stops the migrations to the pool which is being deleted,
+// the nodes are uncordoned and stay in their current pool
+func (r *NodePoolReconciler) abortMigrations(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool) error {
+	nodes, err := r.listNodesByIndex(ctx,
+		fieldindex.IndexNameForNodeMigrateToNodePool, nodePool.GetName())
+	if err != nil {
+		return err
+	}
+	for i := range nodes {
+		node := &nodes[i]
+		if node.Annotations[appsv1alpha1.AnnotationCordonedByNodePool] == nodePool.GetName() {
+			uncordonNode(node)
+		}
+		delete(node.Annotations, appsv1alpha1.AnnotationMigrateToNodePool)
+		if err := r.updateNode(ctx, nodePool.GetName(), node); err != nil {
+			return err
+		}
+		r.recorder.Eventf(node, corev1.EventTypeWarning, "MigrationAborted",
+			"nodepool %s is being deleted", nodePool.GetName())
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestIsPoolPinnedPod(t *testing.T) {
+	affinityPod := func(pools ...string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				// the pool-name label is the name of the UnitedDeployment pool
+				Labels: map[string]string{appsv1alpha1.PoolNameLabelKey: "ud-pool"},
+			},
+			Spec: corev1.PodSpec{
+				Affinity: &corev1.Affinity{
+					NodeAffinity: &corev1.NodeAffinity{
+						RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+							NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+								MatchExpressions: []corev1.NodeSelectorRequirement{{
+									Key:      appsv1alpha1.LabelCurrentNodePool,
+									Operator: corev1.NodeSelectorOpIn,
+									Values:   pools,
+								}},
+							}},
+						},
+					},
+				},
+			},
+			Status: corev1.PodStatus{Phase: corev1.PodRunning},
+		}
+	}
+	tests := []struct {
+		name   string
+		pod    *corev1.Pod
+		expect bool
+	}{
+		{
+			"pod pinned by the nodeSelector",
+			&corev1.Pod{
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{appsv1alpha1.LabelCurrentNodePool: "foo"},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			},
+			true,
+		},
+		{
+			"pod of a UnitedDeployment pool named differently from the nodepool",
+			affinityPod("foo"),
+			true,
+		},
+		{
+			"pod pinned to another pool",
+			affinityPod("bar"),
+			false,
+		},
+		{
+			"pod allowed in several pools",
+			affinityPod("foo", "bar"),
+			false,
+		},
+		{
+			"pod labeled with the pool name but not pinned",
+			&corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: map[string]string{appsv1alpha1.PoolNameLabelKey: "foo"},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodRunning},
+			},
+			false,
+		},
+		{
+			"terminated pod of the pool",
+			&corev1.Pod{
+				Spec: corev1.PodSpec{
+					NodeSelector: map[string]string{appsv1alpha1.LabelCurrentNodePool: "foo"},
+				},
+				Status: corev1.PodStatus{Phase: corev1.PodFailed},
+			},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			{
+				get := isPoolPinnedPod(st.pod, "foo")
+				if get != st.expect {
+					t.Fatalf("\t%s\texpect %v, but get %v", failed, st.expect, get)
+				}
+				t.Logf("\t%s\texpect %v, get %v", succeed, st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestIsNodeMigrating(t *testing.T) {
+	if isNodeMigrating(&corev1.Node{}) {
+		t.Errorf("node without the %s annotation should not be migrating",
+			appsv1alpha1.AnnotationMigrateToNodePool)
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{appsv1alpha1.AnnotationMigrateToNodePool: "foo"},
+		},
+	}
+	if !isNodeMigrating(node) {
+		t.Errorf("node with the %s annotation should be migrating",
+			appsv1alpha1.AnnotationMigrateToNodePool)
+	}
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"time"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+// quotaSyncInterval is the interval to refresh the quota usage, as the
+// controller doesn't watch pods. The quota is advisory, it is checked by the
+// pod webhook against the cached pods, and the usage in the status is
+// informational only
+const quotaSyncInterval = 30 * time.Second
+
+// conciliateQuota returns the resources used by the pods on the member nodes,
+// or nil if the pool has no quota
+func (r *NodePoolReconciler) conciliateQuota(ctx context.Context,
+	nodePool *appsv1alpha1.NodePool) (*appsv1alpha1.NodePoolQuotaStatus, error) {
+	if nodePool.Spec.Quota == nil {
+		return nil, nil
+	}
+	pods, err := nodepoolutil.ListPoolPods(ctx, r.Client, nodePool.GetName())
+	if err != nil {
+		return nil, err
+	}
+	return nodepoolutil.CalculateQuotaUsage(nodePool.Spec.Quota, pods), nil
+}
+
+// quotaStatusEqual checks if two quota status report the same usage, the
+// quantities are compared by value
+func quotaStatusEqual(s1, s2 *appsv1alpha1.NodePoolQuotaStatus) bool {
+	if s1 == nil || s2 == nil {
+		return s1 == s2
+	}
+	if !resourceListEqual(s1.Used, s2.Used) || len(s1.Namespaces) != len(s2.Namespaces) {
+		return false
+	}
+	for i := range s1.Namespaces {
+		if s1.Namespaces[i].Namespace != s2.Namespaces[i].Namespace ||
+			!resourceListEqual(s1.Namespaces[i].Used, s2.Namespaces[i].Used) {
+			return false
+		}
+	}
+	return true
+}
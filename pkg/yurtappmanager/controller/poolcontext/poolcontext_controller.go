@@ -0,0 +1,221 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolcontext
+
+import (
+	"context"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/gate"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+const controllerName = "poolcontext-controller"
+
+var concurrentReconciles = 3
+
+// PoolContextReconciler syncs the pool context to the pods that ask for it
+// once they are bound to nodes, as the pool of most pods is unknown when
+// they are created
+type PoolContextReconciler struct {
+	client.Client
+}
+
+// Add creates a new PoolContext Controller and adds it to the Manager.
+// The Manager will set fields on the Controller and Start it when the
+// Manager is Started.
+func Add(mgr manager.Manager, _ context.Context) error {
+	if !gate.ResourceEnabled(&appsv1alpha1.NodePool{}) {
+		return nil
+	}
+	return add(mgr, &PoolContextReconciler{Client: mgr.GetClient()})
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *PoolContextReconciler) error {
+	c, err := controller.New(controllerName,
+		mgr, controller.Options{
+			Reconciler:              r,
+			MaxConcurrentReconciles: concurrentReconciles})
+	if err != nil {
+		return err
+	}
+
+	// Watch for the bound pods that ask for the pool context
+	err = c.Watch(&source.Kind{Type: &corev1.Pod{}},
+		&handler.EnqueueRequestForObject{}, podPredicate)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to NodePool, so that the pool annotations copied to
+	// the pods are kept up to date
+	err = c.Watch(&source.Kind{Type: &appsv1alpha1.NodePool{}},
+		handler.EnqueueRequestsFromMapFunc(r.podsOfNodePool), nodePoolPredicate)
+	if err != nil {
+		return err
+	}
+
+	// Watch for the nodes moving to another pool, e.g. after a migration, so
+	// that the pool labels of their pods are updated
+	return c.Watch(&source.Kind{Type: &corev1.Node{}},
+		handler.EnqueueRequestsFromMapFunc(r.podsOfNode), nodePredicate)
+}
+
+// nodePoolPredicate filters out the NodePool updates that don't change the
+// pool context, i.e. the labels, the annotations and the type of the pool
+var nodePoolPredicate = predicate.Funcs{
+	UpdateFunc: func(evt event.UpdateEvent) bool {
+		oldNp, ok := evt.ObjectOld.(*appsv1alpha1.NodePool)
+		if !ok {
+			return true
+		}
+		newNp, ok := evt.ObjectNew.(*appsv1alpha1.NodePool)
+		if !ok {
+			return true
+		}
+		return !reflect.DeepEqual(oldNp.Labels, newNp.Labels) ||
+			!reflect.DeepEqual(oldNp.Annotations, newNp.Annotations) ||
+			oldNp.Spec.Type != newNp.Spec.Type
+	},
+}
+
+// nodePredicate only passes the node updates that change the nodepool label,
+// the pods of a new node are handled once they are bound
+var nodePredicate = predicate.Funcs{
+	CreateFunc: func(evt event.CreateEvent) bool {
+		return false
+	},
+	UpdateFunc: func(evt event.UpdateEvent) bool {
+		return evt.ObjectOld.GetLabels()[appsv1alpha1.LabelCurrentNodePool] !=
+			evt.ObjectNew.GetLabels()[appsv1alpha1.LabelCurrentNodePool]
+	},
+	DeleteFunc: func(evt event.DeleteEvent) bool {
+		return false
+	},
+	GenericFunc: func(evt event.GenericEvent) bool {
+		return false
+	},
+}
+
+// podPredicate filters out the pods that don't ask for the pool context or
+// are not bound yet
+var podPredicate = predicate.NewPredicateFuncs(func(obj client.Object) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	return pod.Spec.NodeName != "" && nodepoolutil.PoolContextMode(pod) != ""
+})
+
+// podsOfNodePool returns the requests of the pods on the member nodes of the
+// pool that ask for the pool context
+func (r *PoolContextReconciler) podsOfNodePool(obj client.Object) []reconcile.Request {
+	var nodeList corev1.NodeList
+	if err := r.List(context.TODO(), &nodeList, client.MatchingFields{
+		fieldindex.IndexNameForNodeCurrentNodePool: obj.GetName(),
+	}); err != nil {
+		klog.Errorf("fail to list nodes of nodepool %s: %v", obj.GetName(), err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range nodeList.Items {
+		requests = append(requests, r.podsOfNode(&nodeList.Items[i])...)
+	}
+	return requests
+}
+
+// podsOfNode returns the requests of the pods on the node that ask for the
+// pool context
+func (r *PoolContextReconciler) podsOfNode(obj client.Object) []reconcile.Request {
+	var podList corev1.PodList
+	if err := r.List(context.TODO(), &podList, client.MatchingFields{
+		fieldindex.IndexNameForPodNodeName: obj.GetName(),
+	}); err != nil {
+		klog.Errorf("fail to list pods on node %s: %v", obj.GetName(), err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range podList.Items {
+		if nodepoolutil.PoolContextMode(&podList.Items[i]) == "" {
+			continue
+		}
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: podList.Items[i].Namespace,
+			Name:      podList.Items[i].Name,
+		}})
+	}
+	return requests
+}
+
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;update;patch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get;list;watch
+
+// Reconcile sets the labels and annotations of the pool context on the pod
+// according to the pool of its node
+func (r *PoolContextReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var pod corev1.Pod
+	if err := r.Get(ctx, req.NamespacedName, &pod); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if pod.DeletionTimestamp != nil || pod.Spec.NodeName == "" ||
+		nodepoolutil.PoolContextMode(&pod) == "" {
+		return ctrl.Result{}, nil
+	}
+
+	var node corev1.Node
+	if err := r.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	poolName := node.Labels[appsv1alpha1.LabelCurrentNodePool]
+	if poolName == "" {
+		return ctrl.Result{}, nil
+	}
+	var np appsv1alpha1.NodePool
+	if err := r.Get(ctx, types.NamespacedName{Name: poolName}, &np); err != nil {
+		if apierrors.IsNotFound(err) {
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	newPod := pod.DeepCopy()
+	if !nodepoolutil.ApplyPoolContext(newPod, &np) {
+		return ctrl.Result{}, nil
+	}
+	klog.V(4).Infof("sync the context of nodepool %s to pod %s/%s", poolName, pod.Namespace, pod.Name)
+	return ctrl.Result{}, r.Patch(ctx, newPod, client.MergeFrom(&pod))
+}
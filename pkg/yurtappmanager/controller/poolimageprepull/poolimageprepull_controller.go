@@ -0,0 +1,300 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolimageprepull
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/klog"
+	nodeutil "k8s.io/kubernetes/pkg/controller/util/node"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/gate"
+)
+
+const controllerName = "poolimageprepull-controller"
+
+var concurrentReconciles = 3
+
+// PoolImagePrePullReconciler pulls the images on the member nodes of the
+// target pools through short-lived pull pods
+type PoolImagePrePullReconciler struct {
+	client.Client
+	recorder record.EventRecorder
+}
+
+// Add creates a new PoolImagePrePull Controller and adds it to the Manager.
+// The Manager will set fields on the Controller and Start it when the
+// Manager is Started.
+func Add(mgr manager.Manager, _ context.Context) error {
+	if !gate.ResourceEnabled(&appsv1alpha1.PoolImagePrePull{}) {
+		return nil
+	}
+	return add(mgr, &PoolImagePrePullReconciler{
+		Client:   mgr.GetClient(),
+		recorder: mgr.GetEventRecorderFor(controllerName),
+	})
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func add(mgr manager.Manager, r *PoolImagePrePullReconciler) error {
+	c, err := controller.New(controllerName,
+		mgr, controller.Options{
+			Reconciler:              r,
+			MaxConcurrentReconciles: concurrentReconciles})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to PoolImagePrePull
+	err = c.Watch(&source.Kind{Type: &appsv1alpha1.PoolImagePrePull{}},
+		&handler.EnqueueRequestForObject{})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to the pull pods
+	err = c.Watch(&source.Kind{Type: &corev1.Pod{}}, &handler.EnqueueRequestForOwner{
+		OwnerType:    &appsv1alpha1.PoolImagePrePull{},
+		IsController: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	// Watch for the nodes that join or leave the pools, or become ready
+	err = c.Watch(&source.Kind{Type: &corev1.Node{}},
+		handler.EnqueueRequestsFromMapFunc(r.allPoolImagePrePulls), nodePredicate)
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to NodePool, as the pools are selected by labels
+	return c.Watch(&source.Kind{Type: &appsv1alpha1.NodePool{}},
+		handler.EnqueueRequestsFromMapFunc(r.allPoolImagePrePulls))
+}
+
+// nodePredicate filters out the node updates that change neither the pool
+// nor the readiness of the node, e.g. the heartbeats
+var nodePredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldNode, ok := e.ObjectOld.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		newNode, ok := e.ObjectNew.(*corev1.Node)
+		if !ok {
+			return false
+		}
+		return oldNode.Labels[appsv1alpha1.LabelCurrentNodePool] !=
+			newNode.Labels[appsv1alpha1.LabelCurrentNodePool] ||
+			isNodeReady(oldNode) != isNodeReady(newNode)
+	},
+}
+
+// allPoolImagePrePulls returns the requests of all PoolImagePrePulls, as any
+// of them may target the pool of the object
+func (r *PoolImagePrePullReconciler) allPoolImagePrePulls(obj client.Object) []reconcile.Request {
+	var pipList appsv1alpha1.PoolImagePrePullList
+	if err := r.List(context.TODO(), &pipList); err != nil {
+		klog.Errorf("fail to list poolimageprepulls: %v", err)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(pipList.Items))
+	for _, pip := range pipList.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{
+			Namespace: pip.GetNamespace(),
+			Name:      pip.GetName(),
+		}})
+	}
+	return requests
+}
+
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=poolimageprepulls,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=poolimageprepulls/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=nodes,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch;create;delete
+
+// Reconcile creates a pull pod on each ready member node of the target pools
+// that hasn't pulled the images, records the result of the pull pods in the
+// status and deletes the finished ones
+func (r *PoolImagePrePullReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	klog.V(4).Infof("Reconcile PoolImagePrePull %s", req.NamespacedName)
+	var pip appsv1alpha1.PoolImagePrePull
+	if err := r.Get(ctx, req.NamespacedName, &pip); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	// the pull pods are garbage collected with the PoolImagePrePull
+	if pip.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+
+	members, err := r.memberNodes(ctx, &pip)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var podList corev1.PodList
+	if err := r.List(ctx, &podList, client.InNamespace(pip.GetNamespace()),
+		client.MatchingLabels{appsv1alpha1.LabelPoolImagePrePull: pip.GetName()}); err != nil {
+		return ctrl.Result{}, err
+	}
+	pods := make(map[string]*corev1.Pod)
+	var stalePods []*corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if !metav1.IsControlledBy(pod, &pip) {
+			continue
+		}
+		if _, exist := members[pod.Spec.NodeName]; !exist || isPullPodOutdated(&pip, pod) {
+			stalePods = append(stalePods, pod)
+			continue
+		}
+		pods[pod.Spec.NodeName] = pod
+	}
+
+	// the pulling is restarted on all nodes once the spec is changed
+	finished := make(map[string]appsv1alpha1.PoolImagePrePullNodeStatus)
+	if pip.Status.ObservedGeneration == pip.GetGeneration() {
+		for _, ns := range pip.Status.Nodes {
+			if isPrePullFinished(ns.Phase) {
+				finished[ns.NodeName] = ns
+			}
+		}
+	}
+
+	now := metav1.Now()
+	var finishedPods []*corev1.Pod
+	nodes := make([]appsv1alpha1.PoolImagePrePullNodeStatus, 0, len(members))
+	for name, node := range members {
+		poolName := node.Labels[appsv1alpha1.LabelCurrentNodePool]
+		pod := pods[name]
+		if ns, exist := finished[name]; exist {
+			ns.NodePool = poolName
+			nodes = append(nodes, ns)
+			if pod != nil {
+				finishedPods = append(finishedPods, pod)
+			}
+			continue
+		}
+
+		ns := appsv1alpha1.PoolImagePrePullNodeStatus{
+			NodeName: name,
+			NodePool: poolName,
+			Phase:    appsv1alpha1.PrePullRunning,
+		}
+		switch {
+		case pod != nil:
+			ns.Phase, ns.Message = pullPodPhase(pod)
+			if isPrePullFinished(ns.Phase) {
+				ns.CompletionTime = &now
+				finishedPods = append(finishedPods, pod)
+				r.recordPrePullFinished(&pip, &ns)
+			}
+		case !isNodeReady(node):
+			ns.Phase = appsv1alpha1.PrePullPending
+		default:
+			if err := r.Create(ctx, newPullPod(&pip, name)); err != nil && !apierrors.IsAlreadyExists(err) {
+				return ctrl.Result{}, err
+			}
+		}
+		nodes = append(nodes, ns)
+	}
+
+	// the finished pods are deleted only after their result is recorded,
+	// otherwise they would be created again
+	status := calculateStatus(&pip, nodes, now)
+	if !apiequality.Semantic.DeepEqual(pip.Status, status) {
+		pip.Status = status
+		if err := r.Status().Update(ctx, &pip); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	for _, pod := range append(stalePods, finishedPods...) {
+		if err := r.Delete(ctx, pod); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{}, nil
+}
+
+// memberNodes returns the member nodes of the pools targeted by the
+// PoolImagePrePull, indexed by the node name
+func (r *PoolImagePrePullReconciler) memberNodes(ctx context.Context,
+	pip *appsv1alpha1.PoolImagePrePull) (map[string]*corev1.Node, error) {
+	var poolList appsv1alpha1.NodePoolList
+	if err := r.List(ctx, &poolList); err != nil {
+		return nil, err
+	}
+	pools, err := targetNodePools(pip, poolList.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]*corev1.Node)
+	for _, pool := range pools {
+		var nodeList corev1.NodeList
+		if err := r.List(ctx, &nodeList, client.MatchingFields{
+			fieldindex.IndexNameForNodeCurrentNodePool: pool,
+		}); err != nil {
+			return nil, err
+		}
+		for i := range nodeList.Items {
+			members[nodeList.Items[i].GetName()] = &nodeList.Items[i]
+		}
+	}
+	return members, nil
+}
+
+// recordPrePullFinished records an event once the pulling on the node is
+// finished
+func (r *PoolImagePrePullReconciler) recordPrePullFinished(pip *appsv1alpha1.PoolImagePrePull,
+	ns *appsv1alpha1.PoolImagePrePullNodeStatus) {
+	if ns.Phase == appsv1alpha1.PrePullSucceeded {
+		r.recorder.Event(pip, corev1.EventTypeNormal, "PrePullSucceeded",
+			fmt.Sprintf("images are pulled on node %s", ns.NodeName))
+		return
+	}
+	r.recorder.Event(pip, corev1.EventTypeWarning, "PrePullFailed",
+		fmt.Sprintf("fail to pull images on node %s: %s", ns.NodeName, ns.Message))
+}
+
+// isNodeReady checks if the `node` is `corev1.NodeReady`
+func isNodeReady(node *corev1.Node) bool {
+	_, nc := nodeutil.GetNodeCondition(&node.Status, corev1.NodeReady)
+	// GetNodeCondition will return nil and -1 if the condition is not present
+	return nc != nil && nc.Status == corev1.ConditionTrue
+}
@@ -0,0 +1,222 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolimageprepull
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+// pullCommand is run by the pull containers, it only matters that the image
+// is pulled, so the containers are not required to run it successfully
+var pullCommand = []string{"/bin/sh", "-c", "exit 0"}
+
+// pulledWaitingReasons are the reasons of the waiting containers whose
+// images are already pulled, as the kubelet pulls the image before creating
+// the container
+var pulledWaitingReasons = sets.NewString("CreateContainerConfigError", "CreateContainerError")
+
+// failedWaitingReasons are the reasons of the waiting containers whose
+// images can never be pulled, other pulling errors are retried by the
+// kubelet until the pull pod exceeds its deadline
+var failedWaitingReasons = sets.NewString("InvalidImageName", "ErrImageNeverPull")
+
+// targetNodePools returns the sorted names of the pools targeted by the
+// PoolImagePrePull, including the descendants of the targeted pools
+func targetNodePools(pip *appsv1alpha1.PoolImagePrePull,
+	pools []appsv1alpha1.NodePool) ([]string, error) {
+	names := sets.NewString(pip.Spec.NodePools...)
+	if pip.Spec.NodePoolSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(pip.Spec.NodePoolSelector)
+		if err != nil {
+			return nil, err
+		}
+		for _, np := range pools {
+			if selector.Matches(labels.Set(np.GetLabels())) {
+				names.Insert(np.GetName())
+			}
+		}
+	}
+	return nodepoolutil.ExpandNodePools(names.List(), pools), nil
+}
+
+// pullPodName returns the name of the pull pod on the node, the node name is
+// hashed as it may be too long to be a part of the pod name
+func pullPodName(pipName, nodeName string) string {
+	hasher := fnv.New32a()
+	hasher.Write([]byte(nodeName))
+	return fmt.Sprintf("%s-%s", pipName, rand.SafeEncodeString(fmt.Sprint(hasher.Sum32())))
+}
+
+// newPullPod returns the pod that pulls the images on the node, each image
+// is pulled by a container that does nothing. The pod is bound to the node
+// directly and tolerates all taints, so that it is not blocked by the
+// scheduler
+func newPullPod(pip *appsv1alpha1.PoolImagePrePull, nodeName string) *corev1.Pod {
+	deadline := appsv1alpha1.DefaultPrePullActiveDeadlineSeconds
+	if pip.Spec.ActiveDeadlineSeconds != nil {
+		deadline = *pip.Spec.ActiveDeadlineSeconds
+	}
+	requests := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("10m"),
+		corev1.ResourceMemory: resource.MustParse("16Mi"),
+	}
+
+	containers := make([]corev1.Container, 0, len(pip.Spec.Images))
+	for i, image := range pip.Spec.Images {
+		containers = append(containers, corev1.Container{
+			Name:            fmt.Sprintf("pull-%d", i),
+			Image:           image,
+			Command:         pullCommand,
+			ImagePullPolicy: corev1.PullIfNotPresent,
+			Resources: corev1.ResourceRequirements{
+				Requests: requests,
+				Limits:   requests,
+			},
+		})
+	}
+
+	automount := false
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: pip.GetNamespace(),
+			Name:      pullPodName(pip.GetName(), nodeName),
+			Labels: map[string]string{
+				appsv1alpha1.LabelPoolImagePrePull: pip.GetName(),
+			},
+			Annotations: map[string]string{
+				appsv1alpha1.AnnotationPrePullGeneration: strconv.FormatInt(pip.GetGeneration(), 10),
+			},
+			OwnerReferences: []metav1.OwnerReference{
+				*metav1.NewControllerRef(pip, appsv1alpha1.GroupVersion.WithKind("PoolImagePrePull")),
+			},
+		},
+		Spec: corev1.PodSpec{
+			NodeName:                     nodeName,
+			Containers:                   containers,
+			ImagePullSecrets:             pip.Spec.ImagePullSecrets,
+			RestartPolicy:                corev1.RestartPolicyNever,
+			ActiveDeadlineSeconds:        &deadline,
+			AutomountServiceAccountToken: &automount,
+			Tolerations: []corev1.Toleration{
+				{Operator: corev1.TolerationOpExists},
+			},
+		},
+	}
+}
+
+// isPullPodOutdated checks if the pull pod is created for an older spec of
+// the PoolImagePrePull
+func isPullPodOutdated(pip *appsv1alpha1.PoolImagePrePull, pod *corev1.Pod) bool {
+	return pod.Annotations[appsv1alpha1.AnnotationPrePullGeneration] !=
+		strconv.FormatInt(pip.GetGeneration(), 10)
+}
+
+// pullPodPhase returns the phase of pulling the images by the pod, and the
+// reason if the pulling failed. An image is pulled once its container is
+// created, no matter whether the container runs successfully
+func pullPodPhase(pod *corev1.Pod) (appsv1alpha1.PrePullPhase, string) {
+	statuses := make(map[string]corev1.ContainerStatus)
+	for _, cs := range pod.Status.ContainerStatuses {
+		statuses[cs.Name] = cs
+	}
+
+	var pending []string
+	for _, c := range pod.Spec.Containers {
+		cs, exist := statuses[c.Name]
+		if !exist {
+			pending = append(pending, c.Image)
+			continue
+		}
+		switch {
+		case cs.State.Running != nil, cs.State.Terminated != nil:
+		case cs.State.Waiting != nil && pulledWaitingReasons.Has(cs.State.Waiting.Reason):
+		case cs.State.Waiting != nil && failedWaitingReasons.Has(cs.State.Waiting.Reason):
+			return appsv1alpha1.PrePullFailed, fmt.Sprintf("fail to pull image %s: %s",
+				c.Image, cs.State.Waiting.Reason)
+		default:
+			if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+				pending = append(pending, fmt.Sprintf("%s (%s)", c.Image, cs.State.Waiting.Reason))
+			} else {
+				pending = append(pending, c.Image)
+			}
+		}
+	}
+
+	if len(pending) == 0 {
+		return appsv1alpha1.PrePullSucceeded, ""
+	}
+	if pod.Status.Phase == corev1.PodFailed {
+		msg := fmt.Sprintf("images not pulled: %s", strings.Join(pending, ", "))
+		if pod.Status.Reason != "" {
+			msg = fmt.Sprintf("%s, %s", pod.Status.Reason, msg)
+		}
+		return appsv1alpha1.PrePullFailed, msg
+	}
+	return appsv1alpha1.PrePullRunning, ""
+}
+
+// isPrePullFinished checks if the pulling on the node is finished
+func isPrePullFinished(phase appsv1alpha1.PrePullPhase) bool {
+	return phase == appsv1alpha1.PrePullSucceeded || phase == appsv1alpha1.PrePullFailed
+}
+
+// calculateStatus counts the nodes in each phase, the node status are sorted
+// by the node name. The completion time is kept if the pulling is still
+// finished on all nodes, and set to now if it just finished
+func calculateStatus(pip *appsv1alpha1.PoolImagePrePull,
+	nodes []appsv1alpha1.PoolImagePrePullNodeStatus, now metav1.Time) appsv1alpha1.PoolImagePrePullStatus {
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].NodeName < nodes[j].NodeName })
+	status := appsv1alpha1.PoolImagePrePullStatus{
+		ObservedGeneration: pip.GetGeneration(),
+		DesiredNodes:       int32(len(nodes)),
+		Nodes:              nodes,
+	}
+
+	finished := len(nodes) != 0
+	for _, ns := range nodes {
+		switch ns.Phase {
+		case appsv1alpha1.PrePullSucceeded:
+			status.SucceededNodes++
+		case appsv1alpha1.PrePullFailed:
+			status.FailedNodes++
+		default:
+			finished = false
+		}
+	}
+
+	if finished {
+		status.CompletionTime = &now
+		if pip.Status.ObservedGeneration == pip.GetGeneration() && pip.Status.CompletionTime != nil {
+			status.CompletionTime = pip.Status.CompletionTime
+		}
+	}
+	return status
+}
@@ -0,0 +1,215 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package poolimageprepull
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newPrePullNodePool(name, parent string, labels map[string]string) appsv1alpha1.NodePool {
+	return appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: labels},
+		Spec:       appsv1alpha1.NodePoolSpec{Parent: parent},
+	}
+}
+
+func TestTargetNodePools(t *testing.T) {
+	pools := []appsv1alpha1.NodePool{
+		newPrePullNodePool("east", "", map[string]string{"region": "east"}),
+		newPrePullNodePool("hangzhou", "east", nil),
+		newPrePullNodePool("west", "", map[string]string{"region": "west"}),
+		newPrePullNodePool("chengdu", "west", nil),
+	}
+	tests := []struct {
+		name   string
+		spec   appsv1alpha1.PoolImagePrePullSpec
+		expect []string
+	}{
+		{
+			"pools by name",
+			appsv1alpha1.PoolImagePrePullSpec{NodePools: []string{"chengdu", "hangzhou"}},
+			[]string{"chengdu", "hangzhou"},
+		},
+		{
+			"descendants are targeted",
+			appsv1alpha1.PoolImagePrePullSpec{NodePools: []string{"west"}},
+			[]string{"chengdu", "west"},
+		},
+		{
+			"pools by selector",
+			appsv1alpha1.PoolImagePrePullSpec{
+				NodePools: []string{"chengdu"},
+				NodePoolSelector: &metav1.LabelSelector{
+					MatchLabels: map[string]string{"region": "east"},
+				},
+			},
+			[]string{"chengdu", "east", "hangzhou"},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			pip := &appsv1alpha1.PoolImagePrePull{Spec: st.spec}
+			get, err := targetNodePools(pip, pools)
+			if err != nil {
+				t.Fatalf("fail to get the target pools: %v", err)
+			}
+			if !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect target pools %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestNewPullPod(t *testing.T) {
+	pip := &appsv1alpha1.PoolImagePrePull{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "warm-up", Generation: 2},
+		Spec: appsv1alpha1.PoolImagePrePullSpec{
+			Images:           []string{"nginx:1.19", "redis:6"},
+			ImagePullSecrets: []corev1.LocalObjectReference{{Name: "regcred"}},
+		},
+	}
+	pod := newPullPod(pip, "node-a")
+	if pod.Name != pullPodName("warm-up", "node-a") || pod.Name == pullPodName("warm-up", "node-b") {
+		t.Errorf("expect the pod name to be unique for the node, but get %s", pod.Name)
+	}
+	if pod.Spec.NodeName != "node-a" || len(pod.Spec.Containers) != 2 ||
+		pod.Spec.Containers[1].Image != "redis:6" {
+		t.Errorf("expect the pod to pull the images on node-a, but get %v", pod.Spec)
+	}
+	if *pod.Spec.ActiveDeadlineSeconds != appsv1alpha1.DefaultPrePullActiveDeadlineSeconds {
+		t.Errorf("expect the default deadline, but get %d", *pod.Spec.ActiveDeadlineSeconds)
+	}
+	if !metav1.IsControlledBy(pod, pip) || isPullPodOutdated(pip, pod) {
+		t.Errorf("expect the pod to be controlled by the current poolimageprepull")
+	}
+	pip.Generation = 3
+	if !isPullPodOutdated(pip, pod) {
+		t.Errorf("expect the pod to be outdated once the spec is changed")
+	}
+}
+
+func newPullContainerStatus(name string, state corev1.ContainerState) corev1.ContainerStatus {
+	return corev1.ContainerStatus{Name: name, State: state}
+}
+
+func waiting(reason string) corev1.ContainerState {
+	return corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}
+}
+
+func TestPullPodPhase(t *testing.T) {
+	terminated := corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 127}}
+	running := corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	tests := []struct {
+		name     string
+		phase    corev1.PodPhase
+		statuses []corev1.ContainerStatus
+		expect   appsv1alpha1.PrePullPhase
+	}{
+		{"pod not started", corev1.PodPending, nil, appsv1alpha1.PrePullRunning},
+		{
+			"images being pulled",
+			corev1.PodPending,
+			[]corev1.ContainerStatus{
+				newPullContainerStatus("pull-0", running),
+				newPullContainerStatus("pull-1", waiting("ImagePullBackOff")),
+			},
+			appsv1alpha1.PrePullRunning,
+		},
+		{
+			"containers created no matter how they exit",
+			corev1.PodFailed,
+			[]corev1.ContainerStatus{
+				newPullContainerStatus("pull-0", terminated),
+				newPullContainerStatus("pull-1", waiting("CreateContainerError")),
+			},
+			appsv1alpha1.PrePullSucceeded,
+		},
+		{
+			"invalid image",
+			corev1.PodPending,
+			[]corev1.ContainerStatus{
+				newPullContainerStatus("pull-0", running),
+				newPullContainerStatus("pull-1", waiting("InvalidImageName")),
+			},
+			appsv1alpha1.PrePullFailed,
+		},
+		{
+			"deadline exceeded",
+			corev1.PodFailed,
+			[]corev1.ContainerStatus{
+				newPullContainerStatus("pull-0", running),
+				newPullContainerStatus("pull-1", waiting("ErrImagePull")),
+			},
+			appsv1alpha1.PrePullFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			pod := &corev1.Pod{
+				Spec: corev1.PodSpec{Containers: []corev1.Container{
+					{Name: "pull-0", Image: "nginx"},
+					{Name: "pull-1", Image: "redis"},
+				}},
+				Status: corev1.PodStatus{Phase: st.phase, ContainerStatuses: st.statuses},
+			}
+			if get, msg := pullPodPhase(pod); get != st.expect {
+				t.Errorf("expect phase %s, but get %s: %s", st.expect, get, msg)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestCalculateStatus(t *testing.T) {
+	now := metav1.Now()
+	pip := &appsv1alpha1.PoolImagePrePull{ObjectMeta: metav1.ObjectMeta{Generation: 1}}
+	status := calculateStatus(pip, []appsv1alpha1.PoolImagePrePullNodeStatus{
+		{NodeName: "node-b", Phase: appsv1alpha1.PrePullFailed},
+		{NodeName: "node-a", Phase: appsv1alpha1.PrePullSucceeded},
+		{NodeName: "node-c", Phase: appsv1alpha1.PrePullPending},
+	}, now)
+	if status.DesiredNodes != 3 || status.SucceededNodes != 1 || status.FailedNodes != 1 {
+		t.Errorf("unexpected counts of nodes: %+v", status)
+	}
+	if status.Nodes[0].NodeName != "node-a" || status.CompletionTime != nil {
+		t.Errorf("expect sorted nodes and no completion time, but get %+v", status)
+	}
+
+	pip.Status = calculateStatus(pip, status.Nodes[:2], now)
+	if pip.Status.CompletionTime == nil {
+		t.Fatalf("expect the completion time once all nodes are finished")
+	}
+	later := metav1.NewTime(now.Add(60))
+	if status = calculateStatus(pip, pip.Status.Nodes, later); !status.CompletionTime.Equal(&now) {
+		t.Errorf("expect the completion time to be kept, but get %v", status.CompletionTime)
+	}
+}
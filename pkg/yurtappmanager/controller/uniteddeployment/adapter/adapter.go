@@ -50,4 +50,10 @@ type Adapter interface {
 type ReplicasInfo struct {
 	Replicas      int32
 	ReadyReplicas int32
+	// UpdatedReadyReplicas is the number of ready replicas of the latest
+	// template of the pool workload
+	UpdatedReadyReplicas int32
+	// UnschedulableReplicas is the number of pods of the pool workload that
+	// the scheduler can't find a node for
+	UnschedulableReplicas int32
 }
@@ -18,6 +18,7 @@ limitations under the License.
 package adapter
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 
@@ -28,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 func getPoolPrefix(controllerName, poolName string) string {
@@ -96,6 +98,54 @@ func getRevision(objMeta metav1.Object) string {
 	return objMeta.GetLabels()[appsv1alpha1.ControllerRevisionHashLabelKey]
 }
 
+// getUpdatedReadyReplicas estimates the ready replicas of the latest template
+// of the pool workload from its status, the replicas of the old template are
+// assumed to be ready first. It returns 0 until the workload controller has
+// observed the latest generation
+func getUpdatedReadyReplicas(generation, observedGeneration int64, statusReplicas, readyReplicas, updatedReplicas int32) int32 {
+	if observedGeneration < generation {
+		return 0
+	}
+	ready := readyReplicas - (statusReplicas - updatedReplicas)
+	if ready < 0 {
+		return 0
+	}
+	if ready > updatedReplicas {
+		return updatedReplicas
+	}
+	return ready
+}
+
+// isPodUnschedulable checks if the scheduler can't find a node for the pod
+func isPodUnschedulable(pod *corev1.Pod) bool {
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled {
+			return cond.Status == corev1.ConditionFalse && cond.Reason == corev1.PodReasonUnschedulable
+		}
+	}
+	return false
+}
+
+// getUnschedulableReplicas returns the number of the unschedulable pods of the
+// pool workload, the pods are listed from the cache of the client
+func getUnschedulableReplicas(c client.Client, namespace string, labelSelector *metav1.LabelSelector) (int32, error) {
+	selector, err := metav1.LabelSelectorAsSelector(labelSelector)
+	if err != nil {
+		return 0, err
+	}
+	podList := &corev1.PodList{}
+	if err := c.List(context.TODO(), podList, &client.ListOptions{Namespace: namespace, LabelSelector: selector}); err != nil {
+		return 0, err
+	}
+	var unschedulable int32
+	for i := range podList.Items {
+		if podList.Items[i].DeletionTimestamp == nil && isPodUnschedulable(&podList.Items[i]) {
+			unschedulable++
+		}
+	}
+	return unschedulable, nil
+}
+
 // getCurrentPartition calculates current partition by counting the pods not having the updated revision
 func getCurrentPartition(pods []*corev1.Pod, revision string) *int32 {
 	var partition int32
@@ -68,6 +68,46 @@ func TestGetCurrentPartitionForStrategyOnDelete(t *testing.T) {
 	}
 }
 
+func TestIsPodUnschedulable(t *testing.T) {
+	tests := []struct {
+		name       string
+		conditions []corev1.PodCondition
+		expect     bool
+	}{
+		{
+			"unschedulable pod",
+			[]corev1.PodCondition{{Type: corev1.PodScheduled, Status: corev1.ConditionFalse, Reason: corev1.PodReasonUnschedulable}},
+			true,
+		},
+		{
+			"scheduled pod not ready",
+			[]corev1.PodCondition{
+				{Type: corev1.PodScheduled, Status: corev1.ConditionTrue},
+				{Type: corev1.PodReady, Status: corev1.ConditionFalse},
+			},
+			false,
+		},
+		{
+			"pod waiting for the scheduler",
+			nil,
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			pod := &corev1.Pod{Status: corev1.PodStatus{Conditions: st.conditions}}
+			if get := isPodUnschedulable(pod); get != st.expect {
+				t.Errorf("expect unschedulable %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
 func buildPodList(ordinals []int, revisions []string, t *testing.T) []*corev1.Pod {
 	if len(ordinals) != len(revisions) {
 		t.Fatalf("ordinals count should equals to revision count")
@@ -171,3 +211,35 @@ func TestCreateNewPatchedObject(t *testing.T) {
 	}
 
 }
+
+func TestGetUpdatedReadyReplicas(t *testing.T) {
+	tests := []struct {
+		name               string
+		generation         int64
+		observedGeneration int64
+		statusReplicas     int32
+		readyReplicas      int32
+		updatedReplicas    int32
+		expect             int32
+	}{
+		{"generation not observed", 2, 1, 3, 3, 3, 0},
+		{"all replicas updated and ready", 2, 2, 3, 3, 3, 3},
+		{"old replicas are ready first", 2, 2, 4, 3, 2, 1},
+		{"no updated replica ready", 2, 2, 4, 2, 2, 0},
+		{"surge replicas are not counted", 2, 2, 3, 3, 1, 1},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			get := getUpdatedReadyReplicas(st.generation, st.observedGeneration,
+				st.statusReplicas, st.readyReplicas, st.updatedReplicas)
+			if get != st.expect {
+				t.Errorf("expect %d updated ready replicas, but get %d", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
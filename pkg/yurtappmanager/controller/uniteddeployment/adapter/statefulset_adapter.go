@@ -73,7 +73,14 @@ func (a *StatefulSetAdapter) GetDetails(obj metav1.Object) (ReplicasInfo, error)
 	replicasInfo := ReplicasInfo{
 		Replicas:      specReplicas,
 		ReadyReplicas: set.Status.ReadyReplicas,
+		UpdatedReadyReplicas: getUpdatedReadyReplicas(set.Generation, set.Status.ObservedGeneration,
+			set.Status.Replicas, set.Status.ReadyReplicas, set.Status.UpdatedReplicas),
 	}
+	unschedulable, err := getUnschedulableReplicas(a.Client, set.Namespace, set.Spec.Selector)
+	if err != nil {
+		return replicasInfo, err
+	}
+	replicasInfo.UnschedulableReplicas = unschedulable
 
 	return replicasInfo, nil
 }
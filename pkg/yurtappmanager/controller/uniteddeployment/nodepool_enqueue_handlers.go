@@ -0,0 +1,138 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"context"
+
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+// EnqueueUnitedDeploymentForNodePool enqueues the UnitedDeployments whose pools
+// or fallback pools target a NodePool or one of its ancestors when the
+// NodePool hierarchy changes, as the pools targeting a parent NodePool are
+// expanded to its leaf NodePools. The UnitedDeployments distributing replicas
+// by capacity or failing over pools are also enqueued when the capacity or the
+// node readiness of a NodePool they target changes
+type EnqueueUnitedDeploymentForNodePool struct {
+	client client.Client
+}
+
+func (e *EnqueueUnitedDeploymentForNodePool) Create(event event.CreateEvent, limitingInterface workqueue.RateLimitingInterface) {
+	np, ok := event.Object.(*unitv1alpha1.NodePool)
+	if !ok {
+		return
+	}
+	e.addReferencingUnitedDeploymentToWorkQueue(limitingInterface, false, np)
+}
+
+func (e *EnqueueUnitedDeploymentForNodePool) Update(event event.UpdateEvent, limitingInterface workqueue.RateLimitingInterface) {
+	oldNp, ok := event.ObjectOld.(*unitv1alpha1.NodePool)
+	if !ok {
+		return
+	}
+	newNp, ok := event.ObjectNew.(*unitv1alpha1.NodePool)
+	if !ok {
+		return
+	}
+	if oldNp.Spec.Parent != newNp.Spec.Parent {
+		e.addReferencingUnitedDeploymentToWorkQueue(limitingInterface, false, oldNp, newNp)
+		return
+	}
+	if oldNp.Status.ReadyNodeNum != newNp.Status.ReadyNodeNum ||
+		oldNp.Status.UnreadyNodeNum != newNp.Status.UnreadyNodeNum ||
+		!apiequality.Semantic.DeepEqual(oldNp.Status.Allocatable, newNp.Status.Allocatable) {
+		e.addReferencingUnitedDeploymentToWorkQueue(limitingInterface, true, newNp)
+	}
+}
+
+func (e *EnqueueUnitedDeploymentForNodePool) Delete(event event.DeleteEvent, limitingInterface workqueue.RateLimitingInterface) {
+	np, ok := event.Object.(*unitv1alpha1.NodePool)
+	if !ok {
+		return
+	}
+	e.addReferencingUnitedDeploymentToWorkQueue(limitingInterface, false, np)
+}
+
+func (e *EnqueueUnitedDeploymentForNodePool) Generic(event event.GenericEvent, limitingInterface workqueue.RateLimitingInterface) {
+	return
+}
+
+// addReferencingUnitedDeploymentToWorkQueue enqueues the UnitedDeployments
+// referencing the NodePools or their ancestors, only those depending on the
+// status of the NodePools if statusOnly is set
+func (e *EnqueueUnitedDeploymentForNodePool) addReferencingUnitedDeploymentToWorkQueue(
+	limitingInterface workqueue.RateLimitingInterface, statusOnly bool, nps ...*unitv1alpha1.NodePool) {
+	nodePools := &unitv1alpha1.NodePoolList{}
+	if err := e.client.List(context.TODO(), nodePools); err != nil {
+		return
+	}
+	names := sets.NewString()
+	for _, np := range nps {
+		names = names.Union(hierarchyNodePools(np, nodePools.Items))
+	}
+
+	uds := &unitv1alpha1.UnitedDeploymentList{}
+	if err := e.client.List(context.TODO(), uds); err != nil {
+		return
+	}
+	for i := range uds.Items {
+		ud := &uds.Items[i]
+		if statusOnly && !isCapacityDistribution(ud.Spec.ReplicasDistribution) && ud.Spec.FailoverPolicy == nil {
+			continue
+		}
+		if !referencesNodePools(ud, names) {
+			continue
+		}
+		limitingInterface.Add(reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: ud.GetName(), Namespace: ud.GetNamespace()},
+		})
+	}
+}
+
+// hierarchyNodePools returns the names of the NodePool and its ancestors, the
+// pools targeting any of them are expanded to the leaf NodePools under it
+func hierarchyNodePools(np *unitv1alpha1.NodePool, nodePools []unitv1alpha1.NodePool) sets.String {
+	names := sets.NewString(np.Name)
+	for _, ancestor := range nodepoolutil.Ancestors(np, nodePools) {
+		names.Insert(ancestor.Name)
+	}
+	return names
+}
+
+// referencesNodePools checks if a pool or a fallback pool of the
+// UnitedDeployment targets one of the NodePools
+func referencesNodePools(ud *unitv1alpha1.UnitedDeployment, names sets.String) bool {
+	for i := range ud.Spec.Topology.Pools {
+		if names.Has(poolNodePool(&ud.Spec.Topology.Pools[i])) {
+			return true
+		}
+	}
+	return ud.Spec.FailoverPolicy != nil && names.HasAny(ud.Spec.FailoverPolicy.FallbackPools...)
+}
+
+var _ handler.EventHandler = &EnqueueUnitedDeploymentForNodePool{}
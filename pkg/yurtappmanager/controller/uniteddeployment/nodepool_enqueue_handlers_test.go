@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestReferencesNodePools(t *testing.T) {
+	nodePools := []unitv1alpha1.NodePool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "site-a"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cloud"}},
+	}
+	newUD := func(fallbackPools []string, nodePools ...string) *unitv1alpha1.UnitedDeployment {
+		ud := &unitv1alpha1.UnitedDeployment{}
+		for _, np := range nodePools {
+			ud.Spec.Topology.Pools = append(ud.Spec.Topology.Pools,
+				unitv1alpha1.Pool{Name: np + "-pool", NodeSelectorTerm: nodePoolTerm(np)})
+		}
+		if fallbackPools != nil {
+			ud.Spec.FailoverPolicy = &unitv1alpha1.UnitedDeploymentFailoverPolicy{FallbackPools: fallbackPools}
+		}
+		return ud
+	}
+	// a child NodePool created under site-a changes the leaves of site-a and
+	// region
+	child := &unitv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "site-a-1"},
+		Spec:       unitv1alpha1.NodePoolSpec{Parent: "site-a"},
+	}
+	names := hierarchyNodePools(child, nodePools)
+	if !names.Equal(sets.NewString("site-a-1", "site-a", "region")) {
+		t.Fatalf("unexpected hierarchy %v", names.List())
+	}
+
+	tests := []struct {
+		name   string
+		ud     *unitv1alpha1.UnitedDeployment
+		expect bool
+	}{
+		{"pool targeting the parent", newUD(nil, "site-a"), true},
+		{"pool targeting an ancestor", newUD(nil, "cloud", "region"), true},
+		{"fallback pool naming an ancestor", newUD([]string{"region"}, "cloud"), true},
+		{"pools in another hierarchy", newUD([]string{"cloud"}, "cloud"), false},
+		{"pool without nodepool", newUD(nil), false},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if get := referencesNodePools(st.ud, names); get != st.expect {
+				t.Errorf("expect references %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -28,6 +28,8 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
 	"k8s.io/klog"
 	"k8s.io/kubernetes/pkg/controller/history"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -77,7 +79,11 @@ func (r *ReconcileUnitedDeployment) controlledHistories(ud *appsalphav1.UnitedDe
 	return claimHistories, nil
 }
 
-func (r *ReconcileUnitedDeployment) constructUnitedDeploymentRevisions(ud *appsalphav1.UnitedDeployment) (*apps.ControllerRevision, *apps.ControllerRevision, int32, error) {
+// constructUnitedDeploymentRevisions returns the current and update revisions
+// of the UnitedDeployment, the live revisions, e.g. those of the pools held
+// back by the update strategy, are kept in the history
+func (r *ReconcileUnitedDeployment) constructUnitedDeploymentRevisions(ud *appsalphav1.UnitedDeployment,
+	live sets.String) (*apps.ControllerRevision, *apps.ControllerRevision, int32, error) {
 	var currentRevision, updateRevision *apps.ControllerRevision
 	revisions, err := r.controlledHistories(ud)
 	if err != nil {
@@ -88,7 +94,7 @@ func (r *ReconcileUnitedDeployment) constructUnitedDeploymentRevisions(ud *appsa
 	}
 
 	history.SortControllerRevisions(revisions)
-	cleanedRevision, err := r.cleanExpiredRevision(ud, &revisions)
+	cleanedRevision, err := r.cleanExpiredRevision(ud, &revisions, live)
 	if err != nil {
 		if ud.Status.CollisionCount == nil {
 			return currentRevision, updateRevision, 0, err
@@ -151,32 +157,42 @@ func (r *ReconcileUnitedDeployment) constructUnitedDeploymentRevisions(ud *appsa
 }
 
 func (r *ReconcileUnitedDeployment) cleanExpiredRevision(ud *appsalphav1.UnitedDeployment,
-	sortedRevisions *[]*apps.ControllerRevision) (*[]*apps.ControllerRevision, error) {
-
-	exceedNum := len(*sortedRevisions) - int(*ud.Spec.RevisionHistoryLimit)
-	if exceedNum <= 0 {
-		return sortedRevisions, nil
+	sortedRevisions *[]*apps.ControllerRevision, live sets.String) (*[]*apps.ControllerRevision, error) {
+	expired, cleanedRevisions := expiredRevisions(*sortedRevisions, int(*ud.Spec.RevisionHistoryLimit),
+		live.Union(sets.NewString(ud.Status.CurrentRevision)))
+	for _, revision := range expired {
+		if err := r.Client.Delete(context.TODO(), revision); err != nil {
+			return sortedRevisions, err
+		}
 	}
+	return &cleanedRevisions, nil
+}
 
-	live := map[string]bool{}
-	live[ud.Status.CurrentRevision] = true
-
-	for i, revision := range *sortedRevisions {
-		if _, exist := live[revision.Name]; exist {
+// expiredRevisions splits the sorted revisions into the oldest ones beyond the
+// history limit and the ones kept, the live revisions are always kept
+func expiredRevisions(sortedRevisions []*apps.ControllerRevision, limit int,
+	live sets.String) (expired, kept []*apps.ControllerRevision) {
+	exceedNum := len(sortedRevisions) - limit
+	for i, revision := range sortedRevisions {
+		if i < exceedNum && !live.Has(revision.Name) {
+			expired = append(expired, revision)
 			continue
 		}
+		kept = append(kept, revision)
+	}
+	return expired, kept
+}
 
-		if i >= exceedNum {
-			break
-		}
-
-		if err := r.Client.Delete(context.TODO(), revision); err != nil {
-			return sortedRevisions, err
+// poolRevisions returns the revisions of the pool workloads, the pools held
+// back by the update strategy are restored from them
+func poolRevisions(nameToPool map[string]*Pool) sets.String {
+	revisions := sets.NewString()
+	for _, pool := range nameToPool {
+		if revision := pool.Spec.PoolRef.GetLabels()[appsalphav1.ControllerRevisionHashLabelKey]; revision != "" {
+			revisions.Insert(revision)
 		}
 	}
-	cleanedRevisions := (*sortedRevisions)[exceedNum:]
-
-	return &cleanedRevisions, nil
+	return revisions
 }
 
 // createControllerRevision creates the controller revision owned by the parent.
@@ -284,3 +300,33 @@ func getUnitedDeploymentPatch(ud *appsalphav1.UnitedDeployment) ([]byte, error)
 	patch, err := json.Marshal(objCopy)
 	return patch, err
 }
+
+// restoreRevision returns a copy of the UnitedDeployment with the workload
+// template of the named revision, the other fields are kept
+func restoreRevision(ud *appsalphav1.UnitedDeployment, name string,
+	revisions []*apps.ControllerRevision) (*appsalphav1.UnitedDeployment, error) {
+	var revision *apps.ControllerRevision
+	for i := range revisions {
+		if revisions[i].Name == name {
+			revision = revisions[i]
+			break
+		}
+	}
+	if revision == nil {
+		return nil, fmt.Errorf("revision %s not found", name)
+	}
+
+	original, err := json.Marshal(ud)
+	if err != nil {
+		return nil, err
+	}
+	patched, err := strategicpatch.StrategicMergePatch(original, revision.Data.Raw, ud)
+	if err != nil {
+		return nil, err
+	}
+	restored := &appsalphav1.UnitedDeployment{}
+	if err := json.Unmarshal(patched, restored); err != nil {
+		return nil, err
+	}
+	return restored, nil
+}
@@ -26,10 +26,12 @@ import (
 	"flag"
 	"fmt"
 	"reflect"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/klog"
@@ -62,6 +64,9 @@ const (
 	eventTypeDupPoolsDelete     = "DeleteDuplicatedPools"
 	eventTypePoolsUpdate        = "UpdatePool"
 	eventTypeTemplateController = "TemplateController"
+	eventTypePoolFailover       = "PoolFailover"
+	eventTypePoolRecover        = "PoolRecover"
+	eventTypeNodePoolNotFound   = "NodePoolNotFound"
 
 	slowStartInitialBatchSize = 1
 )
@@ -121,6 +126,12 @@ func add(mgr manager.Manager, r reconcile.Reconciler) error {
 		return err
 	}
 
+	// Watch for changes to the NodePool hierarchy
+	err = c.Watch(&source.Kind{Type: &unitv1alpha1.NodePool{}}, &EnqueueUnitedDeploymentForNodePool{client: mgr.GetClient()})
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -145,6 +156,8 @@ type ReconcileUnitedDeployment struct {
 // +kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=core,resources=persistentvolumeclaims,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=coordination.k8s.io,resources=leases,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=apps.openyurt.io,resources=nodepools,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=pods,verbs=get;list;watch
 
 // Reconcile reads that state of the cluster for a UnitedDeployment object and makes changes based on the state read
 // and what is in the UnitedDeployment.Spec
@@ -165,12 +178,13 @@ func (r *ReconcileUnitedDeployment) Reconcile(_ context.Context, request reconci
 	}
 	oldStatus := instance.Status.DeepCopy()
 
-	currentRevision, updatedRevision, collisionCount, err := r.constructUnitedDeploymentRevisions(instance)
-	if err != nil {
-		klog.Errorf("Fail to construct controller revision of UnitedDeployment %s/%s: %s", instance.Namespace, instance.Name, err)
-		r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypeRevisionProvision), err.Error())
+	// expand the pools targeting parent NodePools, the expanded topology is
+	// only used in memory and never written back
+	nodePools := unitv1alpha1.NodePoolList{}
+	if err := r.List(context.TODO(), &nodePools); err != nil {
 		return reconcile.Result{}, err
 	}
+	instance.Spec.Topology.Pools = ExpandTopologyPools(instance.Spec.Topology.Pools, nodePools.Items)
 
 	control, poolType, err := r.getPoolControls(instance)
 	if err != nil {
@@ -188,20 +202,53 @@ func (r *ReconcileUnitedDeployment) Reconcile(_ context.Context, request reconci
 		return reconcile.Result{}, nil
 	}
 
-	nextPatches := GetNextPatches(instance)
+	// the revisions of the pools are kept in the history, as the pools held
+	// back by the update strategy are restored from them
+	currentRevision, updatedRevision, collisionCount, err := r.constructUnitedDeploymentRevisions(instance, poolRevisions(nameToPool))
+	if err != nil {
+		klog.Errorf("Fail to construct controller revision of UnitedDeployment %s/%s: %s", instance.Namespace, instance.Name, err)
+		r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypeRevisionProvision), err.Error())
+		return reconcile.Result{}, err
+	}
+
+	if isCapacityDistribution(instance.Spec.ReplicasDistribution) {
+		if missing := poolsWithoutNodePool(instance.Spec.Topology.Pools, nodePools.Items); len(missing) != 0 {
+			r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, eventTypeNodePoolNotFound,
+				fmt.Sprintf("No NodePool is selected by the nodeSelectorTerm of pools %v, they have no capacity", missing))
+		}
+	}
+	nextPatches := GetNextPatches(instance, nodePools.Items)
+	var failovers []unitv1alpha1.UnitedDeploymentPoolFailover
+	var failoverRequeueAfter time.Duration
+	if instance.Spec.FailoverPolicy != nil {
+		failovers, failoverRequeueAfter = planFailover(instance.Spec.FailoverPolicy, oldStatus.PoolFailovers,
+			instance.Spec.Topology.Pools, nameToPool, nodePools.Items, metav1.Now())
+		targets := applyFailover(nextPatches, failovers, instance.Spec.FailoverPolicy, nodePools.Items)
+		r.recordFailoverEvents(instance, oldStatus.PoolFailovers, failovers, targets)
+	}
 	klog.V(4).Infof("Get UnitedDeployment %s/%s next Patches %v", instance.Namespace, instance.Name, nextPatches)
 
 	expectedRevision := currentRevision
 	if updatedRevision != nil {
 		expectedRevision = updatedRevision
 	}
-	newStatus, err := r.managePools(instance, nameToPool, nextPatches, expectedRevision, poolType)
+	newStatus, requeueAfter, err := r.managePools(instance, nameToPool, nextPatches, expectedRevision, nodePools.Items, poolType)
 	if err != nil {
 		klog.Errorf("Fail to update UnitedDeployment %s/%s: %s", instance.Namespace, instance.Name, err)
 		r.recorder.Event(instance.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypePoolsUpdate), err.Error())
 	}
+	newStatus.PoolFailovers = failovers
+	if failoverRequeueAfter > 0 && (requeueAfter == 0 || failoverRequeueAfter < requeueAfter) {
+		requeueAfter = failoverRequeueAfter
+	}
 
-	return r.updateStatus(instance, newStatus, oldStatus, nameToPool, currentRevision, collisionCount, control)
+	result, err := r.updateStatus(instance, newStatus, oldStatus, nameToPool, nextPatches, currentRevision, collisionCount, control)
+	if err == nil && requeueAfter > 0 {
+		// wait for the interval between the batches of the update strategy,
+		// or for the next transition of the pool failover
+		result.RequeueAfter = requeueAfter
+	}
+	return result, err
 }
 
 func (r *ReconcileUnitedDeployment) getNameToPool(instance *unitv1alpha1.UnitedDeployment, control ControlInterface) (map[string]*Pool, error) {
@@ -272,17 +319,17 @@ func (r *ReconcileUnitedDeployment) classifyPoolByPoolName(pools []*Pool) map[st
 }
 
 func (r *ReconcileUnitedDeployment) updateStatus(instance *unitv1alpha1.UnitedDeployment, newStatus, oldStatus *unitv1alpha1.UnitedDeploymentStatus,
-	nameToPool map[string]*Pool, currentRevision *appsv1.ControllerRevision,
+	nameToPool map[string]*Pool, nextPatches map[string]UnitedDeploymentPatches, currentRevision *appsv1.ControllerRevision,
 	collisionCount int32, control ControlInterface) (reconcile.Result, error) {
 
-	newStatus = r.calculateStatus(instance, newStatus, nameToPool, currentRevision, collisionCount, control)
+	newStatus = r.calculateStatus(instance, newStatus, nameToPool, nextPatches, currentRevision, collisionCount, control)
 	_, err := r.updateUnitedDeployment(instance, oldStatus, newStatus)
 
 	return reconcile.Result{}, err
 }
 
 func (r *ReconcileUnitedDeployment) calculateStatus(instance *unitv1alpha1.UnitedDeployment, newStatus *unitv1alpha1.UnitedDeploymentStatus,
-	nameToPool map[string]*Pool, currentRevision *appsv1.ControllerRevision,
+	nameToPool map[string]*Pool, nextPatches map[string]UnitedDeploymentPatches, currentRevision *appsv1.ControllerRevision,
 	collisionCount int32, control ControlInterface) *unitv1alpha1.UnitedDeploymentStatus {
 
 	newStatus.CollisionCount = &collisionCount
@@ -292,18 +339,27 @@ func (r *ReconcileUnitedDeployment) calculateStatus(instance *unitv1alpha1.Unite
 		newStatus.CurrentRevision = currentRevision.Name
 	}
 
-	// sync from status
+	// the replicas distributed to the pools
 	newStatus.PoolReplicas = make(map[string]int32)
+	for name, patches := range nextPatches {
+		newStatus.PoolReplicas[name] = patches.Replicas
+	}
+
+	// sync from status
 	newStatus.ReadyReplicas = 0
 	newStatus.Replicas = 0
 	for _, pool := range nameToPool {
-		newStatus.PoolReplicas[pool.Name] = pool.Status.Replicas
 		newStatus.Replicas += pool.Status.Replicas
 		newStatus.ReadyReplicas += pool.Status.ReadyReplicas
 	}
 
 	newStatus.TemplateType = getPoolTemplateType(instance)
 
+	// expose the selector of the pods for the scale subresource
+	if selector, err := metav1.LabelSelectorAsSelector(instance.Spec.Selector); err == nil {
+		newStatus.LabelSelector = selector.String()
+	}
+
 	var poolFailure *string
 	for _, pool := range nameToPool {
 		failureMessage := control.GetPoolFailure(pool)
@@ -340,9 +396,12 @@ func (r *ReconcileUnitedDeployment) updateUnitedDeployment(ud *unitv1alpha1.Unit
 		oldStatus.CollisionCount == newStatus.CollisionCount &&
 		oldStatus.Replicas == newStatus.Replicas &&
 		oldStatus.ReadyReplicas == newStatus.ReadyReplicas &&
+		oldStatus.LabelSelector == newStatus.LabelSelector &&
 		ud.Generation == newStatus.ObservedGeneration &&
 		reflect.DeepEqual(oldStatus.PoolReplicas, newStatus.PoolReplicas) &&
-		reflect.DeepEqual(oldStatus.Conditions, newStatus.Conditions) {
+		reflect.DeepEqual(oldStatus.Conditions, newStatus.Conditions) &&
+		reflect.DeepEqual(oldStatus.UpdateStatus, newStatus.UpdateStatus) &&
+		reflect.DeepEqual(oldStatus.PoolFailovers, newStatus.PoolFailovers) {
 		return ud, nil
 	}
 
@@ -17,6 +17,17 @@ limitations under the License.
 
 package uniteddeployment
 
+import (
+	"reflect"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/uniteddeployment/adapter"
+)
+
 /*
 
 import (
@@ -129,3 +140,79 @@ func TestReconcile(t *testing.T) {
 }
 
 */
+
+// noFailureControl is a ControlInterface reporting no pool failure
+type noFailureControl struct {
+	ControlInterface
+}
+
+func (noFailureControl) GetPoolFailure(*Pool) *string {
+	return nil
+}
+
+func TestCalculateStatusForScale(t *testing.T) {
+	tests := []struct {
+		name               string
+		replicas           *int32
+		expectPoolReplicas map[string]int32
+	}{
+		{
+			// the scale subresource reports the replicas of the pods, and
+			// scaling sets spec.replicas and distributes it
+			"total replicas",
+			int32Ptr(10),
+			map[string]int32{"a": 3, "b": 7},
+		},
+		{
+			// the scale subresource reports 0 replicas in spec, the pools
+			// run their own replicas until the UnitedDeployment is scaled
+			"replicas unspecified",
+			nil,
+			map[string]int32{"a": 3, "b": 0},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			ud := &unitv1alpha1.UnitedDeployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "default"},
+				Spec: unitv1alpha1.UnitedDeploymentSpec{
+					Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "foo"}},
+					Replicas: st.replicas,
+					WorkloadTemplate: unitv1alpha1.WorkloadTemplate{
+						DeploymentTemplate: &unitv1alpha1.DeploymentTemplateSpec{},
+					},
+					Topology: unitv1alpha1.Topology{
+						Pools: []unitv1alpha1.Pool{{Name: "a", Replicas: int32Ptr(3)}, {Name: "b"}},
+					},
+				},
+			}
+			nextPatches := GetNextPatches(ud, nil)
+			nameToPool := map[string]*Pool{
+				"a": {Name: "a", Status: PoolStatus{ReplicasInfo: adapter.ReplicasInfo{Replicas: 3, ReadyReplicas: 2}}},
+				"b": {Name: "b", Status: PoolStatus{ReplicasInfo: adapter.ReplicasInfo{Replicas: 1, ReadyReplicas: 1}}},
+			}
+			revision := &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: "foo-1"}}
+
+			r := &ReconcileUnitedDeployment{}
+			status := r.calculateStatus(ud, &unitv1alpha1.UnitedDeploymentStatus{}, nameToPool, nextPatches,
+				revision, 0, noFailureControl{})
+			if status.LabelSelector != "app=foo" {
+				t.Errorf("expect label selector app=foo, but get %s", status.LabelSelector)
+			}
+			if !reflect.DeepEqual(status.PoolReplicas, st.expectPoolReplicas) {
+				t.Errorf("expect pool replicas %v, but get %v", st.expectPoolReplicas, status.PoolReplicas)
+			}
+			// the status replicas of the scale subresource count the pods of
+			// the pools whether spec.replicas is set or not
+			if status.Replicas != 4 || status.ReadyReplicas != 3 {
+				t.Errorf("expect 4 replicas and 3 ready replicas, but get %d and %d",
+					status.Replicas, status.ReadyReplicas)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
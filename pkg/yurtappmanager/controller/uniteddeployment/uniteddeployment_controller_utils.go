@@ -26,8 +26,11 @@ import (
 	"fmt"
 
 	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const updateRetries = 5
@@ -103,13 +106,13 @@ func filterOutCondition(conditions []unitv1alpha1.UnitedDeploymentCondition, con
 	return newConditions
 }
 
-func GetNextPatches(ud *unitv1alpha1.UnitedDeployment) map[string]UnitedDeploymentPatches {
+func GetNextPatches(ud *unitv1alpha1.UnitedDeployment, nodePools []unitv1alpha1.NodePool) map[string]UnitedDeploymentPatches {
 	next := make(map[string]UnitedDeploymentPatches)
+	replicas := DistributeReplicas(ud.Spec.Replicas, ud.Spec.Topology.Pools,
+		PoolCapacities(ud.Spec.ReplicasDistribution, ud.Spec.Topology.Pools, nodePools))
 	for _, pool := range ud.Spec.Topology.Pools {
 		t := UnitedDeploymentPatches{}
-		if pool.Replicas != nil {
-			t.Replicas = *pool.Replicas
-		}
+		t.Replicas = replicas[pool.Name]
 		if pool.Patch != nil {
 			t.Patch = string(pool.Patch.Raw)
 		}
@@ -117,3 +120,155 @@ func GetNextPatches(ud *unitv1alpha1.UnitedDeployment) map[string]UnitedDeployme
 	}
 	return next
 }
+
+// poolNodePool returns the NodePool that the pool targets through the
+// `apps.openyurt.io/nodepool In [x]` requirement of its nodeSelectorTerm, or
+// an empty string if the pool doesn't target a single NodePool
+func poolNodePool(pool *unitv1alpha1.Pool) string {
+	for _, req := range pool.NodeSelectorTerm.MatchExpressions {
+		if req.Key == unitv1alpha1.LabelCurrentNodePool &&
+			req.Operator == corev1.NodeSelectorOpIn && len(req.Values) == 1 {
+			return req.Values[0]
+		}
+	}
+	return ""
+}
+
+// ExpandTopologyPools replaces the pools targeting a parent NodePool with one
+// pool for each leaf NodePool under it, the expanded pools are named after
+// the leaf NodePools and their nodeSelectorTerm selects the nodes of the leaf
+// NodePool. Pools configured explicitly, by the name or the NodePool, take
+// precedence over expanded ones. The expanded pools share the config of the
+// parent pool, except that the replicas and the weight of the parent pool
+// are split evenly across them, so that the parent NodePool runs as many
+// replicas as the parent pool would do.
+// The expanded pools take the names of the leaf NodePools, so a leaf
+// NodePool that gains a child, or a targeted NodePool that gains its first
+// child, renames its pools: the workloads of the old pools are deleted while
+// those of the new leaves are created. Adding or removing a sibling leaf
+// keeps the names of the other pools
+func ExpandTopologyPools(pools []unitv1alpha1.Pool, nodePools []unitv1alpha1.NodePool) []unitv1alpha1.Pool {
+	names, targeted := sets.NewString(), sets.NewString()
+	for i := range pools {
+		names.Insert(pools[i].Name)
+		if np := poolNodePool(&pools[i]); np != "" {
+			targeted.Insert(np)
+		}
+	}
+
+	// leaves of the pools targeting a parent NodePool
+	leaves := make([][]string, len(pools))
+	expanded := sets.NewString()
+	for i := range pools {
+		np := poolNodePool(&pools[i])
+		if np == "" || len(nodepoolutil.Children(np, nodePools)) == 0 {
+			continue
+		}
+		leaves[i] = []string{}
+		for _, leaf := range nodepoolutil.LeafNodePools(np, nodePools) {
+			if names.Has(leaf) || targeted.Has(leaf) || expanded.Has(leaf) {
+				continue
+			}
+			expanded.Insert(leaf)
+			leaves[i] = append(leaves[i], leaf)
+		}
+	}
+
+	// the integer weights are scaled by the least common multiple of the
+	// leaf counts, so that the weight of a parent pool is split exactly
+	scale := 1
+	for i := range pools {
+		if n := len(leaves[i]); n > 0 && isIntWeighted(&pools[i]) {
+			scale = lcm(scale, n)
+		}
+	}
+
+	var result []unitv1alpha1.Pool
+	for i := range pools {
+		pool := pools[i]
+		if leaves[i] == nil {
+			if scale > 1 && isIntWeighted(&pool) {
+				pool.Weight = scaleWeight(pool.Weight, scale, 1)
+			}
+			result = append(result, pool)
+			continue
+		}
+
+		n := len(leaves[i])
+		for j, leaf := range leaves[i] {
+			leafPool := *pool.DeepCopy()
+			leafPool.Name = leaf
+			leafPool.NodeSelectorTerm.MatchExpressions = nil
+			for _, req := range pool.NodeSelectorTerm.MatchExpressions {
+				if req.Key != unitv1alpha1.LabelCurrentNodePool {
+					leafPool.NodeSelectorTerm.MatchExpressions = append(
+						leafPool.NodeSelectorTerm.MatchExpressions, *req.DeepCopy())
+				}
+			}
+			leafPool.NodeSelectorTerm.MatchExpressions = append(
+				leafPool.NodeSelectorTerm.MatchExpressions, corev1.NodeSelectorRequirement{
+					Key:      unitv1alpha1.LabelCurrentNodePool,
+					Operator: corev1.NodeSelectorOpIn,
+					Values:   []string{leaf},
+				})
+			leafPool.Replicas = splitCount(pool.Replicas, n, j)
+			leafPool.MinReplicas = splitCount(pool.MinReplicas, n, j)
+			leafPool.MaxReplicas = splitCount(pool.MaxReplicas, n, j)
+			if isIntWeighted(&pool) {
+				leafPool.Weight = scaleWeight(pool.Weight, scale, n)
+			} else if pool.Weight != nil && pool.Weight.Type == intstr.String {
+				percent, _ := intstr.GetValueFromIntOrPercent(pool.Weight, 100, false)
+				share := intstr.FromString(fmt.Sprintf("%d%%", evenShare(int32(percent), n, j)))
+				leafPool.Weight = &share
+			}
+			result = append(result, leafPool)
+		}
+	}
+	return result
+}
+
+// isIntWeighted checks if the replicas of the pool are split by its integer
+// weight, which defaults to 1
+func isIntWeighted(pool *unitv1alpha1.Pool) bool {
+	return pool.Replicas == nil && (pool.Weight == nil || pool.Weight.Type == intstr.Int)
+}
+
+// scaleWeight returns the integer weight multiplied by scale and divided by
+// n, scale is a multiple of n
+func scaleWeight(weight *intstr.IntOrString, scale, n int) *intstr.IntOrString {
+	w := 1
+	if weight != nil {
+		w = weight.IntValue()
+	}
+	scaled := intstr.FromInt(w * scale / n)
+	return &scaled
+}
+
+// splitCount returns the i-th of the n even shares of the count, or nil if
+// the count is unspecified
+func splitCount(count *int32, n, i int) *int32 {
+	if count == nil {
+		return nil
+	}
+	share := evenShare(*count, n, i)
+	return &share
+}
+
+// evenShare returns the i-th of the n even shares of the total, the first
+// shares take one more if the total can't be split evenly
+func evenShare(total int32, n, i int) int32 {
+	share := total / int32(n)
+	if int32(i) < total%int32(n) {
+		share++
+	}
+	return share
+}
+
+// lcm returns the least common multiple of a and b
+func lcm(a, b int) int {
+	x, y := a, b
+	for y != 0 {
+		x, y = y, x%y
+	}
+	return a / x * b
+}
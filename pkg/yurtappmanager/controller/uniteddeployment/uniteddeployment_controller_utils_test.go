@@ -0,0 +1,229 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// nodePoolTerm returns the nodeSelectorTerm that selects the nodes of the
+// NodePool
+func nodePoolTerm(nodePool string) corev1.NodeSelectorTerm {
+	return corev1.NodeSelectorTerm{
+		MatchExpressions: []corev1.NodeSelectorRequirement{{
+			Key:      unitv1alpha1.LabelCurrentNodePool,
+			Operator: corev1.NodeSelectorOpIn,
+			Values:   []string{nodePool},
+		}},
+	}
+}
+
+// describePools describes the pools in the form of
+// <name>@<nodepool> replicas=<n> min=<n> max=<n> weight=<w>
+func describePools(pools []unitv1alpha1.Pool) []string {
+	count := func(c *int32) string {
+		if c == nil {
+			return "-"
+		}
+		return fmt.Sprint(*c)
+	}
+	var descriptions []string
+	for i := range pools {
+		weight := "-"
+		if pools[i].Weight != nil {
+			weight = pools[i].Weight.String()
+		}
+		descriptions = append(descriptions, fmt.Sprintf("%s@%s replicas=%s min=%s max=%s weight=%s",
+			pools[i].Name, poolNodePool(&pools[i]), count(pools[i].Replicas),
+			count(pools[i].MinReplicas), count(pools[i].MaxReplicas), weight))
+	}
+	return descriptions
+}
+
+func TestExpandTopologyPools(t *testing.T) {
+	nodePools := []unitv1alpha1.NodePool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "site-a"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "site-b"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "site-c"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cloud"}},
+	}
+	tests := []struct {
+		name   string
+		pools  []unitv1alpha1.Pool
+		expect []string
+	}{
+		{
+			"pool targeting a leaf nodepool",
+			[]unitv1alpha1.Pool{{Name: "cloud-pool", NodeSelectorTerm: nodePoolTerm("cloud"), Replicas: int32Ptr(2)}},
+			[]string{"cloud-pool@cloud replicas=2 min=- max=- weight=-"},
+		},
+		{
+			"pool named after the parent nodepool without selecting it",
+			[]unitv1alpha1.Pool{{Name: "region", Replicas: int32Ptr(2)}},
+			[]string{"region@ replicas=2 min=- max=- weight=-"},
+		},
+		{
+			"parent pool is expanded to the leaves",
+			[]unitv1alpha1.Pool{{Name: "region-pool", NodeSelectorTerm: nodePoolTerm("region")}},
+			[]string{
+				"site-a@site-a replicas=- min=- max=- weight=1",
+				"site-b@site-b replicas=- min=- max=- weight=1",
+				"site-c@site-c replicas=- min=- max=- weight=1",
+			},
+		},
+		{
+			"fixed replicas are split",
+			[]unitv1alpha1.Pool{{Name: "region-pool", NodeSelectorTerm: nodePoolTerm("region"), Replicas: int32Ptr(7)}},
+			[]string{
+				"site-a@site-a replicas=3 min=- max=- weight=-",
+				"site-b@site-b replicas=2 min=- max=- weight=-",
+				"site-c@site-c replicas=2 min=- max=- weight=-",
+			},
+		},
+		{
+			"bounds are split",
+			[]unitv1alpha1.Pool{
+				{Name: "region-pool", NodeSelectorTerm: nodePoolTerm("region"), MinReplicas: int32Ptr(4), MaxReplicas: int32Ptr(5)},
+			},
+			[]string{
+				"site-a@site-a replicas=- min=2 max=2 weight=1",
+				"site-b@site-b replicas=- min=1 max=2 weight=1",
+				"site-c@site-c replicas=- min=1 max=1 weight=1",
+			},
+		},
+		{
+			"explicit pools take precedence",
+			[]unitv1alpha1.Pool{
+				{Name: "region-pool", NodeSelectorTerm: nodePoolTerm("region"), Replicas: int32Ptr(6)},
+				{Name: "edge-b", NodeSelectorTerm: nodePoolTerm("site-b"), Replicas: int32Ptr(1)},
+				{Name: "site-c", NodeSelectorTerm: nodePoolTerm("cloud"), Replicas: int32Ptr(1)},
+			},
+			[]string{
+				"site-a@site-a replicas=6 min=- max=- weight=-",
+				"edge-b@site-b replicas=1 min=- max=- weight=-",
+				"site-c@cloud replicas=1 min=- max=- weight=-",
+			},
+		},
+		{
+			"integer weights are scaled",
+			[]unitv1alpha1.Pool{
+				{Name: "region-pool", NodeSelectorTerm: nodePoolTerm("region"), Weight: weightPtr(intstr.FromInt(2))},
+				{Name: "cloud-pool", NodeSelectorTerm: nodePoolTerm("cloud")},
+				{Name: "fixed-pool", Replicas: int32Ptr(1)},
+			},
+			[]string{
+				"site-a@site-a replicas=- min=- max=- weight=2",
+				"site-b@site-b replicas=- min=- max=- weight=2",
+				"site-c@site-c replicas=- min=- max=- weight=2",
+				"cloud-pool@cloud replicas=- min=- max=- weight=3",
+				"fixed-pool@ replicas=1 min=- max=- weight=-",
+			},
+		},
+		{
+			"percentage is split",
+			[]unitv1alpha1.Pool{
+				{Name: "region-pool", NodeSelectorTerm: nodePoolTerm("region"), Weight: weightPtr(intstr.FromString("50%"))},
+			},
+			[]string{
+				"site-a@site-a replicas=- min=- max=- weight=17%",
+				"site-b@site-b replicas=- min=- max=- weight=17%",
+				"site-c@site-c replicas=- min=- max=- weight=16%",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if get := describePools(ExpandTopologyPools(st.pools, nodePools)); !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect pools %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestExpandTopologyPoolsOnHierarchyChange(t *testing.T) {
+	nodePools := []unitv1alpha1.NodePool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "site-a"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "site-b"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cloud"}},
+	}
+	pools := []unitv1alpha1.Pool{
+		{Name: "region-pool", NodeSelectorTerm: nodePoolTerm("region"), Replicas: int32Ptr(4)},
+		{Name: "cloud-pool", NodeSelectorTerm: nodePoolTerm("cloud"), Replicas: int32Ptr(1)},
+	}
+	tests := []struct {
+		name   string
+		added  unitv1alpha1.NodePool
+		expect []string
+	}{
+		{
+			"sibling leaf keeps the names of the other pools",
+			unitv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "site-c"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+			[]string{
+				"site-a@site-a replicas=2 min=- max=- weight=-",
+				"site-b@site-b replicas=1 min=- max=- weight=-",
+				"site-c@site-c replicas=1 min=- max=- weight=-",
+				"cloud-pool@cloud replicas=1 min=- max=- weight=-",
+			},
+		},
+		{
+			"child of a leaf renames the pool of the leaf",
+			unitv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "site-a-1"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "site-a"}},
+			[]string{
+				"site-a-1@site-a-1 replicas=2 min=- max=- weight=-",
+				"site-b@site-b replicas=2 min=- max=- weight=-",
+				"cloud-pool@cloud replicas=1 min=- max=- weight=-",
+			},
+		},
+		{
+			"first child of a targeted nodepool renames the pool",
+			unitv1alpha1.NodePool{ObjectMeta: metav1.ObjectMeta{Name: "cloud-a"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "cloud"}},
+			[]string{
+				"site-a@site-a replicas=2 min=- max=- weight=-",
+				"site-b@site-b replicas=2 min=- max=- weight=-",
+				"cloud-a@cloud-a replicas=1 min=- max=- weight=-",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			changed := append(append([]unitv1alpha1.NodePool{}, nodePools...), st.added)
+			if get := describePools(ExpandTopologyPools(pools, changed)); !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect pools %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
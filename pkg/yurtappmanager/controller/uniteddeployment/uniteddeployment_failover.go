@@ -0,0 +1,244 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+// isNodePoolDark checks if all the nodes of the NodePool are not ready, a
+// NodePool without nodes or not found is not dark
+func isNodePoolDark(name string, nodePools []unitv1alpha1.NodePool) bool {
+	for _, np := range nodePools {
+		if np.Name == name {
+			return np.Status.ReadyNodeNum == 0 && np.Status.UnreadyNodeNum > 0
+		}
+	}
+	return false
+}
+
+// maxFailoverHoldDownShift caps the doubling of the hold-down time of the
+// pools failed over for the pending pods
+const maxFailoverHoldDownShift = 5
+
+// unschedulableReplicas returns the number of the pods of the pool that can't
+// be scheduled, e.g. the site runs out of capacity. Pods that are scheduled
+// but not ready, e.g. crash looping, are not counted
+func unschedulableReplicas(pool *Pool) int32 {
+	if pool == nil {
+		return 0
+	}
+	return pool.Status.UnschedulableReplicas
+}
+
+// pendingHoldDown returns the hold-down time of a pool failed over for the
+// pending pods, it doubles each time the replicas moved back are pending again
+func pendingHoldDown(holdDown time.Duration, retries int32) time.Duration {
+	if retries > maxFailoverHoldDownShift {
+		retries = maxFailoverHoldDownShift
+	}
+	return holdDown << uint(retries)
+}
+
+// planFailover returns the failover state of the pools and the time to wait
+// before the next transition. A pool fails over at once when its NodePool is
+// dark, or after some of its pods are unschedulable for the pending timeout.
+// A pool failed over for the dark NodePool recovers once the NodePool has
+// ready nodes, and its replicas move back after the hold-down time. A pool
+// failed over for the pending pods only moves the unschedulable replicas, and
+// moves them back after the hold-down time to retry, as its capacity can't be
+// observed without the replicas. The pool is then watched for the hold-down
+// time, and the hold-down time doubles each time the pods are pending again
+func planFailover(policy *unitv1alpha1.UnitedDeploymentFailoverPolicy, oldFailovers []unitv1alpha1.UnitedDeploymentPoolFailover,
+	pools []unitv1alpha1.Pool, nameToPool map[string]*Pool, nodePools []unitv1alpha1.NodePool,
+	now metav1.Time) ([]unitv1alpha1.UnitedDeploymentPoolFailover, time.Duration) {
+	pendingTimeout := time.Duration(unitv1alpha1.DefaultFailoverPendingTimeoutSeconds) * time.Second
+	if policy.PendingTimeoutSeconds != nil {
+		pendingTimeout = time.Duration(*policy.PendingTimeoutSeconds) * time.Second
+	}
+	holdDown := time.Duration(unitv1alpha1.DefaultFailoverHoldDownSeconds) * time.Second
+	if policy.HoldDownSeconds != nil {
+		holdDown = time.Duration(*policy.HoldDownSeconds) * time.Second
+	}
+
+	oldByName := make(map[string]unitv1alpha1.UnitedDeploymentPoolFailover, len(oldFailovers))
+	for _, failover := range oldFailovers {
+		oldByName[failover.Name] = failover
+	}
+
+	var failovers []unitv1alpha1.UnitedDeploymentPoolFailover
+	var requeueAfter time.Duration
+	// wait returns if the duration has elapsed since the transition of the
+	// pool, and records the time left to requeue otherwise
+	wait := func(failover unitv1alpha1.UnitedDeploymentPoolFailover, d time.Duration) bool {
+		left := d - now.Sub(failover.LastTransitionTime.Time)
+		if left <= 0 {
+			return true
+		}
+		if requeueAfter == 0 || left < requeueAfter {
+			requeueAfter = left
+		}
+		return false
+	}
+	transit := func(name string, phase unitv1alpha1.PoolFailoverPhase, reason unitv1alpha1.PoolFailoverReason) {
+		failovers = append(failovers, unitv1alpha1.UnitedDeploymentPoolFailover{
+			Name: name, Phase: phase, Reason: reason, LastTransitionTime: now,
+		})
+	}
+	// transitPending moves the pool failing over for the pending pods, or
+	// moving its replicas back, to the phase and keeps its retries
+	transitPending := func(name string, phase unitv1alpha1.PoolFailoverPhase, replicas, retries int32) {
+		transit(name, phase, unitv1alpha1.PoolPodsPending)
+		failovers[len(failovers)-1].Replicas = replicas
+		failovers[len(failovers)-1].Retries = retries
+	}
+
+	for i := range pools {
+		pool := &pools[i]
+		dark := isNodePoolDark(poolNodePool(pool), nodePools)
+		unschedulable := unschedulableReplicas(nameToPool[pool.Name])
+		old, exist := oldByName[pool.Name]
+		switch {
+		case dark && (!exist || old.Phase != unitv1alpha1.PoolFailedOver || old.Reason != unitv1alpha1.PoolNodesNotReady):
+			transit(pool.Name, unitv1alpha1.PoolFailedOver, unitv1alpha1.PoolNodesNotReady)
+		case dark:
+			failovers = append(failovers, old)
+		case !exist:
+			if unschedulable > 0 {
+				transit(pool.Name, unitv1alpha1.PoolUnavailable, unitv1alpha1.PoolPodsPending)
+				wait(failovers[len(failovers)-1], pendingTimeout)
+			}
+		case old.Phase == unitv1alpha1.PoolUnavailable:
+			switch {
+			case unschedulable > 0 && wait(old, pendingTimeout):
+				transitPending(pool.Name, unitv1alpha1.PoolFailedOver, unschedulable, old.Retries)
+				wait(failovers[len(failovers)-1], pendingHoldDown(holdDown, old.Retries))
+			case unschedulable > 0:
+				failovers = append(failovers, old)
+			case old.Retries > 0 && !wait(old, holdDown):
+				// the replicas moved back are watched for the hold-down time
+				failovers = append(failovers, old)
+			}
+		case old.Phase == unitv1alpha1.PoolFailedOver && old.Reason == unitv1alpha1.PoolNodesNotReady:
+			transit(pool.Name, unitv1alpha1.PoolRecovering, unitv1alpha1.PoolNodesNotReady)
+			wait(failovers[len(failovers)-1], holdDown)
+		case old.Phase == unitv1alpha1.PoolFailedOver:
+			// the pool failed over for the pending pods retries after the
+			// hold-down time
+			if wait(old, pendingHoldDown(holdDown, old.Retries)) {
+				transitPending(pool.Name, unitv1alpha1.PoolUnavailable, 0, old.Retries+1)
+				wait(failovers[len(failovers)-1], holdDown)
+			} else {
+				failovers = append(failovers, old)
+			}
+		default:
+			// the pools recovering from the dark NodePool
+			if !wait(old, holdDown) {
+				failovers = append(failovers, old)
+			}
+		}
+	}
+	return failovers, requeueAfter
+}
+
+// failedPools returns the names of the pools whose replicas are moved to the
+// fallback pools, i.e. the pools failed over or recovering
+func failedPools(failovers []unitv1alpha1.UnitedDeploymentPoolFailover) sets.String {
+	failed := sets.NewString()
+	for _, failover := range failovers {
+		if failover.Phase == unitv1alpha1.PoolFailedOver || failover.Phase == unitv1alpha1.PoolRecovering {
+			failed.Insert(failover.Name)
+		}
+	}
+	return failed
+}
+
+// fallbackTargets returns the sorted fallback pools that are not failed, a
+// fallback pool naming a parent NodePool covers its descendant pools
+func fallbackTargets(policy *unitv1alpha1.UnitedDeploymentFailoverPolicy, nextPatches map[string]UnitedDeploymentPatches,
+	failed sets.String, nodePools []unitv1alpha1.NodePool) []string {
+	var targets []string
+	for _, name := range nodepoolutil.ExpandNodePools(policy.FallbackPools, nodePools) {
+		if _, ok := nextPatches[name]; ok && !failed.Has(name) {
+			targets = append(targets, name)
+		}
+	}
+	return targets
+}
+
+// applyFailover moves the replicas of the failed pools to the fallback pools,
+// the pools failed over for the pending pods only move their pending replicas.
+// The replicas are split evenly and the first fallback pools take the rest.
+// It returns the fallback pools that take the replicas, the replicas are not
+// moved if all the fallback pools are failed
+func applyFailover(nextPatches map[string]UnitedDeploymentPatches, failovers []unitv1alpha1.UnitedDeploymentPoolFailover,
+	policy *unitv1alpha1.UnitedDeploymentFailoverPolicy, nodePools []unitv1alpha1.NodePool) []string {
+	failed := failedPools(failovers)
+	targets := fallbackTargets(policy, nextPatches, failed, nodePools)
+	if len(targets) == 0 {
+		return nil
+	}
+
+	var moved int32
+	for _, failover := range failovers {
+		patches, ok := nextPatches[failover.Name]
+		if !ok || !failed.Has(failover.Name) {
+			continue
+		}
+		replicas := patches.Replicas
+		if failover.Reason == unitv1alpha1.PoolPodsPending && failover.Replicas < replicas {
+			replicas = failover.Replicas
+		}
+		moved += replicas
+		patches.Replicas -= replicas
+		nextPatches[failover.Name] = patches
+	}
+	for i, name := range targets {
+		patches := nextPatches[name]
+		patches.Replicas += moved / int32(len(targets))
+		if int32(i) < moved%int32(len(targets)) {
+			patches.Replicas++
+		}
+		nextPatches[name] = patches
+	}
+	return targets
+}
+
+// recordFailoverEvents records the events of the pools that fail over or
+// move their replicas back
+func (r *ReconcileUnitedDeployment) recordFailoverEvents(ud *unitv1alpha1.UnitedDeployment,
+	oldFailovers, failovers []unitv1alpha1.UnitedDeploymentPoolFailover, targets []string) {
+	oldFailed, failed := failedPools(oldFailovers), failedPools(failovers)
+	for _, failover := range failovers {
+		if failover.Phase == unitv1alpha1.PoolFailedOver && !oldFailed.Has(failover.Name) {
+			r.recorder.Event(ud.DeepCopy(), corev1.EventTypeWarning, eventTypePoolFailover,
+				fmt.Sprintf("Pool %s fails over for %s, its replicas are moved to %v", failover.Name, failover.Reason, targets))
+		}
+	}
+	for _, name := range oldFailed.Difference(failed).List() {
+		r.recorder.Event(ud.DeepCopy(), corev1.EventTypeNormal, eventTypePoolRecover,
+			fmt.Sprintf("Pool %s has recovered, its replicas are moved back", name))
+	}
+}
@@ -0,0 +1,307 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/uniteddeployment/adapter"
+)
+
+func TestPlanFailover(t *testing.T) {
+	pendingTimeout, holdDown := int32(60), int32(120)
+	policy := &unitv1alpha1.UnitedDeploymentFailoverPolicy{
+		FallbackPools:         []string{"cloud"},
+		PendingTimeoutSeconds: &pendingTimeout,
+		HoldDownSeconds:       &holdDown,
+	}
+	pools := []unitv1alpha1.Pool{
+		{Name: "edge", NodeSelectorTerm: nodePoolTerm("hangzhou")},
+		{Name: "cloud", NodeSelectorTerm: nodePoolTerm("cloud")},
+	}
+	now := metav1.Now()
+	ago := func(seconds int) metav1.Time {
+		return metav1.NewTime(now.Add(-time.Duration(seconds) * time.Second))
+	}
+	failover := func(phase unitv1alpha1.PoolFailoverPhase, reason unitv1alpha1.PoolFailoverReason,
+		since metav1.Time) []unitv1alpha1.UnitedDeploymentPoolFailover {
+		return []unitv1alpha1.UnitedDeploymentPoolFailover{
+			{Name: "edge", Phase: phase, Reason: reason, LastTransitionTime: since},
+		}
+	}
+	pending := func(phase unitv1alpha1.PoolFailoverPhase, since metav1.Time,
+		replicas, retries int32) []unitv1alpha1.UnitedDeploymentPoolFailover {
+		return []unitv1alpha1.UnitedDeploymentPoolFailover{{
+			Name: "edge", Phase: phase, Reason: unitv1alpha1.PoolPodsPending, LastTransitionTime: since,
+			Replicas: replicas, Retries: retries,
+		}}
+	}
+	tests := []struct {
+		name          string
+		old           []unitv1alpha1.UnitedDeploymentPoolFailover
+		dark          bool
+		unschedulable int32
+		expect        []unitv1alpha1.UnitedDeploymentPoolFailover
+		expectRequeue time.Duration
+	}{
+		{
+			"healthy pool",
+			nil, false, 0,
+			nil, 0,
+		},
+		{
+			"dark nodepool fails over at once",
+			nil, true, 0,
+			failover(unitv1alpha1.PoolFailedOver, unitv1alpha1.PoolNodesNotReady, now), 0,
+		},
+		{
+			"pool with some unschedulable pods is unavailable",
+			nil, false, 1,
+			failover(unitv1alpha1.PoolUnavailable, unitv1alpha1.PoolPodsPending, now), 60 * time.Second,
+		},
+		{
+			"unavailable pool waits for the pending timeout",
+			failover(unitv1alpha1.PoolUnavailable, unitv1alpha1.PoolPodsPending, ago(20)), false, 1,
+			failover(unitv1alpha1.PoolUnavailable, unitv1alpha1.PoolPodsPending, ago(20)), 40 * time.Second,
+		},
+		{
+			"unavailable pool fails over its unschedulable replicas after the pending timeout",
+			failover(unitv1alpha1.PoolUnavailable, unitv1alpha1.PoolPodsPending, ago(60)), false, 1,
+			pending(unitv1alpha1.PoolFailedOver, now, 1, 0), 120 * time.Second,
+		},
+		{
+			"unavailable pool with scheduled pods is available again",
+			failover(unitv1alpha1.PoolUnavailable, unitv1alpha1.PoolPodsPending, ago(20)), false, 0,
+			nil, 0,
+		},
+		{
+			"dark nodepool stays failed over",
+			failover(unitv1alpha1.PoolFailedOver, unitv1alpha1.PoolNodesNotReady, ago(600)), true, 0,
+			failover(unitv1alpha1.PoolFailedOver, unitv1alpha1.PoolNodesNotReady, ago(600)), 0,
+		},
+		{
+			"nodepool with ready nodes recovers",
+			failover(unitv1alpha1.PoolFailedOver, unitv1alpha1.PoolNodesNotReady, ago(600)), false, 0,
+			failover(unitv1alpha1.PoolRecovering, unitv1alpha1.PoolNodesNotReady, now), 120 * time.Second,
+		},
+		{
+			"recovering pool waits for the hold-down time",
+			failover(unitv1alpha1.PoolRecovering, unitv1alpha1.PoolNodesNotReady, ago(20)), false, 0,
+			failover(unitv1alpha1.PoolRecovering, unitv1alpha1.PoolNodesNotReady, ago(20)), 100 * time.Second,
+		},
+		{
+			"recovering pool moves back after the hold-down time",
+			failover(unitv1alpha1.PoolRecovering, unitv1alpha1.PoolNodesNotReady, ago(120)), false, 0,
+			nil, 0,
+		},
+		{
+			"recovering pool fails over again once the nodepool is dark",
+			failover(unitv1alpha1.PoolRecovering, unitv1alpha1.PoolNodesNotReady, ago(20)), true, 0,
+			failover(unitv1alpha1.PoolFailedOver, unitv1alpha1.PoolNodesNotReady, now), 0,
+		},
+		{
+			"pool failed over for pending pods waits for the hold-down time",
+			pending(unitv1alpha1.PoolFailedOver, ago(20), 1, 0), false, 0,
+			pending(unitv1alpha1.PoolFailedOver, ago(20), 1, 0), 100 * time.Second,
+		},
+		{
+			"pool failed over for pending pods retries after the hold-down time",
+			pending(unitv1alpha1.PoolFailedOver, ago(120), 1, 0), false, 0,
+			pending(unitv1alpha1.PoolUnavailable, now, 0, 1), 120 * time.Second,
+		},
+		{
+			"hold-down time doubles with the retries",
+			pending(unitv1alpha1.PoolFailedOver, ago(120), 1, 1), false, 0,
+			pending(unitv1alpha1.PoolFailedOver, ago(120), 1, 1), 120 * time.Second,
+		},
+		{
+			"replicas moved back are watched for the hold-down time",
+			pending(unitv1alpha1.PoolUnavailable, ago(20), 0, 1), false, 0,
+			pending(unitv1alpha1.PoolUnavailable, ago(20), 0, 1), 100 * time.Second,
+		},
+		{
+			"replicas moved back pending again fail over with the retries kept",
+			pending(unitv1alpha1.PoolUnavailable, ago(60), 0, 1), false, 2,
+			pending(unitv1alpha1.PoolFailedOver, now, 2, 1), 240 * time.Second,
+		},
+		{
+			"replicas moved back and scheduled for the hold-down time are available again",
+			pending(unitv1alpha1.PoolUnavailable, ago(120), 0, 1), false, 0,
+			nil, 0,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			nodePools := []unitv1alpha1.NodePool{
+				{ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"}, Status: unitv1alpha1.NodePoolStatus{ReadyNodeNum: 2}},
+				{ObjectMeta: metav1.ObjectMeta{Name: "cloud"}, Status: unitv1alpha1.NodePoolStatus{ReadyNodeNum: 2}},
+			}
+			if st.dark {
+				nodePools[0].Status = unitv1alpha1.NodePoolStatus{UnreadyNodeNum: 2}
+			}
+			// the ready pods show that the pool with unschedulable pods has
+			// capacity left for part of its replicas
+			edge := &Pool{Name: "edge", Status: PoolStatus{ReplicasInfo: adapter.ReplicasInfo{
+				Replicas: 2 + st.unschedulable, ReadyReplicas: 2, UnschedulableReplicas: st.unschedulable,
+			}}}
+			nameToPool := map[string]*Pool{"edge": edge}
+
+			get, requeue := planFailover(policy, st.old, pools, nameToPool, nodePools, now)
+			if !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect failovers %v, but get %v", st.expect, get)
+			}
+			if requeue != st.expectRequeue {
+				t.Errorf("expect requeue after %v, but get %v", st.expectRequeue, requeue)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestPendingHoldDown(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int32
+		expect  time.Duration
+	}{
+		{"first failover", 0, 2 * time.Minute},
+		{"first retry", 1, 4 * time.Minute},
+		{"last doubled retry", 5, 64 * time.Minute},
+		{"retries beyond the cap", 9, 64 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if get := pendingHoldDown(2*time.Minute, st.retries); get != st.expect {
+				t.Errorf("expect hold-down %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestApplyFailover(t *testing.T) {
+	nodePools := []unitv1alpha1.NodePool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "cloud"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cloud-a"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "cloud"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "cloud-b"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "cloud"}},
+	}
+	failed := func(names ...string) []unitv1alpha1.UnitedDeploymentPoolFailover {
+		var failovers []unitv1alpha1.UnitedDeploymentPoolFailover
+		for _, name := range names {
+			failovers = append(failovers, unitv1alpha1.UnitedDeploymentPoolFailover{
+				Name: name, Phase: unitv1alpha1.PoolFailedOver, Reason: unitv1alpha1.PoolNodesNotReady,
+			})
+		}
+		return failovers
+	}
+	tests := []struct {
+		name          string
+		fallbackPools []string
+		failovers     []unitv1alpha1.UnitedDeploymentPoolFailover
+		expect        map[string]int32
+		expectTargets []string
+	}{
+		{
+			"no failed pool",
+			[]string{"cloud-a"},
+			nil,
+			map[string]int32{"edge-a": 3, "edge-b": 2, "cloud-a": 1, "cloud-b": 1},
+			[]string{"cloud-a"},
+		},
+		{
+			"replicas move to the fallback pool",
+			[]string{"cloud-a"},
+			failed("edge-a"),
+			map[string]int32{"edge-a": 0, "edge-b": 2, "cloud-a": 4, "cloud-b": 1},
+			[]string{"cloud-a"},
+		},
+		{
+			"pool failed over for pending pods moves its pending replicas",
+			[]string{"cloud-a"},
+			[]unitv1alpha1.UnitedDeploymentPoolFailover{{
+				Name: "edge-a", Phase: unitv1alpha1.PoolFailedOver, Reason: unitv1alpha1.PoolPodsPending, Replicas: 2,
+			}},
+			map[string]int32{"edge-a": 1, "edge-b": 2, "cloud-a": 3, "cloud-b": 1},
+			[]string{"cloud-a"},
+		},
+		{
+			"pending replicas are capped by the replicas of the pool",
+			[]string{"cloud-a"},
+			[]unitv1alpha1.UnitedDeploymentPoolFailover{{
+				Name: "edge-a", Phase: unitv1alpha1.PoolFailedOver, Reason: unitv1alpha1.PoolPodsPending, Replicas: 5,
+			}},
+			map[string]int32{"edge-a": 0, "edge-b": 2, "cloud-a": 4, "cloud-b": 1},
+			[]string{"cloud-a"},
+		},
+		{
+			"parent nodepool covers its descendants",
+			[]string{"cloud"},
+			failed("edge-a", "edge-b"),
+			map[string]int32{"edge-a": 0, "edge-b": 0, "cloud-a": 4, "cloud-b": 3},
+			[]string{"cloud-a", "cloud-b"},
+		},
+		{
+			"failed fallback pools are skipped",
+			[]string{"cloud"},
+			failed("edge-a", "cloud-a"),
+			map[string]int32{"edge-a": 0, "edge-b": 2, "cloud-a": 0, "cloud-b": 5},
+			[]string{"cloud-b"},
+		},
+		{
+			"replicas stay without healthy fallback pool",
+			[]string{"cloud-a"},
+			failed("edge-a", "cloud-a"),
+			map[string]int32{"edge-a": 3, "edge-b": 2, "cloud-a": 1, "cloud-b": 1},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			nextPatches := map[string]UnitedDeploymentPatches{
+				"edge-a": {Replicas: 3}, "edge-b": {Replicas: 2}, "cloud-a": {Replicas: 1}, "cloud-b": {Replicas: 1},
+			}
+			policy := &unitv1alpha1.UnitedDeploymentFailoverPolicy{FallbackPools: st.fallbackPools}
+			targets := applyFailover(nextPatches, st.failovers, policy, nodePools)
+			if !reflect.DeepEqual(targets, st.expectTargets) {
+				t.Errorf("expect fallback pools %v, but get %v", st.expectTargets, targets)
+			}
+			for name, replicas := range st.expect {
+				if nextPatches[name].Replicas != replicas {
+					t.Errorf("expect pool %s to have %d replicas, but get %d", name, replicas, nextPatches[name].Replicas)
+				}
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
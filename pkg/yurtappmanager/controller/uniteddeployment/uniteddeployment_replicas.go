@@ -0,0 +1,248 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"math"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// poolShare is the share of a pool in the replicas of the UnitedDeployment
+type poolShare struct {
+	name     string
+	value    float64
+	min, max *int32
+	pinned   bool
+}
+
+// DistributeReplicas returns the replicas of each pool. If the replicas of
+// the UnitedDeployment is unspecified, every pool runs its own replicas.
+// Otherwise the pools with fixed replicas take their count first, the pools
+// with a percentage take their share of the total replicas, and the replicas
+// left are split by the weight of the other pools. The replicas of a pool are
+// kept within its minReplicas and maxReplicas, the replicas beyond the bounds
+// are given to the other pools. If the capacities are specified, they replace
+// the weight of the pools, and the pools are weighed evenly if no capacity is
+// reported at all
+func DistributeReplicas(replicas *int32, pools []unitv1alpha1.Pool, capacities map[string]float64) map[string]int32 {
+	result := make(map[string]int32, len(pools))
+	if replicas == nil {
+		for _, pool := range pools {
+			result[pool.Name] = 0
+			if pool.Replicas != nil {
+				result[pool.Name] = *pool.Replicas
+			}
+		}
+		return result
+	}
+
+	remaining := *replicas
+	var percentages, weighted []*poolShare
+	var weightSum float64
+	for _, pool := range pools {
+		if pool.Replicas != nil {
+			result[pool.Name] = *pool.Replicas
+			remaining -= *pool.Replicas
+			continue
+		}
+		share := &poolShare{name: pool.Name, value: 1, min: pool.MinReplicas, max: pool.MaxReplicas}
+		if pool.Weight != nil && pool.Weight.Type == intstr.String {
+			percent, _ := intstr.GetValueFromIntOrPercent(pool.Weight, 100, false)
+			share.value = float64(*replicas) * float64(percent) / 100
+			percentages = append(percentages, share)
+			continue
+		}
+		if capacities != nil {
+			share.value = capacities[pool.Name]
+		} else if pool.Weight != nil {
+			share.value = float64(pool.Weight.IntValue())
+		}
+		weightSum += share.value
+		weighted = append(weighted, share)
+	}
+	if capacities != nil && weightSum == 0 {
+		for _, share := range weighted {
+			share.value = 1
+		}
+		weightSum = float64(len(weighted))
+	}
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	// the percentage pools are scaled down if the fixed pools leave less
+	// replicas, and the weighted pools split the rest
+	var percentSum float64
+	for _, share := range percentages {
+		percentSum += share.value
+	}
+	if percentSum > float64(remaining) {
+		for _, share := range percentages {
+			share.value *= float64(remaining) / percentSum
+		}
+		percentSum = float64(remaining)
+	}
+	target := int32(math.Floor(percentSum + 1e-9))
+	if len(weighted) != 0 && weightSum > 0 {
+		for _, share := range weighted {
+			share.value *= (float64(remaining) - percentSum) / weightSum
+		}
+		target = remaining
+	} else {
+		for _, share := range weighted {
+			share.value = 0
+		}
+	}
+
+	shares := append(percentages, weighted...)
+	applyReplicasBounds(shares, target)
+	for name, value := range roundShares(shares, target) {
+		result[name] = value
+	}
+	return result
+}
+
+// PoolCapacities returns the capacity of each pool for the capacity modes of
+// the distribution, or nil if the replicas are split by the weight of the
+// pools. The capacity of a pool is the one of the NodePool selected by its
+// nodeSelectorTerm, the pools without NodePool have no capacity
+func PoolCapacities(distribution *unitv1alpha1.UnitedDeploymentReplicasDistribution,
+	pools []unitv1alpha1.Pool, nodePools []unitv1alpha1.NodePool) map[string]float64 {
+	if !isCapacityDistribution(distribution) {
+		return nil
+	}
+
+	resource := distribution.Resource
+	if resource == "" {
+		resource = corev1.ResourceCPU
+	}
+	nameToNodePool := make(map[string]*unitv1alpha1.NodePool, len(nodePools))
+	for i := range nodePools {
+		nameToNodePool[nodePools[i].Name] = &nodePools[i]
+	}
+	capacities := make(map[string]float64, len(pools))
+	for i := range pools {
+		np, ok := nameToNodePool[poolNodePool(&pools[i])]
+		if !ok {
+			continue
+		}
+		switch distribution.Mode {
+		case unitv1alpha1.ReadyNodesReplicasDistribution:
+			capacities[pools[i].Name] = float64(np.Status.ReadyNodeNum)
+		case unitv1alpha1.AllocatableReplicasDistribution:
+			if q, ok := np.Status.Allocatable[resource]; ok {
+				capacities[pools[i].Name] = float64(q.MilliValue())
+			}
+		}
+	}
+	return capacities
+}
+
+// poolsWithoutNodePool returns the names of the pools whose NodePool is not
+// found, they have no capacity for the capacity modes of the distribution
+func poolsWithoutNodePool(pools []unitv1alpha1.Pool, nodePools []unitv1alpha1.NodePool) []string {
+	names := sets.NewString()
+	for _, np := range nodePools {
+		names.Insert(np.Name)
+	}
+	var missing []string
+	for i := range pools {
+		if np := poolNodePool(&pools[i]); np == "" || !names.Has(np) {
+			missing = append(missing, pools[i].Name)
+		}
+	}
+	return missing
+}
+
+// isCapacityDistribution checks if the replicas are split by the capacity of
+// the NodePools
+func isCapacityDistribution(distribution *unitv1alpha1.UnitedDeploymentReplicasDistribution) bool {
+	return distribution != nil && (distribution.Mode == unitv1alpha1.ReadyNodesReplicasDistribution ||
+		distribution.Mode == unitv1alpha1.AllocatableReplicasDistribution)
+}
+
+// applyReplicasBounds pins the shares beyond the bounds of the pools, and
+// scales the other shares to keep the sum of the shares at the target
+func applyReplicasBounds(shares []*poolShare, target int32) {
+	for {
+		budget, freeSum := float64(target), 0.0
+		for _, share := range shares {
+			if share.pinned {
+				budget -= share.value
+			} else {
+				freeSum += share.value
+			}
+		}
+		if budget < 0 {
+			budget = 0
+		}
+
+		var changed bool
+		for _, share := range shares {
+			if share.pinned {
+				continue
+			}
+			if freeSum > 0 {
+				share.value *= budget / freeSum
+			}
+			if share.min != nil && share.value < float64(*share.min) {
+				share.value, share.pinned, changed = float64(*share.min), true, true
+			} else if share.max != nil && share.value > float64(*share.max) {
+				share.value, share.pinned, changed = float64(*share.max), true, true
+			}
+		}
+		if !changed {
+			return
+		}
+	}
+}
+
+// roundShares rounds the shares down, and gives the replicas left to the
+// shares with the largest fractions below their maxReplicas, the shares are
+// kept in order on ties
+func roundShares(shares []*poolShare, target int32) map[string]int32 {
+	result := make(map[string]int32, len(shares))
+	left := target
+	for _, share := range shares {
+		result[share.name] = int32(math.Floor(share.value + 1e-9))
+		left -= result[share.name]
+	}
+
+	var free []*poolShare
+	for _, share := range shares {
+		if !share.pinned {
+			free = append(free, share)
+		}
+	}
+	sort.SliceStable(free, func(i, j int) bool {
+		return free[i].value-math.Floor(free[i].value+1e-9) > free[j].value-math.Floor(free[j].value+1e-9)
+	})
+	for i := 0; left > 0 && i < len(free); i++ {
+		if free[i].max != nil && result[free[i].name] >= *free[i].max {
+			continue
+		}
+		result[free[i].name]++
+		left--
+	}
+	return result
+}
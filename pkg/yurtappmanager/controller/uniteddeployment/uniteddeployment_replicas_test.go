@@ -0,0 +1,239 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func int32Ptr(i int32) *int32 {
+	return &i
+}
+
+func weightPtr(weight intstr.IntOrString) *intstr.IntOrString {
+	return &weight
+}
+
+func TestDistributeReplicas(t *testing.T) {
+	tests := []struct {
+		name       string
+		replicas   *int32
+		pools      []unitv1alpha1.Pool
+		capacities map[string]float64
+		expect     map[string]int32
+	}{
+		{
+			"pools run their own replicas",
+			nil,
+			[]unitv1alpha1.Pool{{Name: "a", Replicas: int32Ptr(3)}, {Name: "b"}},
+			nil,
+			map[string]int32{"a": 3, "b": 0},
+		},
+		{
+			"even split by default",
+			int32Ptr(10),
+			[]unitv1alpha1.Pool{{Name: "a"}, {Name: "b"}, {Name: "c"}},
+			nil,
+			map[string]int32{"a": 4, "b": 3, "c": 3},
+		},
+		{
+			"fixed pools take their count first",
+			int32Ptr(10),
+			[]unitv1alpha1.Pool{
+				{Name: "a", Replicas: int32Ptr(2)},
+				{Name: "b", Weight: weightPtr(intstr.FromInt(3))},
+				{Name: "c", Weight: weightPtr(intstr.FromInt(1))},
+			},
+			nil,
+			map[string]int32{"a": 2, "b": 6, "c": 2},
+		},
+		{
+			"percentages",
+			int32Ptr(10),
+			[]unitv1alpha1.Pool{
+				{Name: "a", Weight: weightPtr(intstr.FromString("30%"))},
+				{Name: "b", Weight: weightPtr(intstr.FromString("70%"))},
+			},
+			nil,
+			map[string]int32{"a": 3, "b": 7},
+		},
+		{
+			"weighted pools split the replicas left by percentages",
+			int32Ptr(10),
+			[]unitv1alpha1.Pool{
+				{Name: "a", Weight: weightPtr(intstr.FromString("50%"))},
+				{Name: "b"},
+				{Name: "c"},
+			},
+			nil,
+			map[string]int32{"a": 5, "b": 3, "c": 2},
+		},
+		{
+			"replicas beyond max are given to other pools",
+			int32Ptr(10),
+			[]unitv1alpha1.Pool{{Name: "a", MaxReplicas: int32Ptr(2)}, {Name: "b"}, {Name: "c"}},
+			nil,
+			map[string]int32{"a": 2, "b": 4, "c": 4},
+		},
+		{
+			"min replicas are taken from other pools",
+			int32Ptr(4),
+			[]unitv1alpha1.Pool{
+				{Name: "a", MinReplicas: int32Ptr(3)},
+				{Name: "b", Weight: weightPtr(intstr.FromInt(3))},
+			},
+			nil,
+			map[string]int32{"a": 3, "b": 1},
+		},
+		{
+			"fixed pools exceed the replicas",
+			int32Ptr(2),
+			[]unitv1alpha1.Pool{{Name: "a", Replicas: int32Ptr(3)}, {Name: "b"}},
+			nil,
+			map[string]int32{"a": 3, "b": 0},
+		},
+		{
+			"percentages are scaled down to the replicas left",
+			int32Ptr(10),
+			[]unitv1alpha1.Pool{
+				{Name: "a", Replicas: int32Ptr(6)},
+				{Name: "b", Weight: weightPtr(intstr.FromString("50%"))},
+				{Name: "c", Weight: weightPtr(intstr.FromString("50%"))},
+			},
+			nil,
+			map[string]int32{"a": 6, "b": 2, "c": 2},
+		},
+		{
+			"capacities replace the weight",
+			int32Ptr(10),
+			[]unitv1alpha1.Pool{
+				{Name: "a", Replicas: int32Ptr(1)},
+				{Name: "b"},
+				{Name: "c"},
+				{Name: "d"},
+			},
+			map[string]float64{"b": 4, "c": 2},
+			map[string]int32{"a": 1, "b": 6, "c": 3, "d": 0},
+		},
+		{
+			"pools are weighed evenly without capacity",
+			int32Ptr(4),
+			[]unitv1alpha1.Pool{{Name: "a"}, {Name: "b"}},
+			map[string]float64{},
+			map[string]int32{"a": 2, "b": 2},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if get := DistributeReplicas(st.replicas, st.pools, st.capacities); !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect replicas %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestPoolCapacities(t *testing.T) {
+	nodePools := []unitv1alpha1.NodePool{
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "a"},
+			Status: unitv1alpha1.NodePoolStatus{
+				ReadyNodeNum: 3,
+				Allocatable:  corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1500m")},
+			},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "b"},
+			Status: unitv1alpha1.NodePoolStatus{
+				ReadyNodeNum: 1,
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:    resource.MustParse("4"),
+					corev1.ResourceMemory: resource.MustParse("1Ki"),
+				},
+			},
+		},
+	}
+	pools := []unitv1alpha1.Pool{
+		{Name: "pool-a", NodeSelectorTerm: nodePoolTerm("a")},
+		{Name: "pool-b", NodeSelectorTerm: nodePoolTerm("b")},
+		{Name: "pool-c", NodeSelectorTerm: nodePoolTerm("c")},
+		{Name: "a"},
+	}
+	tests := []struct {
+		name         string
+		distribution *unitv1alpha1.UnitedDeploymentReplicasDistribution
+		expect       map[string]float64
+	}{
+		{
+			"no distribution",
+			nil,
+			nil,
+		},
+		{
+			"weight mode",
+			&unitv1alpha1.UnitedDeploymentReplicasDistribution{Mode: unitv1alpha1.WeightReplicasDistribution},
+			nil,
+		},
+		{
+			"ready nodes mode",
+			&unitv1alpha1.UnitedDeploymentReplicasDistribution{Mode: unitv1alpha1.ReadyNodesReplicasDistribution},
+			map[string]float64{"pool-a": 3, "pool-b": 1},
+		},
+		{
+			"allocatable cpu by default",
+			&unitv1alpha1.UnitedDeploymentReplicasDistribution{Mode: unitv1alpha1.AllocatableReplicasDistribution},
+			map[string]float64{"pool-a": 1500, "pool-b": 4000},
+		},
+		{
+			"allocatable resource",
+			&unitv1alpha1.UnitedDeploymentReplicasDistribution{
+				Mode:     unitv1alpha1.AllocatableReplicasDistribution,
+				Resource: corev1.ResourceMemory,
+			},
+			map[string]float64{"pool-b": 1024000},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if get := PoolCapacities(st.distribution, pools, nodePools); !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect capacities %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+
+	expectMissing := []string{"pool-c", "a"}
+	if get := poolsWithoutNodePool(pools, nodePools); !reflect.DeepEqual(get, expectMissing) {
+		t.Errorf("expect pools without nodepool %v, but get %v", expectMissing, get)
+	}
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+// poolRolloutState is the state of an existing pool in the staged rollout
+type poolRolloutState struct {
+	// updated indicates the pool is at the updated revision
+	updated bool
+	// ready indicates all replicas of the pool are ready at the updated revision
+	ready bool
+}
+
+// getPoolRolloutState returns the rollout state of the pool for the revision
+func getPoolRolloutState(pool *Pool, revision string) poolRolloutState {
+	updated := pool.Spec.PoolRef.GetLabels()[unitv1alpha1.ControllerRevisionHashLabelKey] == revision
+	return poolRolloutState{
+		updated: updated,
+		ready: updated && pool.Status.ObservedGeneration >= pool.Spec.PoolRef.GetGeneration() &&
+			pool.Status.UpdatedReadyReplicas >= pool.Status.Replicas,
+	}
+}
+
+// rolloutBatches groups the pools into the batches of the strategy, a batch
+// naming a parent NodePool covers its descendant pools, and a pool is only
+// updated in the first batch covering it. The pools that are not covered make
+// up the last batch
+func rolloutBatches(strategy *unitv1alpha1.UnitedDeploymentUpdateStrategy, pools []string,
+	nodePools []unitv1alpha1.NodePool) [][]string {
+	remaining := sets.NewString(pools...)
+	var batches [][]string
+	for _, batch := range strategy.Batches {
+		var names []string
+		for _, name := range nodepoolutil.ExpandNodePools(batch.Pools, nodePools) {
+			if remaining.Has(name) {
+				remaining.Delete(name)
+				names = append(names, name)
+			}
+		}
+		batches = append(batches, names)
+	}
+	if remaining.Len() != 0 {
+		batches = append(batches, remaining.List())
+	}
+	return batches
+}
+
+// planRollout returns the pools allowed to be updated to the revision, the
+// progress of the rollout and the time to wait before the next batch starts.
+// The pools already at the revision are always allowed, and the pools of the
+// current batch, i.e. the first batch not ready, are allowed as long as the
+// rollout is not paused, the interval since the last batch is elapsed, and
+// the number of updated pools not ready is below maxUnavailablePools
+func planRollout(strategy *unitv1alpha1.UnitedDeploymentUpdateStrategy, oldStatus *unitv1alpha1.UnitedDeploymentUpdateStatus,
+	revision string, batches [][]string, states map[string]poolRolloutState,
+	now metav1.Time) (sets.String, *unitv1alpha1.UnitedDeploymentUpdateStatus, time.Duration) {
+	status := &unitv1alpha1.UnitedDeploymentUpdateStatus{
+		UpdatedRevision: revision,
+		Paused:          strategy.Paused,
+	}
+	var prevBatch int32
+	if oldStatus != nil && oldStatus.UpdatedRevision == revision {
+		prevBatch = oldStatus.CurrentBatch
+		status.LastBatchReadyTime = oldStatus.LastBatchReadyTime
+	}
+
+	allowed := sets.NewString()
+	var unavailable int32
+	for name, state := range states {
+		if state.updated {
+			allowed.Insert(name)
+			status.UpdatedPools = append(status.UpdatedPools, name)
+			if state.ready {
+				status.ReadyPools = append(status.ReadyPools, name)
+			} else {
+				unavailable++
+			}
+		}
+	}
+	status.UpdatedPools = sets.NewString(status.UpdatedPools...).List()
+	status.ReadyPools = sets.NewString(status.ReadyPools...).List()
+
+	status.CurrentBatch = int32(len(batches))
+	for i, batch := range batches {
+		var notReady bool
+		for _, name := range batch {
+			if !states[name].ready {
+				notReady = true
+				break
+			}
+		}
+		if notReady {
+			status.CurrentBatch = int32(i)
+			break
+		}
+	}
+	if status.CurrentBatch > prevBatch {
+		status.LastBatchReadyTime = &now
+	}
+
+	if status.CurrentBatch == int32(len(batches)) || strategy.Paused {
+		return allowed, status, 0
+	}
+	if status.CurrentBatch > 0 && status.LastBatchReadyTime != nil && strategy.BatchIntervalSeconds > 0 {
+		interval := time.Duration(strategy.BatchIntervalSeconds) * time.Second
+		if elapsed := now.Sub(status.LastBatchReadyTime.Time); elapsed < interval {
+			return allowed, status, interval - elapsed
+		}
+	}
+
+	for _, name := range batches[status.CurrentBatch] {
+		if states[name].updated {
+			continue
+		}
+		if strategy.MaxUnavailablePools != nil && unavailable >= *strategy.MaxUnavailablePools {
+			break
+		}
+		allowed.Insert(name)
+		unavailable++
+	}
+	return allowed, status, 0
+}
@@ -0,0 +1,236 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package uniteddeployment
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestRolloutBatches(t *testing.T) {
+	nodePools := []unitv1alpha1.NodePool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "site-a"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "site-b"}, Spec: unitv1alpha1.NodePoolSpec{Parent: "region"}},
+	}
+	pools := []string{"site-a", "site-b", "site-c", "site-d"}
+	tests := []struct {
+		name    string
+		batches []unitv1alpha1.UnitedDeploymentUpdateBatch
+		expect  [][]string
+	}{
+		{
+			"no batch",
+			nil,
+			[][]string{{"site-a", "site-b", "site-c", "site-d"}},
+		},
+		{
+			"pools not listed make up the last batch",
+			[]unitv1alpha1.UnitedDeploymentUpdateBatch{{Pools: []string{"site-d"}}, {Pools: []string{"site-b"}}},
+			[][]string{{"site-d"}, {"site-b"}, {"site-a", "site-c"}},
+		},
+		{
+			"parent nodepool covers its descendants",
+			[]unitv1alpha1.UnitedDeploymentUpdateBatch{{Pools: []string{"site-b"}}, {Pools: []string{"region"}}},
+			[][]string{{"site-b"}, {"site-a"}, {"site-c", "site-d"}},
+		},
+		{
+			"all pools listed",
+			[]unitv1alpha1.UnitedDeploymentUpdateBatch{{Pools: []string{"site-a", "site-b", "site-c", "site-d"}}},
+			[][]string{{"site-a", "site-b", "site-c", "site-d"}},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			strategy := &unitv1alpha1.UnitedDeploymentUpdateStrategy{Batches: st.batches}
+			if get := rolloutBatches(strategy, pools, nodePools); !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect batches %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestPlanRollout(t *testing.T) {
+	one := int32(1)
+	now := metav1.Now()
+	before := metav1.NewTime(now.Add(-20 * time.Second))
+	batches := [][]string{{"a", "b"}, {"c", "d"}}
+	tests := []struct {
+		name          string
+		strategy      unitv1alpha1.UnitedDeploymentUpdateStrategy
+		oldStatus     *unitv1alpha1.UnitedDeploymentUpdateStatus
+		states        map[string]poolRolloutState
+		expectAllowed []string
+		expectBatch   int32
+		expectRequeue time.Duration
+	}{
+		{
+			"first batch starts",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{},
+			nil,
+			map[string]poolRolloutState{},
+			[]string{"a", "b"},
+			0,
+			0,
+		},
+		{
+			"max unavailable pools limits the batch",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{MaxUnavailablePools: &one},
+			nil,
+			map[string]poolRolloutState{"a": {updated: true}},
+			[]string{"a"},
+			0,
+			0,
+		},
+		{
+			"next pool of the batch starts once the updated pool is ready",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{MaxUnavailablePools: &one},
+			nil,
+			map[string]poolRolloutState{"a": {updated: true, ready: true}},
+			[]string{"a", "b"},
+			0,
+			0,
+		},
+		{
+			"next batch waits for the previous batch",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{},
+			nil,
+			map[string]poolRolloutState{"a": {updated: true, ready: true}, "b": {updated: true}},
+			[]string{"a", "b"},
+			0,
+			0,
+		},
+		{
+			"next batch starts once the previous batch is ready",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{},
+			nil,
+			map[string]poolRolloutState{"a": {updated: true, ready: true}, "b": {updated: true, ready: true}},
+			[]string{"a", "b", "c", "d"},
+			1,
+			0,
+		},
+		{
+			"next batch waits for the interval",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{BatchIntervalSeconds: 30},
+			&unitv1alpha1.UnitedDeploymentUpdateStatus{UpdatedRevision: "rev", CurrentBatch: 1, LastBatchReadyTime: &before},
+			map[string]poolRolloutState{"a": {updated: true, ready: true}, "b": {updated: true, ready: true}},
+			[]string{"a", "b"},
+			1,
+			10 * time.Second,
+		},
+		{
+			"interval restarts for a new revision",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{BatchIntervalSeconds: 30},
+			&unitv1alpha1.UnitedDeploymentUpdateStatus{UpdatedRevision: "old", CurrentBatch: 1, LastBatchReadyTime: &before},
+			map[string]poolRolloutState{"a": {updated: true, ready: true}, "b": {updated: true, ready: true}},
+			[]string{"a", "b"},
+			1,
+			30 * time.Second,
+		},
+		{
+			"paused rollout keeps the updated pools only",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{Paused: true},
+			nil,
+			map[string]poolRolloutState{"a": {updated: true, ready: true}, "b": {updated: true, ready: true}},
+			[]string{"a", "b"},
+			1,
+			0,
+		},
+		{
+			"rollout finished",
+			unitv1alpha1.UnitedDeploymentUpdateStrategy{},
+			nil,
+			map[string]poolRolloutState{"a": {updated: true, ready: true}, "b": {updated: true, ready: true},
+				"c": {updated: true, ready: true}, "d": {updated: true, ready: true}},
+			[]string{"a", "b", "c", "d"},
+			2,
+			0,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			allowed, status, requeueAfter := planRollout(&st.strategy, st.oldStatus, "rev", batches, st.states, now)
+			if get := allowed.List(); !reflect.DeepEqual(get, st.expectAllowed) {
+				t.Errorf("expect allowed pools %v, but get %v", st.expectAllowed, get)
+			}
+			if status.CurrentBatch != st.expectBatch {
+				t.Errorf("expect current batch %d, but get %d", st.expectBatch, status.CurrentBatch)
+			}
+			if requeueAfter != st.expectRequeue {
+				t.Errorf("expect requeue after %v, but get %v", st.expectRequeue, requeueAfter)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestExpiredRevisions(t *testing.T) {
+	var sorted []*appsv1.ControllerRevision
+	for _, name := range []string{"rev-1", "rev-2", "rev-3", "rev-4"} {
+		sorted = append(sorted, &appsv1.ControllerRevision{ObjectMeta: metav1.ObjectMeta{Name: name}})
+	}
+	names := func(revisions []*appsv1.ControllerRevision) []string {
+		var result []string
+		for _, revision := range revisions {
+			result = append(result, revision.Name)
+		}
+		return result
+	}
+	tests := []struct {
+		name          string
+		limit         int
+		live          sets.String
+		expectExpired []string
+		expectKept    []string
+	}{
+		{"within the limit", 4, sets.NewString(), nil, []string{"rev-1", "rev-2", "rev-3", "rev-4"}},
+		{"oldest revisions expire", 2, sets.NewString(), []string{"rev-1", "rev-2"}, []string{"rev-3", "rev-4"}},
+		{"revision of held back pool is kept", 2, sets.NewString("rev-1"), []string{"rev-2"}, []string{"rev-1", "rev-3", "rev-4"}},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			expired, kept := expiredRevisions(sorted, st.limit, st.live)
+			if get := names(expired); !reflect.DeepEqual(get, st.expectExpired) {
+				t.Errorf("expect expired revisions %v, but get %v", st.expectExpired, get)
+			}
+			if get := names(kept); !reflect.DeepEqual(get, st.expectKept) {
+				t.Errorf("expect kept revisions %v, but get %v", st.expectKept, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -23,10 +23,12 @@ package uniteddeployment
 
 import (
 	"fmt"
+	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
@@ -37,27 +39,57 @@ import (
 
 func (r *ReconcileUnitedDeployment) managePools(ud *unitv1alpha1.UnitedDeployment,
 	nameToPool map[string]*Pool, nextPatches map[string]UnitedDeploymentPatches,
-	expectedRevision *appsv1.ControllerRevision,
-	poolType unitv1alpha1.TemplateType) (newStatus *unitv1alpha1.UnitedDeploymentStatus, updateErr error) {
+	expectedRevision *appsv1.ControllerRevision, nodePools []unitv1alpha1.NodePool,
+	poolType unitv1alpha1.TemplateType) (newStatus *unitv1alpha1.UnitedDeploymentStatus, requeueAfter time.Duration, updateErr error) {
 
 	newStatus = ud.Status.DeepCopy()
 	exists, provisioned, err := r.managePoolProvision(ud, nameToPool, nextPatches, expectedRevision, poolType)
 	if err != nil {
 		SetUnitedDeploymentCondition(newStatus, NewUnitedDeploymentCondition(unitv1alpha1.PoolProvisioned, corev1.ConditionFalse, "Error", err.Error()))
-		return newStatus, fmt.Errorf("fail to manage Pool provision: %s", err)
+		return newStatus, 0, fmt.Errorf("fail to manage Pool provision: %s", err)
 	}
 
 	if provisioned {
 		SetUnitedDeploymentCondition(newStatus, NewUnitedDeploymentCondition(unitv1alpha1.PoolProvisioned, corev1.ConditionTrue, "", ""))
 	}
 
+	// the pools not allowed by the update strategy are kept at their own
+	// revision, they are only updated when the replicas or patch changes
+	allowed := exists
+	states := make(map[string]poolRolloutState, exists.Len())
+	for _, name := range exists.List() {
+		states[name] = getPoolRolloutState(nameToPool[name], expectedRevision.Name)
+	}
+	newStatus.UpdateStatus = nil
+	if ud.Spec.UpdateStrategy != nil {
+		batches := rolloutBatches(ud.Spec.UpdateStrategy, exists.List(), nodePools)
+		allowed, newStatus.UpdateStatus, requeueAfter = planRollout(ud.Spec.UpdateStrategy,
+			ud.Status.UpdateStatus, expectedRevision.Name, batches, states, metav1.Now())
+	}
+
+	allUpdated, heldBack := true, false
 	var needUpdate []string
 	for _, name := range exists.List() {
 		pool := nameToPool[name]
-		if r.poolControls[poolType].IsExpected(pool, expectedRevision.Name) ||
+		if !states[name].updated {
+			allUpdated = false
+		}
+		if (allowed.Has(name) && r.poolControls[poolType].IsExpected(pool, expectedRevision.Name)) ||
 			pool.Status.ReplicasInfo.Replicas != nextPatches[name].Replicas ||
 			pool.Status.PatchInfo != nextPatches[name].Patch {
 			needUpdate = append(needUpdate, name)
+			heldBack = heldBack || !allowed.Has(name)
+		}
+	}
+	// the current revision catches up once all the pools are updated
+	if allUpdated {
+		newStatus.CurrentRevision = expectedRevision.Name
+	}
+
+	var revisions []*appsv1.ControllerRevision
+	if heldBack {
+		if revisions, err = r.controlledHistories(ud); err != nil {
+			return newStatus, requeueAfter, fmt.Errorf("fail to list revisions: %s", err)
 		}
 	}
 
@@ -67,10 +99,21 @@ func (r *ReconcileUnitedDeployment) managePools(ud *unitv1alpha1.UnitedDeploymen
 			pool := nameToPool[cell]
 			replicas := nextPatches[cell].Replicas
 
+			poolUD, revision := ud, expectedRevision.Name
+			if !allowed.Has(cell) {
+				revision = pool.Spec.PoolRef.GetLabels()[unitv1alpha1.ControllerRevisionHashLabelKey]
+				var err error
+				if poolUD, err = restoreRevision(ud, revision, revisions); err != nil {
+					err = fmt.Errorf("fail to restore revision %s of Pool (%s) %s held back: %s", revision, poolType, pool.Name, err)
+					r.recorder.Event(ud.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypePoolsUpdate), err.Error())
+					return err
+				}
+			}
+
 			klog.Infof("UnitedDeployment %s/%s needs to update Pool (%s) %s/%s with revision %s, replicas %d ",
-				ud.Namespace, ud.Name, poolType, pool.Namespace, pool.Name, expectedRevision.Name, replicas)
+				ud.Namespace, ud.Name, poolType, pool.Namespace, pool.Name, revision, replicas)
 
-			updatePoolErr := r.poolControls[poolType].UpdatePool(pool, ud, expectedRevision.Name, replicas)
+			updatePoolErr := r.poolControls[poolType].UpdatePool(pool, poolUD, revision, replicas)
 			if updatePoolErr != nil {
 				r.recorder.Event(ud.DeepCopy(), corev1.EventTypeWarning, fmt.Sprintf("Failed%s", eventTypePoolsUpdate), fmt.Sprintf("Error updating PodSet (%s) %s when updating: %s", poolType, pool.Name, updatePoolErr))
 			}
@@ -41,6 +41,7 @@ import (
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/controller/yurtappdaemon/workloadcontroller"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util"
 	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/gate"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
 )
 
 var (
@@ -403,12 +404,30 @@ func (r *ReconcileYurtAppDaemon) getNameToNodePools(instance *unitv1alpha1.YurtA
 		return nil, nil
 	}
 
+	allNodepools := unitv1alpha1.NodePoolList{}
+	if err := r.Client.List(context.TODO(), &allNodepools); err != nil {
+		klog.Errorf("YurtAppDaemon [%s/%s] Fail to get NodePoolList", instance.GetNamespace(),
+			instance.GetName())
+		return nil, nil
+	}
+	nameToNodepool := make(map[string]*unitv1alpha1.NodePool)
+	for i := range allNodepools.Items {
+		nameToNodepool[allNodepools.Items[i].GetName()] = &allNodepools.Items[i]
+	}
+
 	indexs := make(map[string]unitv1alpha1.NodePool)
 	for i, v := range nodepools.Items {
-		indexs[v.GetName()] = v
-		klog.V(4).Infof("YurtAppDaemon [%s/%s] get %d's associated nodepools %s",
-			instance.Namespace, instance.Name, i, v.Name)
-
+		// a parent nodepool is expanded to its leaf nodepools, which carry
+		// the taints inherited from their ancestors
+		for _, leaf := range nodepoolutil.LeafNodePools(v.GetName(), allNodepools.Items) {
+			np, ok := nameToNodepool[leaf]
+			if !ok {
+				continue
+			}
+			indexs[leaf] = *nodepoolutil.InheritAttributes(np, allNodepools.Items)
+			klog.V(4).Infof("YurtAppDaemon [%s/%s] get %d's associated nodepools %s",
+				instance.Namespace, instance.Name, i, leaf)
+		}
 	}
 
 	return indexs, nil
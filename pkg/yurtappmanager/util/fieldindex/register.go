@@ -26,13 +26,40 @@ import (
 	v1 "k8s.io/api/core/v1"
 	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
 )
 
 const (
 	IndexNameForPodNodeName = "spec.nodeName"
 	IndexNameForOwnerRefUID = "ownerRefUID"
+
+	// IndexNameForNodeDesiredNodePool indexes nodes by the value of the
+	// `apps.openyurt.io/desired-nodepool` label
+	IndexNameForNodeDesiredNodePool = "desiredNodePool"
+	// IndexNameForNodeCurrentNodePool indexes nodes by the value of the
+	// `apps.openyurt.io/nodepool` label
+	IndexNameForNodeCurrentNodePool = "currentNodePool"
+	// IndexNameForNodeMigrateToNodePool indexes nodes by the value of the
+	// `nodepool.openyurt.io/migrate-to` annotation
+	IndexNameForNodeMigrateToNodePool = "migrateToNodePool"
 )
 
+// nodeLabelIndexFunc returns an IndexerFunc that indexes nodes by the value
+// of the label `key`
+func nodeLabelIndexFunc(key string) client.IndexerFunc {
+	return func(obj client.Object) []string {
+		node, ok := obj.(*v1.Node)
+		if !ok {
+			return []string{}
+		}
+		if v := node.Labels[key]; v != "" {
+			return []string{v}
+		}
+		return []string{}
+	}
+}
+
 var registerOnce sync.Once
 
 func RegisterFieldIndexes(c cache.Cache) error {
@@ -73,6 +100,29 @@ func RegisterFieldIndexes(c cache.Cache) error {
 		if err = c.IndexField(context.TODO(), &v1.PersistentVolumeClaim{}, IndexNameForOwnerRefUID, ownerIndexFunc); err != nil {
 			return
 		}
+
+		// node nodepool labels
+		if err = c.IndexField(context.TODO(), &v1.Node{}, IndexNameForNodeDesiredNodePool,
+			nodeLabelIndexFunc(appsv1alpha1.LabelDesiredNodePool)); err != nil {
+			return
+		}
+		if err = c.IndexField(context.TODO(), &v1.Node{}, IndexNameForNodeCurrentNodePool,
+			nodeLabelIndexFunc(appsv1alpha1.LabelCurrentNodePool)); err != nil {
+			return
+		}
+		if err = c.IndexField(context.TODO(), &v1.Node{}, IndexNameForNodeMigrateToNodePool,
+			func(obj client.Object) []string {
+				node, ok := obj.(*v1.Node)
+				if !ok {
+					return []string{}
+				}
+				if v := node.Annotations[appsv1alpha1.AnnotationMigrateToNodePool]; v != "" {
+					return []string{v}
+				}
+				return []string{}
+			}); err != nil {
+			return
+		}
 	})
 	return err
 }
@@ -0,0 +1,161 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// indexByName returns the pools indexed by their names
+func indexByName(pools []appsv1alpha1.NodePool) map[string]*appsv1alpha1.NodePool {
+	index := make(map[string]*appsv1alpha1.NodePool, len(pools))
+	for i := range pools {
+		index[pools[i].GetName()] = &pools[i]
+	}
+	return index
+}
+
+// Ancestors returns the ancestors of the pool, from its parent to the root.
+// The walk stops at the first parent that doesn't exist, or at the first
+// pool that has been visited, in case there is a cycle
+func Ancestors(np *appsv1alpha1.NodePool,
+	pools []appsv1alpha1.NodePool) []appsv1alpha1.NodePool {
+	index := indexByName(pools)
+	visited := map[string]bool{np.GetName(): true}
+	var ancestors []appsv1alpha1.NodePool
+	for parent := np.Spec.Parent; parent != "" && !visited[parent]; {
+		p, exist := index[parent]
+		if !exist {
+			break
+		}
+		visited[parent] = true
+		ancestors = append(ancestors, *p)
+		parent = p.Spec.Parent
+	}
+	return ancestors
+}
+
+// Children returns the sorted names of the pools whose parent is `name`
+func Children(name string, pools []appsv1alpha1.NodePool) []string {
+	var children []string
+	for _, np := range pools {
+		if np.Spec.Parent == name && np.GetName() != name {
+			children = append(children, np.GetName())
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// Descendants returns the sorted names of all pools under `name`
+func Descendants(name string, pools []appsv1alpha1.NodePool) []string {
+	var descendants []string
+	visited := map[string]bool{name: true}
+	queue := []string{name}
+	for len(queue) != 0 {
+		for _, child := range Children(queue[0], pools) {
+			if visited[child] {
+				continue
+			}
+			visited[child] = true
+			descendants = append(descendants, child)
+			queue = append(queue, child)
+		}
+		queue = queue[1:]
+	}
+	sort.Strings(descendants)
+	return descendants
+}
+
+// LeafNodePools returns the sorted names of the leaf pools under `name`, or
+// `name` itself if it has no child. Workloads targeting a parent pool are
+// expanded to these pools, as nodes join the leaf pools
+func LeafNodePools(name string, pools []appsv1alpha1.NodePool) []string {
+	var leaves []string
+	for _, d := range Descendants(name, pools) {
+		if len(Children(d, pools)) == 0 {
+			leaves = append(leaves, d)
+		}
+	}
+	if len(leaves) == 0 {
+		return []string{name}
+	}
+	return leaves
+}
+
+// InheritAttributes returns a copy of the pool whose labels, annotations,
+// taints and image registry mirrors are merged with those of its ancestors.
+// The values of a pool take precedence over those of its ancestors, taints
+// are identified by the key and effect pair
+func InheritAttributes(np *appsv1alpha1.NodePool,
+	pools []appsv1alpha1.NodePool) *appsv1alpha1.NodePool {
+	merged := np.DeepCopy()
+	ancestors := Ancestors(np, pools)
+	if len(ancestors) == 0 {
+		return merged
+	}
+
+	labels := make(map[string]string)
+	annotations := make(map[string]string)
+	mirrors := make(map[string]string)
+	var taints []corev1.Taint
+	// apply the attributes from the root to the pool itself
+	chain := append([]appsv1alpha1.NodePool{*np}, ancestors...)
+	for i := len(chain) - 1; i >= 0; i-- {
+		spec := chain[i].Spec
+		for k, v := range spec.Labels {
+			labels[k] = v
+		}
+		for k, v := range spec.Annotations {
+			annotations[k] = v
+		}
+		for _, t := range spec.Taints {
+			taints = mergeTaint(taints, t)
+		}
+		for k, v := range spec.ImageRegistryMirrors {
+			mirrors[k] = v
+		}
+	}
+
+	if len(labels) != 0 {
+		merged.Spec.Labels = labels
+	}
+	if len(annotations) != 0 {
+		merged.Spec.Annotations = annotations
+	}
+	if len(mirrors) != 0 {
+		merged.Spec.ImageRegistryMirrors = mirrors
+	}
+	merged.Spec.Taints = taints
+	return merged
+}
+
+// mergeTaint adds the taint to the taints, or replaces the one with the same
+// key and effect
+func mergeTaint(taints []corev1.Taint, taint corev1.Taint) []corev1.Taint {
+	for i, t := range taints {
+		if t.Key == taint.Key && t.Effect == taint.Effect {
+			taints[i] = taint
+			return taints
+		}
+	}
+	return append(taints, taint)
+}
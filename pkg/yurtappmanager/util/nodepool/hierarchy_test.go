@@ -0,0 +1,112 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newNodePool(name, parent string) appsv1alpha1.NodePool {
+	return appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec:       appsv1alpha1.NodePoolSpec{Parent: parent},
+	}
+}
+
+// newNodePoolTree returns the pools of the following tree:
+// region -> site-a -> rack-1, rack-2
+// region -> site-b
+func newNodePoolTree() []appsv1alpha1.NodePool {
+	return []appsv1alpha1.NodePool{
+		newNodePool("region", ""),
+		newNodePool("site-a", "region"),
+		newNodePool("site-b", "region"),
+		newNodePool("rack-1", "site-a"),
+		newNodePool("rack-2", "site-a"),
+	}
+}
+
+func TestAncestors(t *testing.T) {
+	pools := newNodePoolTree()
+	rack := pools[3]
+	var names []string
+	for _, np := range Ancestors(&rack, pools) {
+		names = append(names, np.GetName())
+	}
+	if expect := []string{"site-a", "region"}; !reflect.DeepEqual(names, expect) {
+		t.Errorf("expect ancestors %v, but get %v", expect, names)
+	}
+
+	cycle := []appsv1alpha1.NodePool{newNodePool("a", "b"), newNodePool("b", "a")}
+	if ancestors := Ancestors(&cycle[0], cycle); len(ancestors) != 1 {
+		t.Errorf("expect the walk to stop at the cycle, but get %d ancestors", len(ancestors))
+	}
+}
+
+func TestLeafNodePools(t *testing.T) {
+	pools := newNodePoolTree()
+	tests := []struct {
+		name   string
+		expect []string
+	}{
+		{"region", []string{"rack-1", "rack-2", "site-b"}},
+		{"site-a", []string{"rack-1", "rack-2"}},
+		{"rack-1", []string{"rack-1"}},
+	}
+	for _, tt := range tests {
+		if get := LeafNodePools(tt.name, pools); !reflect.DeepEqual(get, tt.expect) {
+			t.Errorf("expect leaf pools of %s to be %v, but get %v", tt.name, tt.expect, get)
+		}
+	}
+}
+
+func TestInheritAttributes(t *testing.T) {
+	pools := newNodePoolTree()
+	pools[0].Spec.Labels = map[string]string{"region": "east", "tier": "region"}
+	pools[0].Spec.Taints = []corev1.Taint{
+		{Key: "edge", Value: "region", Effect: corev1.TaintEffectNoSchedule},
+	}
+	pools[1].Spec.Annotations = map[string]string{"owner": "foo"}
+	pools[1].Spec.ImageRegistryMirrors = map[string]string{"docker.io": "mirror.site-a.local"}
+	pools[3].Spec.Labels = map[string]string{"tier": "rack"}
+	pools[3].Spec.Taints = []corev1.Taint{
+		{Key: "edge", Value: "rack", Effect: corev1.TaintEffectNoSchedule},
+	}
+
+	merged := InheritAttributes(&pools[3], pools)
+	if expect := map[string]string{"region": "east", "tier": "rack"}; !reflect.DeepEqual(merged.Spec.Labels, expect) {
+		t.Errorf("expect labels %v, but get %v", expect, merged.Spec.Labels)
+	}
+	if expect := map[string]string{"owner": "foo"}; !reflect.DeepEqual(merged.Spec.Annotations, expect) {
+		t.Errorf("expect annotations %v, but get %v", expect, merged.Spec.Annotations)
+	}
+	if expect := map[string]string{"docker.io": "mirror.site-a.local"}; !reflect.DeepEqual(merged.Spec.ImageRegistryMirrors, expect) {
+		t.Errorf("expect image registry mirrors %v, but get %v", expect, merged.Spec.ImageRegistryMirrors)
+	}
+	if len(merged.Spec.Taints) != 1 || merged.Spec.Taints[0].Value != "rack" {
+		t.Errorf("expect the taint of the pool to take precedence, but get %v", merged.Spec.Taints)
+	}
+	if pools[3].Spec.Labels["region"] != "" {
+		t.Errorf("the pool should not be modified")
+	}
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// defaultRegistry is the registry of the images without a registry
+	defaultRegistry = "docker.io"
+	// officialRepositoryPrefix prefixes the official images of docker.io
+	officialRepositoryPrefix = "library/"
+)
+
+// SplitImageRegistry splits the image into the registry and the repository
+// with the tag or digest, the same way as docker does: the first component
+// of the image is a registry if it contains a "." or ":", or is "localhost".
+// Images without a registry come from docker.io, and the official images of
+// docker.io are prefixed with "library/"
+func SplitImageRegistry(image string) (string, string) {
+	registry, repository := defaultRegistry, image
+	if i := strings.IndexRune(image, '/'); i != -1 &&
+		(strings.ContainsAny(image[:i], ".:") || image[:i] == "localhost") {
+		registry, repository = image[:i], image[i+1:]
+	}
+	if registry == "index.docker.io" {
+		registry = defaultRegistry
+	}
+	if registry == defaultRegistry && !strings.ContainsRune(repository, '/') {
+		repository = officialRepositoryPrefix + repository
+	}
+	return registry, repository
+}
+
+// RewriteImage returns the image pulled from the mirror of its registry, or
+// the image itself if its registry has no mirror
+func RewriteImage(image string, mirrors map[string]string) string {
+	registry, repository := SplitImageRegistry(image)
+	mirror, exist := mirrors[registry]
+	if !exist || mirror == "" {
+		return image
+	}
+	return strings.TrimSuffix(mirror, "/") + "/" + repository
+}
+
+// RewritePodImages rewrites the images of the containers and init containers
+// of the pod to pull from the mirrors, it returns true if the pod is changed
+func RewritePodImages(pod *corev1.Pod, mirrors map[string]string) bool {
+	if len(mirrors) == 0 {
+		return false
+	}
+	var changed bool
+	for _, containers := range [][]corev1.Container{pod.Spec.InitContainers, pod.Spec.Containers} {
+		for i := range containers {
+			if image := RewriteImage(containers[i].Image, mirrors); image != containers[i].Image {
+				containers[i].Image = image
+				changed = true
+			}
+		}
+	}
+	return changed
+}
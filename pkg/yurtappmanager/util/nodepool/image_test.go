@@ -0,0 +1,81 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestRewriteImage(t *testing.T) {
+	mirrors := map[string]string{
+		"docker.io":          "registry.site-a.local:5000",
+		"k8s.gcr.io":         "registry.site-a.local:5000/k8s/",
+		"localhost:5000":     "",
+		"quay.io":            "mirror.local",
+		"registry.cloud.com": "registry.site-a.local",
+	}
+	tests := []struct {
+		image  string
+		expect string
+	}{
+		{"nginx", "registry.site-a.local:5000/library/nginx"},
+		{"nginx:1.19", "registry.site-a.local:5000/library/nginx:1.19"},
+		{"openyurt/yurthub:v0.4.0", "registry.site-a.local:5000/openyurt/yurthub:v0.4.0"},
+		{"docker.io/library/busybox", "registry.site-a.local:5000/library/busybox"},
+		{"index.docker.io/busybox", "registry.site-a.local:5000/library/busybox"},
+		{"k8s.gcr.io/pause:3.2", "registry.site-a.local:5000/k8s/pause:3.2"},
+		{"quay.io/coreos/etcd@sha256:abc", "mirror.local/coreos/etcd@sha256:abc"},
+		{"registry.cloud.com:443/app", "registry.cloud.com:443/app"},
+		{"localhost:5000/app", "localhost:5000/app"},
+		{"gcr.io/app", "gcr.io/app"},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.image)
+			if get := RewriteImage(st.image, mirrors); get != st.expect {
+				t.Errorf("expect image %s, but get %s", st.expect, get)
+			}
+		}
+		t.Run(st.image, tf)
+	}
+}
+
+func TestRewritePodImages(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Image: "busybox"}},
+			Containers:     []corev1.Container{{Image: "nginx"}, {Image: "gcr.io/app"}},
+		},
+	}
+	if !RewritePodImages(pod, map[string]string{"docker.io": "mirror.local"}) {
+		t.Fatalf("expect the images to be rewritten")
+	}
+	if image := pod.Spec.InitContainers[0].Image; image != "mirror.local/library/busybox" {
+		t.Errorf("unexpected image of the init container %s", image)
+	}
+	if image := pod.Spec.Containers[0].Image; image != "mirror.local/library/nginx" {
+		t.Errorf("unexpected image of the container %s", image)
+	}
+	if RewritePodImages(pod, map[string]string{"docker.io": "mirror.local"}) {
+		t.Errorf("expect the rewritten images to be kept")
+	}
+}
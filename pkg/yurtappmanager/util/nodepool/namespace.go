@@ -0,0 +1,103 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// AllowedNodePools parses the allowed-nodepools annotation of the namespace,
+// it returns nil if the annotation is absent or empty, i.e. the pods of the
+// namespace are not restricted
+func AllowedNodePools(ns *corev1.Namespace) []string {
+	allowed := sets.NewString()
+	for _, name := range strings.Split(ns.Annotations[appsv1alpha1.AnnotationAllowedNodePools], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			allowed.Insert(name)
+		}
+	}
+	if allowed.Len() == 0 {
+		return nil
+	}
+	return allowed.List()
+}
+
+// ExpandNodePools returns the sorted names of the pools and all their
+// descendants, as nodes of the descendant pools belong to the ancestors too
+func ExpandNodePools(names []string, pools []appsv1alpha1.NodePool) []string {
+	expanded := sets.NewString(names...)
+	for _, name := range names {
+		expanded.Insert(Descendants(name, pools)...)
+	}
+	return expanded.List()
+}
+
+// InjectNodePoolAffinity restricts the pod to the nodes of the pools through
+// the required node affinity on the nodepool label, the requirement is added
+// to every node selector term as the terms are ORed. It returns false if the
+// pod already has the requirement
+func InjectNodePoolAffinity(pod *corev1.Pod, pools []string) bool {
+	requirement := corev1.NodeSelectorRequirement{
+		Key:      appsv1alpha1.LabelCurrentNodePool,
+		Operator: corev1.NodeSelectorOpIn,
+		Values:   pools,
+	}
+
+	if pod.Spec.Affinity == nil {
+		pod.Spec.Affinity = &corev1.Affinity{}
+	}
+	if pod.Spec.Affinity.NodeAffinity == nil {
+		pod.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	na := pod.Spec.Affinity.NodeAffinity
+	if na.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		na.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{}
+	}
+	selector := na.RequiredDuringSchedulingIgnoredDuringExecution
+	if len(selector.NodeSelectorTerms) == 0 {
+		selector.NodeSelectorTerms = []corev1.NodeSelectorTerm{{}}
+	}
+
+	var injected bool
+	for i := range selector.NodeSelectorTerms {
+		term := &selector.NodeSelectorTerms[i]
+		if containsRequirement(term.MatchExpressions, requirement) {
+			continue
+		}
+		term.MatchExpressions = append(term.MatchExpressions, requirement)
+		injected = true
+	}
+	return injected
+}
+
+// containsRequirement checks if the requirement is in the requirements, the
+// values are compared as sets
+func containsRequirement(requirements []corev1.NodeSelectorRequirement,
+	requirement corev1.NodeSelectorRequirement) bool {
+	for _, r := range requirements {
+		if r.Key == requirement.Key && r.Operator == requirement.Operator &&
+			sets.NewString(r.Values...).Equal(sets.NewString(requirement.Values...)) {
+			return true
+		}
+	}
+	return false
+}
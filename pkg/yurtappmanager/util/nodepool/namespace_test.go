@@ -0,0 +1,106 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestAllowedNodePools(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		expect      []string
+	}{
+		{"no annotation", nil, nil},
+		{"empty annotation", map[string]string{appsv1alpha1.AnnotationAllowedNodePools: " , "}, nil},
+		{
+			"pools are trimmed and deduplicated",
+			map[string]string{appsv1alpha1.AnnotationAllowedNodePools: "site-b, site-a,site-b"},
+			[]string{"site-a", "site-b"},
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Annotations: st.annotations}}
+			if get := AllowedNodePools(ns); !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect allowed pools %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestExpandNodePools(t *testing.T) {
+	pools := newNodePoolTree()
+	expect := []string{"rack-1", "rack-2", "site-a", "site-b"}
+	if get := ExpandNodePools([]string{"site-a", "site-b"}, pools); !reflect.DeepEqual(get, expect) {
+		t.Errorf("expect expanded pools %v, but get %v", expect, get)
+	}
+}
+
+func TestInjectNodePoolAffinity(t *testing.T) {
+	pod := &corev1.Pod{
+		Spec: corev1.PodSpec{
+			Affinity: &corev1.Affinity{
+				NodeAffinity: &corev1.NodeAffinity{
+					RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+						NodeSelectorTerms: []corev1.NodeSelectorTerm{
+							{MatchExpressions: []corev1.NodeSelectorRequirement{
+								{Key: "disk", Operator: corev1.NodeSelectorOpIn, Values: []string{"ssd"}},
+							}},
+							{MatchExpressions: []corev1.NodeSelectorRequirement{
+								{Key: "gpu", Operator: corev1.NodeSelectorOpExists},
+							}},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	if !InjectNodePoolAffinity(pod, []string{"site-a"}) {
+		t.Fatalf("expect the affinity to be injected")
+	}
+	for i, term := range pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		if len(term.MatchExpressions) != 2 ||
+			term.MatchExpressions[1].Key != appsv1alpha1.LabelCurrentNodePool {
+			t.Errorf("expect the nodepool requirement in term %d, but get %v", i, term.MatchExpressions)
+		}
+	}
+	if InjectNodePoolAffinity(pod, []string{"site-a"}) {
+		t.Errorf("expect the affinity not to be injected twice")
+	}
+
+	empty := &corev1.Pod{}
+	if !InjectNodePoolAffinity(empty, []string{"site-a"}) {
+		t.Fatalf("expect the affinity to be injected")
+	}
+	if terms := empty.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms; len(terms) != 1 {
+		t.Errorf("expect one node selector term, but get %v", terms)
+	}
+}
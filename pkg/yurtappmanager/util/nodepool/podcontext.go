@@ -0,0 +1,201 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+const (
+	// PoolContextLabels sets the pool context as the pod labels and annotations
+	PoolContextLabels = "labels"
+	// PoolContextEnv also exposes the pool context to the containers as
+	// environment variables
+	PoolContextEnv = "env"
+
+	// EnvNodePoolName is the environment variable of the pool name
+	EnvNodePoolName = "YURT_NODEPOOL_NAME"
+	// EnvNodePoolType is the environment variable of the pool type
+	EnvNodePoolType = "YURT_NODEPOOL_TYPE"
+	// envNodePoolAnnotationPrefix prefixes the environment variables of the
+	// pool annotations
+	envNodePoolAnnotationPrefix = "YURT_NODEPOOL_ANNOTATION_"
+)
+
+// PoolContextMode returns the mode of the pool context injection of the pod,
+// or an empty string if the pod doesn't ask for it
+func PoolContextMode(pod *corev1.Pod) string {
+	switch mode := pod.Annotations[appsv1alpha1.AnnotationInjectPoolContext]; mode {
+	case PoolContextLabels, PoolContextEnv:
+		return mode
+	default:
+		return ""
+	}
+}
+
+// PoolAnnotationKeys returns the keys of the pool annotations that the pod
+// asks for
+func PoolAnnotationKeys(pod *corev1.Pod) []string {
+	var keys []string
+	for _, key := range strings.Split(pod.Annotations[appsv1alpha1.AnnotationInjectPoolAnnotations], ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// PinnedNodePool returns the pool that the pod is pinned to before it is
+// scheduled, through the nodeSelector, e.g. the pods of YurtAppDaemon, or
+// through the required node affinity that selects a single pool in every
+// term, e.g. the pods of UnitedDeployment. It returns an empty string if the
+// pod may run in several pools
+func PinnedNodePool(pod *corev1.Pod) string {
+	if pool := pod.Spec.NodeSelector[appsv1alpha1.LabelCurrentNodePool]; pool != "" {
+		return pool
+	}
+	if pod.Spec.Affinity == nil || pod.Spec.Affinity.NodeAffinity == nil ||
+		pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return ""
+	}
+
+	var pinned string
+	terms := pod.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	for _, term := range terms {
+		var pool string
+		for _, r := range term.MatchExpressions {
+			if r.Key == appsv1alpha1.LabelCurrentNodePool &&
+				r.Operator == corev1.NodeSelectorOpIn && len(r.Values) == 1 {
+				pool = r.Values[0]
+				break
+			}
+		}
+		if pool == "" || (pinned != "" && pinned != pool) {
+			return ""
+		}
+		pinned = pool
+	}
+	return pinned
+}
+
+// ApplyPoolContext sets the pool name and type as the pod labels, and copies
+// the selected pool annotations to the pod, it returns true if the pod is
+// changed
+func ApplyPoolContext(pod *corev1.Pod, np *appsv1alpha1.NodePool) bool {
+	var changed bool
+	if pod.Labels == nil {
+		pod.Labels = make(map[string]string)
+	}
+	labels := map[string]string{appsv1alpha1.LabelCurrentNodePool: np.GetName()}
+	if np.Spec.Type != "" {
+		labels[appsv1alpha1.LabelNodePoolType] = string(np.Spec.Type)
+	}
+	for k, v := range labels {
+		if pod.Labels[k] != v {
+			pod.Labels[k] = v
+			changed = true
+		}
+	}
+
+	for _, key := range PoolAnnotationKeys(pod) {
+		value, exist := np.Annotations[key]
+		if !exist {
+			continue
+		}
+		if pod.Annotations[key] != value {
+			pod.Annotations[key] = value
+			changed = true
+		}
+	}
+	return changed
+}
+
+// InjectPoolContextEnv exposes the pool context to all containers through
+// the downward API, the values are resolved from the pod labels and
+// annotations when the containers start. Variables defined by the containers
+// are not overwritten. It returns true if the pod is changed
+func InjectPoolContextEnv(pod *corev1.Pod) bool {
+	envs := []corev1.EnvVar{
+		fieldRefEnv(EnvNodePoolName, fmt.Sprintf("metadata.labels['%s']", appsv1alpha1.LabelCurrentNodePool)),
+		fieldRefEnv(EnvNodePoolType, fmt.Sprintf("metadata.labels['%s']", appsv1alpha1.LabelNodePoolType)),
+	}
+	for _, key := range PoolAnnotationKeys(pod) {
+		envs = append(envs, fieldRefEnv(PoolAnnotationEnvName(key),
+			fmt.Sprintf("metadata.annotations['%s']", key)))
+	}
+
+	var changed bool
+	for i := range pod.Spec.InitContainers {
+		changed = injectEnv(&pod.Spec.InitContainers[i], envs) || changed
+	}
+	for i := range pod.Spec.Containers {
+		changed = injectEnv(&pod.Spec.Containers[i], envs) || changed
+	}
+	return changed
+}
+
+// PoolAnnotationEnvName returns the environment variable of the pool
+// annotation, e.g. YURT_NODEPOOL_ANNOTATION_EXAMPLE_COM_SITE_ID for the
+// annotation `example.com/site-id`
+func PoolAnnotationEnvName(key string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			return r
+		}
+		return '_'
+	}, key)
+	return envNodePoolAnnotationPrefix + strings.ToUpper(name)
+}
+
+// fieldRefEnv returns the environment variable that refers to the field of
+// the pod
+func fieldRefEnv(name, fieldPath string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			FieldRef: &corev1.ObjectFieldSelector{
+				APIVersion: "v1",
+				FieldPath:  fieldPath,
+			},
+		},
+	}
+}
+
+// injectEnv appends the environment variables that are not defined by the
+// container, it returns true if the container is changed
+func injectEnv(container *corev1.Container, envs []corev1.EnvVar) bool {
+	var changed bool
+	for _, env := range envs {
+		var defined bool
+		for _, e := range container.Env {
+			if e.Name == env.Name {
+				defined = true
+				break
+			}
+		}
+		if !defined {
+			container.Env = append(container.Env, env)
+			changed = true
+		}
+	}
+	return changed
+}
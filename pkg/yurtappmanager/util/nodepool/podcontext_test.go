@@ -0,0 +1,146 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newPoolAffinity(pools ...[]string) *corev1.Affinity {
+	var terms []corev1.NodeSelectorTerm
+	for _, values := range pools {
+		terms = append(terms, corev1.NodeSelectorTerm{
+			MatchExpressions: []corev1.NodeSelectorRequirement{{
+				Key:      appsv1alpha1.LabelCurrentNodePool,
+				Operator: corev1.NodeSelectorOpIn,
+				Values:   values,
+			}},
+		})
+	}
+	return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+		RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+	}}
+}
+
+func TestPinnedNodePool(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   corev1.PodSpec
+		expect string
+	}{
+		{
+			"pinned by nodeSelector",
+			corev1.PodSpec{NodeSelector: map[string]string{appsv1alpha1.LabelCurrentNodePool: "hangzhou"}},
+			"hangzhou",
+		},
+		{
+			"pinned by node affinity",
+			corev1.PodSpec{Affinity: newPoolAffinity([]string{"hangzhou"}, []string{"hangzhou"})},
+			"hangzhou",
+		},
+		{
+			"several pools in a term",
+			corev1.PodSpec{Affinity: newPoolAffinity([]string{"hangzhou", "beijing"})},
+			"",
+		},
+		{
+			"different pools in the terms",
+			corev1.PodSpec{Affinity: newPoolAffinity([]string{"hangzhou"}, []string{"beijing"})},
+			"",
+		},
+		{
+			"not pinned",
+			corev1.PodSpec{},
+			"",
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if get := PinnedNodePool(&corev1.Pod{Spec: st.spec}); get != st.expect {
+				t.Errorf("expect pinned pool %q, but get %q", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
+
+func TestApplyPoolContext(t *testing.T) {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{
+				appsv1alpha1.AnnotationInjectPoolContext:     PoolContextEnv,
+				appsv1alpha1.AnnotationInjectPoolAnnotations: "example.com/site-id, example.com/missing",
+			},
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Env: []corev1.EnvVar{{Name: EnvNodePoolName, Value: "fixed"}},
+			}},
+		},
+	}
+	np := &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "hangzhou",
+			Annotations: map[string]string{"example.com/site-id": "hz-01"},
+		},
+		Spec: appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Edge},
+	}
+
+	if !ApplyPoolContext(pod, np) {
+		t.Fatalf("expect the pool context to be applied")
+	}
+	if pod.Labels[appsv1alpha1.LabelCurrentNodePool] != "hangzhou" ||
+		pod.Labels[appsv1alpha1.LabelNodePoolType] != "Edge" {
+		t.Errorf("unexpected pool labels %v", pod.Labels)
+	}
+	if pod.Annotations["example.com/site-id"] != "hz-01" {
+		t.Errorf("expect the pool annotation to be copied, but get %v", pod.Annotations)
+	}
+	if _, exist := pod.Annotations["example.com/missing"]; exist {
+		t.Errorf("expect the missing pool annotation to be skipped")
+	}
+	if ApplyPoolContext(pod, np) {
+		t.Errorf("expect the pool context not to be applied twice")
+	}
+
+	if !InjectPoolContextEnv(pod) {
+		t.Fatalf("expect the pool context env to be injected")
+	}
+	envs := map[string]corev1.EnvVar{}
+	for _, env := range pod.Spec.Containers[0].Env {
+		envs[env.Name] = env
+	}
+	if envs[EnvNodePoolName].Value != "fixed" {
+		t.Errorf("expect the env defined by the container to be kept, but get %v", envs[EnvNodePoolName])
+	}
+	if env, exist := envs["YURT_NODEPOOL_ANNOTATION_EXAMPLE_COM_SITE_ID"]; !exist ||
+		env.ValueFrom.FieldRef.FieldPath != "metadata.annotations['example.com/site-id']" {
+		t.Errorf("unexpected env of the pool annotation %v", env)
+	}
+	if len(envs) != 4 {
+		t.Errorf("expect 4 env, but get %v", envs)
+	}
+}
@@ -0,0 +1,168 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"context"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	resourcehelper "k8s.io/kubernetes/pkg/api/v1/resource"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+)
+
+// QuotaResources are the resources that can be limited by the NodePool quota
+var QuotaResources = []corev1.ResourceName{
+	corev1.ResourceCPU,
+	corev1.ResourceMemory,
+	corev1.ResourcePods,
+}
+
+// IsQuotaExempt checks if the pod is not limited by the NodePool quota, i.e.
+// mirror pods and pods managed by DaemonSets, which run on every node anyway,
+// and the short-lived pods that pre-pull images for PoolImagePrePull
+func IsQuotaExempt(pod *corev1.Pod) bool {
+	if _, exist := pod.Annotations[corev1.MirrorPodAnnotationKey]; exist {
+		return true
+	}
+	if ref := metav1.GetControllerOf(pod); ref != nil &&
+		(ref.Kind == "DaemonSet" || ref.Kind == "PoolImagePrePull") {
+		return true
+	}
+	return false
+}
+
+// PodUsage returns the resources counted against the NodePool quota for the
+// pod, i.e. its effective cpu and memory requests and one pod
+func PodUsage(pod *corev1.Pod) corev1.ResourceList {
+	requests, _ := resourcehelper.PodRequestsAndLimits(pod)
+	usage := corev1.ResourceList{
+		corev1.ResourcePods: *resource.NewQuantity(1, resource.DecimalSI),
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if q, exist := requests[name]; exist {
+			usage[name] = q.DeepCopy()
+		}
+	}
+	return usage
+}
+
+// ListPoolPods lists the running and pending pods on the member nodes of the
+// pool through the field indexes, pods exempted from the quota are skipped
+func ListPoolPods(ctx context.Context, cli client.Client,
+	poolName string) ([]corev1.Pod, error) {
+	var nodeList corev1.NodeList
+	if err := cli.List(ctx, &nodeList, client.MatchingFields{
+		fieldindex.IndexNameForNodeCurrentNodePool: poolName,
+	}); err != nil {
+		return nil, err
+	}
+
+	var pods []corev1.Pod
+	for _, node := range nodeList.Items {
+		var podList corev1.PodList
+		if err := cli.List(ctx, &podList, client.MatchingFields{
+			fieldindex.IndexNameForPodNodeName: node.GetName(),
+		}); err != nil {
+			return nil, err
+		}
+		for _, pod := range podList.Items {
+			if pod.Status.Phase == corev1.PodSucceeded ||
+				pod.Status.Phase == corev1.PodFailed || IsQuotaExempt(&pod) {
+				continue
+			}
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// CalculateQuotaUsage sums up the usage of the pods, in total and for each
+// namespace that has a quota. Only the resources in QuotaResources are counted
+func CalculateQuotaUsage(quota *appsv1alpha1.NodePoolQuota,
+	pods []corev1.Pod) *appsv1alpha1.NodePoolQuotaStatus {
+	used := zeroUsage()
+	nsUsed := make(map[string]corev1.ResourceList, len(quota.Namespaces))
+	for _, nq := range quota.Namespaces {
+		nsUsed[nq.Namespace] = zeroUsage()
+	}
+	for i := range pods {
+		usage := PodUsage(&pods[i])
+		addUsage(used, usage)
+		if rl, exist := nsUsed[pods[i].Namespace]; exist {
+			addUsage(rl, usage)
+		}
+	}
+
+	status := &appsv1alpha1.NodePoolQuotaStatus{Used: used}
+	for ns, rl := range nsUsed {
+		status.Namespaces = append(status.Namespaces,
+			appsv1alpha1.NodePoolNamespaceQuotaStatus{Namespace: ns, Used: rl})
+	}
+	sort.Slice(status.Namespaces, func(i, j int) bool {
+		return status.Namespaces[i].Namespace < status.Namespaces[j].Namespace
+	})
+	return status
+}
+
+// ExceededResources returns the sorted names of the resources in `hard` that
+// will be exceeded if `delta` is added to `used`
+func ExceededResources(hard, used, delta corev1.ResourceList) []corev1.ResourceName {
+	var exceeded []corev1.ResourceName
+	for name, limit := range hard {
+		d, exist := delta[name]
+		if !exist || d.IsZero() {
+			continue
+		}
+		total := d.DeepCopy()
+		if u, exist := used[name]; exist {
+			total.Add(u)
+		}
+		if total.Cmp(limit) > 0 {
+			exceeded = append(exceeded, name)
+		}
+	}
+	sort.Slice(exceeded, func(i, j int) bool {
+		return exceeded[i] < exceeded[j]
+	})
+	return exceeded
+}
+
+// zeroUsage returns a ResourceList with zero quantities of QuotaResources
+func zeroUsage() corev1.ResourceList {
+	return corev1.ResourceList{
+		corev1.ResourceCPU:    *resource.NewMilliQuantity(0, resource.DecimalSI),
+		corev1.ResourceMemory: *resource.NewQuantity(0, resource.BinarySI),
+		corev1.ResourcePods:   *resource.NewQuantity(0, resource.DecimalSI),
+	}
+}
+
+// addUsage adds the QuotaResources in `usage` to `total`
+func addUsage(total, usage corev1.ResourceList) {
+	for _, name := range QuotaResources {
+		if q, exist := usage[name]; exist {
+			value := total[name]
+			value.Add(q)
+			total[name] = value
+		}
+	}
+}
@@ -0,0 +1,133 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package nodepool
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func newQuotaPod(namespace, cpu, memory string) corev1.Pod {
+	return corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse(cpu),
+						corev1.ResourceMemory: resource.MustParse(memory),
+					},
+				},
+			}},
+		},
+	}
+}
+
+func TestCalculateQuotaUsage(t *testing.T) {
+	quota := &appsv1alpha1.NodePoolQuota{
+		Namespaces: []appsv1alpha1.NodePoolNamespaceQuota{
+			{Namespace: "foo"},
+			{Namespace: "bar"},
+		},
+	}
+	pods := []corev1.Pod{
+		newQuotaPod("foo", "500m", "1Gi"),
+		newQuotaPod("foo", "1", "1Gi"),
+		newQuotaPod("baz", "200m", "512Mi"),
+	}
+
+	status := CalculateQuotaUsage(quota, pods)
+	expect := map[corev1.ResourceName]string{
+		corev1.ResourceCPU:    "1700m",
+		corev1.ResourceMemory: "2560Mi",
+		corev1.ResourcePods:   "3",
+	}
+	for name, value := range expect {
+		if q := status.Used[name]; q.Cmp(resource.MustParse(value)) != 0 {
+			t.Errorf("expect used %s to be %s, but get %s", name, value, q.String())
+		}
+	}
+
+	if len(status.Namespaces) != 2 || status.Namespaces[0].Namespace != "bar" ||
+		status.Namespaces[1].Namespace != "foo" {
+		t.Fatalf("expect the usage of namespaces bar and foo, but get %v", status.Namespaces)
+	}
+	if q := status.Namespaces[0].Used[corev1.ResourcePods]; !q.IsZero() {
+		t.Errorf("expect no pod in namespace bar, but get %s", q.String())
+	}
+	if q := status.Namespaces[1].Used[corev1.ResourceCPU]; q.Cmp(resource.MustParse("1500m")) != 0 {
+		t.Errorf("expect used cpu of namespace foo to be 1500m, but get %s", q.String())
+	}
+}
+
+func TestExceededResources(t *testing.T) {
+	hard := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("2"),
+		corev1.ResourceMemory: resource.MustParse("2Gi"),
+		corev1.ResourcePods:   resource.MustParse("2"),
+	}
+	tests := []struct {
+		name   string
+		used   corev1.ResourceList
+		delta  corev1.ResourceList
+		expect []corev1.ResourceName
+	}{
+		{
+			"within the quota",
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			nil,
+		},
+		{
+			"exceed several resources",
+			corev1.ResourceList{
+				corev1.ResourceCPU:  resource.MustParse("1500m"),
+				corev1.ResourcePods: resource.MustParse("2"),
+			},
+			corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("1"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+				corev1.ResourcePods:   resource.MustParse("1"),
+			},
+			[]corev1.ResourceName{corev1.ResourceCPU, corev1.ResourcePods},
+		},
+		{
+			"resources not requested are not checked",
+			corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+			corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("1")},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			if get := ExceededResources(hard, st.used, st.delta); !reflect.DeepEqual(get, st.expect) {
+				t.Errorf("expect exceeded resources %v, but get %v", st.expect, get)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -0,0 +1,176 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutating
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util"
+	webhookutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/util"
+)
+
+// DefaultNodePoolRule decides the desired nodepool of the new nodes that
+// have the label `LabelKey=LabelValue`
+type DefaultNodePoolRule struct {
+	LabelKey   string
+	LabelValue string
+	NodePool   string
+}
+
+// ParseDefaultNodePoolRules parses the rules in the format of
+// `<label-key>=<label-value>:<nodepool>`
+func ParseDefaultNodePoolRules(rules []string) ([]DefaultNodePoolRule, error) {
+	var parsed []DefaultNodePoolRule
+	for _, rule := range rules {
+		i := strings.LastIndex(rule, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("invalid default nodepool rule %q, expect <label-key>=<label-value>:<nodepool>", rule)
+		}
+		label, pool := rule[:i], rule[i+1:]
+		kv := strings.SplitN(label, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid default nodepool rule %q, expect <label-key>=<label-value>:<nodepool>", rule)
+		}
+		if errs := validation.IsQualifiedName(kv[0]); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid label key in default nodepool rule %q: %s", rule, strings.Join(errs, ", "))
+		}
+		if errs := validation.IsValidLabelValue(kv[1]); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid label value in default nodepool rule %q: %s", rule, strings.Join(errs, ", "))
+		}
+		if pool == "" {
+			return nil, fmt.Errorf("invalid default nodepool rule %q, nodepool is empty", rule)
+		}
+		if errs := validation.IsValidLabelValue(pool); len(errs) != 0 {
+			return nil, fmt.Errorf("invalid nodepool in default nodepool rule %q: %s", rule, strings.Join(errs, ", "))
+		}
+		parsed = append(parsed, DefaultNodePoolRule{
+			LabelKey:   kv[0],
+			LabelValue: kv[1],
+			NodePool:   pool,
+		})
+	}
+	return parsed, nil
+}
+
+// NodeCreateHandler sets the desired nodepool of the new nodes
+type NodeCreateHandler struct {
+	Client client.Client
+
+	// Decoder decodes objects
+	Decoder *admission.Decoder
+
+	rules []DefaultNodePoolRule
+}
+
+var _ webhookutil.Handler = &NodeCreateHandler{}
+
+func (h *NodeCreateHandler) SetOptions(options webhookutil.Options) {
+	rules, err := ParseDefaultNodePoolRules(options.DefaultNodePoolRules)
+	if err != nil {
+		klog.Errorf("fail to parse the default nodepool rules: %v", err)
+		return
+	}
+	h.rules = rules
+}
+
+// Handle handles admission requests.
+func (h *NodeCreateHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.AdmissionRequest.Operation != admissionv1.Create || len(h.rules) == 0 {
+		return admission.Allowed("")
+	}
+
+	node := corev1.Node{}
+	err := h.Decoder.Decode(req, &node)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if _, exist := node.Labels[appsv1alpha1.LabelDesiredNodePool]; exist {
+		return admission.Allowed("")
+	}
+
+	pool := h.defaultNodePool(ctx, &node)
+	if pool == "" {
+		return admission.Allowed("")
+	}
+	klog.V(4).Infof("set the desired nodepool of node(%s) to %s", node.Name, pool)
+	node.Labels[appsv1alpha1.LabelDesiredNodePool] = pool
+
+	marshalled, err := json.Marshal(&node)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	resp := admission.PatchResponseFromRaw(req.AdmissionRequest.Object.Raw,
+		marshalled)
+	if len(resp.Patches) > 0 {
+		klog.V(5).Infof("Admit Node %s patches: %v", node.Name, util.DumpJSON(resp.Patches))
+	}
+	return resp
+}
+
+// defaultNodePool returns the nodepool of the first rule that matches the
+// node, rules pointing to nodepools that don't exist or are being deleted
+// are skipped, so that the node can still be created
+func (h *NodeCreateHandler) defaultNodePool(ctx context.Context, node *corev1.Node) string {
+	for _, rule := range h.rules {
+		if v, exist := node.Labels[rule.LabelKey]; !exist || v != rule.LabelValue {
+			continue
+		}
+		np := appsv1alpha1.NodePool{}
+		if err := h.Client.Get(ctx, types.NamespacedName{Name: rule.NodePool}, &np); err != nil {
+			klog.Warningf("skip the default nodepool(%s) for node(%s): %v",
+				rule.NodePool, node.Name, err)
+			continue
+		}
+		if np.DeletionTimestamp != nil {
+			klog.Warningf("skip the default nodepool(%s) for node(%s) as it is being deleted",
+				rule.NodePool, node.Name)
+			continue
+		}
+		return rule.NodePool
+	}
+	return ""
+}
+
+var _ admission.DecoderInjector = &NodeCreateHandler{}
+
+// InjectDecoder injects the decoder into the NodeCreateHandler
+func (h *NodeCreateHandler) InjectDecoder(d *admission.Decoder) error {
+	h.Decoder = d
+	return nil
+}
+
+var _ inject.Client = &NodeCreateHandler{}
+
+// InjectClient injects the client into the NodeCreateHandler
+func (h *NodeCreateHandler) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutating
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseDefaultNodePoolRules(t *testing.T) {
+	rules, err := ParseDefaultNodePoolRules([]string{
+		"openyurt.io/is-edge-worker=true:default-edge-nodepool",
+		"site=:unknown-site",
+	})
+	if err != nil {
+		t.Fatalf("expected success: %v", err)
+	}
+	expect := []DefaultNodePoolRule{
+		{LabelKey: "openyurt.io/is-edge-worker", LabelValue: "true", NodePool: "default-edge-nodepool"},
+		{LabelKey: "site", LabelValue: "", NodePool: "unknown-site"},
+	}
+	if !reflect.DeepEqual(rules, expect) {
+		t.Errorf("expect %v, but get %v", expect, rules)
+	}
+
+	errorCases := []string{
+		"site=hangzhou",
+		"site:hangzhou",
+		"=hangzhou:hangzhou",
+		"site=hangzhou:",
+		"site=hang zhou:hangzhou",
+		"site=hangzhou:hang/zhou",
+	}
+	for _, rule := range errorCases {
+		if _, err := ParseDefaultNodePoolRules([]string{rule}); err == nil {
+			t.Errorf("expected failure for %q", rule)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	webhookutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/util"
+)
+
+// NodeCreateUpdateHandler validates the nodepool related labels of Node
+type NodeCreateUpdateHandler struct {
+	Client client.Client
+
+	// Decoder decodes objects
+	Decoder *admission.Decoder
+
+	nodePoolChangeProtection bool
+}
+
+var _ webhookutil.Handler = &NodeCreateUpdateHandler{}
+
+func (h *NodeCreateUpdateHandler) SetOptions(options webhookutil.Options) {
+	h.nodePoolChangeProtection = options.NodePoolChangeProtection
+}
+
+// Handle handles admission requests.
+func (h *NodeCreateUpdateHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	node := corev1.Node{}
+
+	switch req.AdmissionRequest.Operation {
+	case admissionv1.Create:
+		klog.V(4).Info("capture the node creation request")
+		err := h.Decoder.Decode(req, &node)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if allErrs := validateNodeCreate(h.Client, &node); len(allErrs) > 0 {
+			return admission.Errored(http.StatusUnprocessableEntity,
+				allErrs.ToAggregate())
+		}
+	case admissionv1.Update:
+		klog.V(4).Info("capture the node update request")
+		err := h.Decoder.Decode(req, &node)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		oNode := corev1.Node{}
+		err = h.Decoder.DecodeRaw(req.OldObject, &oNode)
+		if err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if allErrs := validateNodeUpdate(h.Client, &node, &oNode,
+			h.nodePoolChangeProtection); len(allErrs) > 0 {
+			return admission.Errored(http.StatusUnprocessableEntity,
+				allErrs.ToAggregate())
+		}
+	}
+
+	return admission.ValidationResponse(true, "")
+}
+
+var _ admission.DecoderInjector = &NodeCreateUpdateHandler{}
+
+// InjectDecoder injects the decoder into the NodeCreateUpdateHandler
+func (h *NodeCreateUpdateHandler) InjectDecoder(d *admission.Decoder) error {
+	h.Decoder = d
+	return nil
+}
+
+var _ inject.Client = &NodeCreateUpdateHandler{}
+
+// InjectClient injects the client into the NodeCreateUpdateHandler
+func (h *NodeCreateUpdateHandler) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
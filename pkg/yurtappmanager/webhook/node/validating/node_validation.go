@@ -0,0 +1,161 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+var (
+	desiredNodePoolPath   = field.NewPath("metadata").Child("labels").Key(appsv1alpha1.LabelDesiredNodePool)
+	migrateToNodePoolPath = field.NewPath("metadata").Child("annotations").Key(appsv1alpha1.AnnotationMigrateToNodePool)
+)
+
+// validateNodePoolRef checks if the nodepool specified by the fldPath, i.e.
+// the desired-nodepool label or the migrate-to annotation, exists and is not
+// being deleted
+func validateNodePoolRef(cli client.Client, fldPath *field.Path, pool string) field.ErrorList {
+	np := appsv1alpha1.NodePool{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: pool}, &np); err != nil {
+		if apierrors.IsNotFound(err) {
+			return field.ErrorList([]*field.Error{
+				field.Invalid(fldPath, pool,
+					fmt.Sprintf("nodepool %s doesn't exist", pool))})
+		}
+		return field.ErrorList([]*field.Error{
+			field.InternalError(fldPath,
+				fmt.Errorf("fail to get nodepool %s: %v", pool, err))})
+	}
+	if np.DeletionTimestamp != nil {
+		return field.ErrorList([]*field.Error{
+			field.Invalid(fldPath, pool,
+				fmt.Sprintf("nodepool %s is being deleted", pool))})
+	}
+	return nil
+}
+
+// isNodePoolReleasing checks if the nodepool is being deleted or is gone, its
+// nodes are released by the nodepool controller and can't be kept in the pool
+func isNodePoolReleasing(cli client.Client, pool string) (bool, error) {
+	np := appsv1alpha1.NodePool{}
+	if err := cli.Get(context.TODO(), types.NamespacedName{Name: pool}, &np); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	return np.DeletionTimestamp != nil, nil
+}
+
+// validateNodePoolChange prevents the node from leaving its current pool if
+// it still runs pods of the pool-scoped workloads, e.g. the UnitedDeployment
+// and the YurtAppDaemon, which are pinned to the pool by the nodeSelector or
+// the required node affinity and will not match them any more
+func validateNodePoolChange(cli client.Client, node *corev1.Node) field.ErrorList {
+	currentPool := node.Labels[appsv1alpha1.LabelCurrentNodePool]
+	if currentPool == "" {
+		return nil
+	}
+
+	pods := corev1.PodList{}
+	if err := cli.List(context.TODO(), &pods, client.MatchingFields{
+		fieldindex.IndexNameForPodNodeName: node.Name,
+	}); err != nil {
+		return field.ErrorList([]*field.Error{
+			field.InternalError(desiredNodePoolPath,
+				fmt.Errorf("fail to list pods on node %s: %v", node.Name, err))})
+	}
+
+	var poolPods []string
+	for _, pod := range pods.Items {
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if nodepoolutil.PinnedNodePool(&pod) == currentPool {
+			poolPods = append(poolPods, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+		}
+	}
+	if len(poolPods) != 0 {
+		return field.ErrorList([]*field.Error{
+			field.Forbidden(desiredNodePoolPath,
+				fmt.Sprintf("node %s still runs pods of the pool-scoped workloads in nodepool %s: %v, please drain them before changing the nodepool",
+					node.Name, currentPool, poolPods))})
+	}
+	return nil
+}
+
+// validateNodeCreate validates the desired-nodepool label and the migrate-to
+// annotation of the new node
+func validateNodeCreate(cli client.Client, node *corev1.Node) field.ErrorList {
+	allErrs := field.ErrorList{}
+	if pool := node.Labels[appsv1alpha1.LabelDesiredNodePool]; pool != "" {
+		allErrs = append(allErrs, validateNodePoolRef(cli, desiredNodePoolPath, pool)...)
+	}
+	if pool := node.Annotations[appsv1alpha1.AnnotationMigrateToNodePool]; pool != "" {
+		allErrs = append(allErrs, validateNodePoolRef(cli, migrateToNodePoolPath, pool)...)
+	}
+	return allErrs
+}
+
+// validateNodeUpdate validates the change of the desired-nodepool label and
+// the migrate-to annotation
+func validateNodeUpdate(cli client.Client, node, oldNode *corev1.Node,
+	nodePoolChangeProtection bool) field.ErrorList {
+	allErrs := field.ErrorList{}
+	target := node.Annotations[appsv1alpha1.AnnotationMigrateToNodePool]
+	if target != "" && target != oldNode.Annotations[appsv1alpha1.AnnotationMigrateToNodePool] {
+		allErrs = append(allErrs, validateNodePoolRef(cli, migrateToNodePoolPath, target)...)
+	}
+
+	pool := node.Labels[appsv1alpha1.LabelDesiredNodePool]
+	oldPool := oldNode.Labels[appsv1alpha1.LabelDesiredNodePool]
+	if pool == oldPool {
+		return allErrs
+	}
+
+	if pool != "" {
+		allErrs = append(allErrs, validateNodePoolRef(cli, desiredNodePoolPath, pool)...)
+	}
+	if !nodePoolChangeProtection {
+		return allErrs
+	}
+	// the nodepool controller removes the desired-nodepool label of the nodes
+	// when the pool is deleted, which must not be blocked
+	if oldPool != "" {
+		releasing, err := isNodePoolReleasing(cli, oldPool)
+		if err != nil {
+			return append(allErrs, field.InternalError(desiredNodePoolPath,
+				fmt.Errorf("fail to get nodepool %s: %v", oldPool, err)))
+		}
+		if releasing {
+			return allErrs
+		}
+	}
+	allErrs = append(allErrs, validateNodePoolChange(cli, oldNode)...)
+	return allErrs
+}
@@ -50,9 +50,12 @@ func (h *NodePoolCreateUpdateHandler) Handle(ctx context.Context, req admission.
 		return admission.Errored(http.StatusBadRequest, err)
 	}
 
-	klog.V(5).Infof("set the nodepool(%s) selector", np.Name)
-	np.Spec.Selector = &metav1.LabelSelector{
-		MatchLabels: map[string]string{appsv1alpha1.LabelCurrentNodePool: np.Name},
+	// only set the default selector if the user doesn't specify one
+	if np.Spec.Selector == nil {
+		klog.V(5).Infof("set the nodepool(%s) selector", np.Name)
+		np.Spec.Selector = &metav1.LabelSelector{
+			MatchLabels: map[string]string{appsv1alpha1.LabelCurrentNodePool: np.Name},
+		}
 	}
 
 	marshalled, err := json.Marshal(&np)
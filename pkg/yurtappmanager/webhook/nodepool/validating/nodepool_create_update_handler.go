@@ -55,7 +55,10 @@ func (h *NodePoolCreateUpdateHandler) Handle(ctx context.Context, req admission.
 		if err != nil {
 			return admission.Errored(http.StatusBadRequest, err)
 		}
-		if allErrs := validateNodePoolSpec(&np.Spec); len(allErrs) > 0 {
+		allErrs := validateNodePoolName(np.Name)
+		allErrs = append(allErrs, validateNodePoolSpec(&np.Spec)...)
+		allErrs = append(allErrs, validateNodePoolParent(h.Client, &np)...)
+		if len(allErrs) > 0 {
 			return admission.Errored(http.StatusUnprocessableEntity,
 				allErrs.ToAggregate())
 		}
@@ -75,6 +78,12 @@ func (h *NodePoolCreateUpdateHandler) Handle(ctx context.Context, req admission.
 			return admission.Errored(http.StatusUnprocessableEntity,
 				allErrs.ToAggregate())
 		}
+		if np.Spec.Parent != onp.Spec.Parent {
+			if allErrs := validateNodePoolParent(h.Client, &np); len(allErrs) > 0 {
+				return admission.Errored(http.StatusUnprocessableEntity,
+					allErrs.ToAggregate())
+			}
+		}
 	case admissionv1.Delete:
 		klog.V(4).Info("capture the nodepool deletion request")
 		err := h.Decoder.DecodeRaw(req.OldObject, &np)
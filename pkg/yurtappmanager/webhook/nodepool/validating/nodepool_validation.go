@@ -20,13 +20,61 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
 
 	corev1 "k8s.io/api/core/v1"
 	apivalidation "k8s.io/apimachinery/pkg/api/validation"
+	unversionedvalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/fieldindex"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+var (
+	// reservedLabelKeys are maintained by the nodepool controller or by users
+	// to decide the node membership, so they can't be set through the pool
+	reservedLabelKeys = sets.NewString(
+		appsv1alpha1.LabelCurrentNodePool,
+		appsv1alpha1.LabelDesiredNodePool,
+		appsv1alpha1.LabelNodePoolLeader,
+	)
+
+	// reservedAnnotationKeys are maintained by the nodepool controller
+	reservedAnnotationKeys = sets.NewString(
+		appsv1alpha1.AnnotationPrevAttrs,
+		appsv1alpha1.AnnotationCordonedByNodePool,
+		appsv1alpha1.AnnotationMigrateToNodePool,
+	)
+
+	supportedPoolTypes = sets.NewString(
+		string(appsv1alpha1.Edge),
+		string(appsv1alpha1.Cloud),
+	)
+
+	supportedDeletionPolicies = sets.NewString(
+		string(appsv1alpha1.KeepDesiredLabel),
+		string(appsv1alpha1.RemoveDesiredLabel),
+		string(appsv1alpha1.BlockDeletion),
+	)
+
+	supportedQuotaResources = sets.NewString(
+		string(corev1.ResourceCPU),
+		string(corev1.ResourceMemory),
+		string(corev1.ResourcePods),
+	)
+
+	supportedTaintEffects = sets.NewString(
+		string(corev1.TaintEffectNoSchedule),
+		string(corev1.TaintEffectPreferNoSchedule),
+		string(corev1.TaintEffectNoExecute),
+	)
 )
 
 // annotationValidator validates the NodePool.Spec.Annotations
@@ -44,33 +92,288 @@ func validateNodePoolSpecAnnotations(annotations map[string]string) field.ErrorL
 			field.Invalid(field.NewPath("spec").Child("annotations"),
 				annotations, "invalid annotations")})
 	}
+	allErrs := field.ErrorList{}
+	for k := range annotations {
+		if reservedAnnotationKeys.Has(k) {
+			allErrs = append(allErrs, field.Forbidden(
+				field.NewPath("spec").Child("annotations").Key(k),
+				"the annotation is reserved for the nodepool controller"))
+		}
+	}
+	return allErrs
+}
+
+// validateNodePoolSpecLabels validates the NodePool.Spec.Labels, which should
+// be valid label keys and values and must not use the reserved keys
+func validateNodePoolSpecLabels(labels map[string]string) field.ErrorList {
+	fldPath := field.NewPath("spec").Child("labels")
+	allErrs := unversionedvalidation.ValidateLabels(labels, fldPath)
+	for k := range labels {
+		if reservedLabelKeys.Has(k) {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Key(k),
+				"the label is reserved for the nodepool membership"))
+		}
+	}
+	return allErrs
+}
+
+// validateNodePoolSpecTaints validates the NodePool.Spec.Taints, the taint
+// keys and values should be valid label keys and values, the effects should
+// be supported and the key and effect pairs should be unique
+func validateNodePoolSpecTaints(taints []corev1.Taint) field.ErrorList {
+	fldPath := field.NewPath("spec").Child("taints")
+	allErrs := field.ErrorList{}
+	keyEffects := sets.NewString()
+	for i, taint := range taints {
+		idxPath := fldPath.Index(i)
+		allErrs = append(allErrs, unversionedvalidation.ValidateLabelName(taint.Key, idxPath.Child("key"))...)
+		if taint.Value != "" {
+			for _, msg := range validation.IsValidLabelValue(taint.Value) {
+				allErrs = append(allErrs, field.Invalid(idxPath.Child("value"), taint.Value, msg))
+			}
+		}
+		if !supportedTaintEffects.Has(string(taint.Effect)) {
+			allErrs = append(allErrs, field.NotSupported(idxPath.Child("effect"),
+				taint.Effect, supportedTaintEffects.List()))
+		}
+
+		keyEffect := fmt.Sprintf("%s:%s", taint.Key, taint.Effect)
+		if keyEffects.Has(keyEffect) {
+			allErrs = append(allErrs, field.Duplicate(idxPath,
+				fmt.Sprintf("taint with key %s and effect %s", taint.Key, taint.Effect)))
+		}
+		keyEffects.Insert(keyEffect)
+	}
+	return allErrs
+}
+
+// validateNodePoolSpecSelector validates the NodePool.Spec.Selector
+func validateNodePoolSpecSelector(spec *appsv1alpha1.NodePoolSpec) field.ErrorList {
+	if spec.Selector == nil {
+		return nil
+	}
+	return unversionedvalidation.ValidateLabelSelector(spec.Selector,
+		field.NewPath("spec").Child("selector"))
+}
+
+// validateIntOrPercent validates that the value is a non-negative integer or
+// a percentage between 0% and 100%
+func validateIntOrPercent(value *intstr.IntOrString, fldPath *field.Path) field.ErrorList {
+	if value == nil {
+		return nil
+	}
+	num, err := intstr.GetValueFromIntOrPercent(value, 100, false)
+	if err != nil {
+		return field.ErrorList{field.Invalid(fldPath, value.String(), err.Error())}
+	}
+	if num < 0 || (value.Type == intstr.String && num > 100) {
+		return field.ErrorList{field.Invalid(fldPath, value.String(),
+			"must be a non-negative integer or a percentage between 0% and 100%")}
+	}
 	return nil
 }
 
+// validateNodePoolSpecPolicies validates the NodePool.Spec.Type and the
+// attributes derived from it, the NodePool.Spec.HealthPolicy and the
+// NodePool.Spec.DeletionPolicy
+func validateNodePoolSpecPolicies(spec *appsv1alpha1.NodePoolSpec) field.ErrorList {
+	fldPath := field.NewPath("spec")
+	allErrs := field.ErrorList{}
+	if spec.Type != "" && !supportedPoolTypes.Has(string(spec.Type)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("type"),
+			spec.Type, supportedPoolTypes.List()))
+	}
+	if spec.Autonomy && spec.Type != appsv1alpha1.Edge {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("autonomy"),
+			spec.Autonomy, "autonomy is only supported by the Edge pools"))
+	}
+	if _, exist := spec.Labels[appsv1alpha1.LabelEdgeWorker]; exist &&
+		spec.Type != "" && !spec.DisableTypeAttributes {
+		allErrs = append(allErrs, field.Invalid(
+			fldPath.Child("labels").Key(appsv1alpha1.LabelEdgeWorker),
+			spec.Labels[appsv1alpha1.LabelEdgeWorker],
+			"the label is decided by the pool type unless disableTypeAttributes is set"))
+	}
+	if spec.DeletionPolicy != "" && !supportedDeletionPolicies.Has(string(spec.DeletionPolicy)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("deletionPolicy"),
+			spec.DeletionPolicy, supportedDeletionPolicies.List()))
+	}
+	if hp := spec.HealthPolicy; hp != nil {
+		allErrs = append(allErrs, validateIntOrPercent(hp.MinReadyNodes,
+			fldPath.Child("healthPolicy", "minReadyNodes"))...)
+		allErrs = append(allErrs, validateIntOrPercent(hp.MaxUnreadyNodes,
+			fldPath.Child("healthPolicy", "maxUnreadyNodes"))...)
+	}
+	return allErrs
+}
+
+// validateResourceList validates that the resources of the quota are
+// supported and the quantities are non-negative
+func validateResourceList(rl corev1.ResourceList, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for name, q := range rl {
+		if !supportedQuotaResources.Has(string(name)) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Key(string(name)),
+				name, supportedQuotaResources.List()))
+			continue
+		}
+		if q.Sign() < 0 {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(string(name)),
+				q.String(), "must be greater than or equal to 0"))
+		}
+	}
+	return allErrs
+}
+
+// validateNodePoolSpecQuota validates the NodePool.Spec.Quota, the namespaces
+// should be valid and unique
+func validateNodePoolSpecQuota(quota *appsv1alpha1.NodePoolQuota) field.ErrorList {
+	if quota == nil {
+		return nil
+	}
+	fldPath := field.NewPath("spec").Child("quota")
+	allErrs := validateResourceList(quota.Hard, fldPath.Child("hard"))
+	namespaces := sets.NewString()
+	for i, nq := range quota.Namespaces {
+		idxPath := fldPath.Child("namespaces").Index(i)
+		for _, msg := range apivalidation.ValidateNamespaceName(nq.Namespace, false) {
+			allErrs = append(allErrs, field.Invalid(idxPath.Child("namespace"), nq.Namespace, msg))
+		}
+		if namespaces.Has(nq.Namespace) {
+			allErrs = append(allErrs, field.Duplicate(idxPath.Child("namespace"), nq.Namespace))
+		}
+		namespaces.Insert(nq.Namespace)
+		allErrs = append(allErrs, validateResourceList(nq.Hard, idxPath.Child("hard"))...)
+	}
+	return allErrs
+}
+
+// validateRegistryHost validates the registry host in the format of
+// `<host>[:<port>]`, which is the registry component of an image
+func validateRegistryHost(host string) []string {
+	var msgs []string
+	name := host
+	if i := strings.LastIndex(host, ":"); i != -1 {
+		name = host[:i]
+		msgs = append(msgs, validation.IsValidPortNum(parsePort(host[i+1:]))...)
+	}
+	if name != "localhost" {
+		msgs = append(msgs, validation.IsDNS1123Subdomain(name)...)
+	}
+	return msgs
+}
+
+// parsePort parses the port number, it returns -1 if the port is invalid
+func parsePort(port string) int {
+	num, err := strconv.Atoi(port)
+	if err != nil {
+		return -1
+	}
+	return num
+}
+
+// validateNodePoolSpecImageRegistryMirrors validates the
+// NodePool.Spec.ImageRegistryMirrors, the keys should be registry hosts and
+// the values should be registry hosts with optional paths
+func validateNodePoolSpecImageRegistryMirrors(mirrors map[string]string) field.ErrorList {
+	fldPath := field.NewPath("spec").Child("imageRegistryMirrors")
+	allErrs := field.ErrorList{}
+	for registry, mirror := range mirrors {
+		for _, msg := range validateRegistryHost(registry) {
+			allErrs = append(allErrs, field.Invalid(fldPath, registry, msg))
+		}
+		host := strings.SplitN(mirror, "/", 2)[0]
+		for _, msg := range validateRegistryHost(host) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Key(registry), mirror, msg))
+		}
+	}
+	return allErrs
+}
+
+// validateNodePoolName validates the nodepool name, which will be used as
+// the value of the nodepool labels on the member nodes
+func validateNodePoolName(name string) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsValidLabelValue(name) {
+		allErrs = append(allErrs, field.Invalid(
+			field.NewPath("metadata").Child("name"), name, msg))
+	}
+	return allErrs
+}
+
 // validateNodePoolSpec validates the nodepool spec.
 func validateNodePoolSpec(spec *appsv1alpha1.NodePoolSpec) field.ErrorList {
-	if allErrs := validateNodePoolSpecAnnotations(spec.Annotations); allErrs != nil {
-		return allErrs
-	}
-	return nil
+	allErrs := validateNodePoolSpecAnnotations(spec.Annotations)
+	allErrs = append(allErrs, validateNodePoolSpecLabels(spec.Labels)...)
+	allErrs = append(allErrs, validateNodePoolSpecTaints(spec.Taints)...)
+	allErrs = append(allErrs, validateNodePoolSpecSelector(spec)...)
+	allErrs = append(allErrs, validateNodePoolSpecPolicies(spec)...)
+	allErrs = append(allErrs, validateNodePoolSpecQuota(spec.Quota)...)
+	allErrs = append(allErrs, validateNodePoolSpecImageRegistryMirrors(spec.ImageRegistryMirrors)...)
+	return allErrs
 }
 
 // validateNodePoolSpecUpdate tests if required fields in the NodePool spec are set.
 func validateNodePoolSpecUpdate(spec, oldSpec *appsv1alpha1.NodePoolSpec) field.ErrorList {
-	if allErrs := validateNodePoolSpec(spec); allErrs != nil {
+	if allErrs := validateNodePoolSpec(spec); len(allErrs) > 0 {
 		return allErrs
 	}
 
-	if spec.Type != oldSpec.Type {
+	if oldSpec.Type != "" && spec.Type == "" {
 		return field.ErrorList([]*field.Error{
 			field.Invalid(field.NewPath("spec").Child("type"),
-				spec.Annotations, "pool type can't be changed")})
+				spec.Type, "pool type can't be unset")})
+	}
+	return nil
+}
+
+// validateNodePoolParent checks that the parent of the pool exists and is not
+// being deleted, and that the pool is not an ancestor of its parent
+func validateNodePoolParent(cli client.Client, np *appsv1alpha1.NodePool) field.ErrorList {
+	fldPath := field.NewPath("spec").Child("parent")
+	parent := np.Spec.Parent
+	if parent == "" {
+		return nil
+	}
+	if parent == np.Name {
+		return field.ErrorList{field.Invalid(fldPath, parent,
+			"the pool can't be its own parent")}
+	}
+
+	pools := appsv1alpha1.NodePoolList{}
+	if err := cli.List(context.TODO(), &pools); err != nil {
+		return field.ErrorList{field.InternalError(fldPath,
+			fmt.Errorf("fail to list nodepools: %v", err))}
+	}
+	var parentPool *appsv1alpha1.NodePool
+	for i := range pools.Items {
+		if pools.Items[i].Name == parent {
+			parentPool = &pools.Items[i]
+			break
+		}
+	}
+	if parentPool == nil {
+		return field.ErrorList{field.Invalid(fldPath, parent,
+			fmt.Sprintf("nodepool %s doesn't exist", parent))}
+	}
+	if parentPool.DeletionTimestamp != nil {
+		return field.ErrorList{field.Invalid(fldPath, parent,
+			fmt.Sprintf("nodepool %s is being deleted", parent))}
+	}
+	for _, a := range nodepoolutil.Ancestors(parentPool, pools.Items) {
+		if a.Name == np.Name {
+			return field.ErrorList{field.Invalid(fldPath, parent,
+				fmt.Sprintf("nodepool %s is a descendant of the pool", parent))}
+		}
 	}
 	return nil
 }
 
 // validateNodePoolDeletion validate the nodepool deletion event, which prevents
-// the default-nodepool from being deleted
+// the default-nodepool and the pools that have child pools from being deleted.
+// The member nodes of the pool will be cleaned up by the nodepool controller,
+// unless the pool's DeletionPolicy is Block and there are nodes whose
+// desired-nodepool label pointing to the pool
 func validateNodePoolDeletion(cli client.Client, np *appsv1alpha1.NodePool) field.ErrorList {
 	nodes := corev1.NodeList{}
 
@@ -80,8 +383,26 @@ func validateNodePoolDeletion(cli client.Client, np *appsv1alpha1.NodePool) fiel
 				fmt.Sprintf("default nodepool %s forbiden to delete", np.Name))})
 	}
 
+	pools := appsv1alpha1.NodePoolList{}
+	if err := cli.List(context.TODO(), &pools); err != nil {
+		return field.ErrorList([]*field.Error{
+			field.Forbidden(field.NewPath("metadata").Child("name"),
+				"fail to get the child pools")})
+	}
+	if children := nodepoolutil.Children(np.Name, pools.Items); len(children) != 0 {
+		return field.ErrorList([]*field.Error{
+			field.Forbidden(field.NewPath("metadata").Child("name"),
+				fmt.Sprintf("cannot remove pool with child pools %v, please remove or re-parent them before deleting", children))})
+	}
+
+	if np.Spec.DeletionPolicy != appsv1alpha1.BlockDeletion {
+		return nil
+	}
+
 	if err := cli.List(context.TODO(), &nodes,
-		client.MatchingLabels(np.Spec.Selector.MatchLabels)); err != nil {
+		client.MatchingFields{
+			fieldindex.IndexNameForNodeDesiredNodePool: np.Name,
+		}); err != nil {
 		return field.ErrorList([]*field.Error{
 			field.Forbidden(field.NewPath("metadata").Child("name"),
 				"fail to get nodes associated to the pool")})
@@ -89,7 +410,8 @@ func validateNodePoolDeletion(cli client.Client, np *appsv1alpha1.NodePool) fiel
 	if len(nodes.Items) != 0 {
 		return field.ErrorList([]*field.Error{
 			field.Forbidden(field.NewPath("metadata").Child("name"),
-				"cannot remove nonempty pool, please drain the pool before deleting")})
+				fmt.Sprintf("cannot remove nonempty pool with deletion policy %s, please remove the %s label from its nodes before deleting",
+					appsv1alpha1.BlockDeletion, appsv1alpha1.LabelDesiredNodePool))})
 	}
 	return nil
 }
@@ -0,0 +1,214 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestValidateNodePoolSpec(t *testing.T) {
+	validPercent := intstr.FromString("30%")
+	invalidPercent := intstr.FromString("130%")
+	negative := intstr.FromInt(-1)
+
+	successCases := map[string]appsv1alpha1.NodePoolSpec{
+		"empty spec": {},
+		"full spec": {
+			Type: appsv1alpha1.Edge,
+			Selector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{
+						Key:      "site",
+						Operator: metav1.LabelSelectorOpIn,
+						Values:   []string{"a", "b"},
+					},
+				},
+			},
+			Labels:      map[string]string{"example.com/site": "hangzhou"},
+			Annotations: map[string]string{"example.com/owner": "foo"},
+			Taints: []corev1.Taint{
+				{Key: "example.com/site", Value: "hangzhou", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "example.com/site", Value: "hangzhou", Effect: corev1.TaintEffectNoExecute},
+			},
+			HealthPolicy: &appsv1alpha1.NodePoolHealthPolicy{
+				MinReadyNodes: &validPercent,
+			},
+			DeletionPolicy: appsv1alpha1.RemoveDesiredLabel,
+			Autonomy:       true,
+			ImageRegistryMirrors: map[string]string{
+				"docker.io":      "registry.site-a.local:5000",
+				"localhost:5000": "registry.site-a.local/localhost",
+			},
+			Quota: &appsv1alpha1.NodePoolQuota{
+				Hard: corev1.ResourceList{
+					corev1.ResourceCPU:  resource.MustParse("8"),
+					corev1.ResourcePods: resource.MustParse("100"),
+				},
+				Namespaces: []appsv1alpha1.NodePoolNamespaceQuota{
+					{
+						Namespace: "default",
+						Hard:      corev1.ResourceList{corev1.ResourceMemory: resource.MustParse("4Gi")},
+					},
+				},
+			},
+		},
+		"type attributes disabled": {
+			Type:                  appsv1alpha1.Cloud,
+			DisableTypeAttributes: true,
+			Labels:                map[string]string{appsv1alpha1.LabelEdgeWorker: "true"},
+		},
+	}
+	for name, spec := range successCases {
+		if errs := validateNodePoolSpec(&spec); len(errs) != 0 {
+			t.Errorf("expected success for %s: %v", name, errs)
+		}
+	}
+
+	errorCases := map[string]struct {
+		spec  appsv1alpha1.NodePoolSpec
+		field string
+	}{
+		"invalid label key": {
+			appsv1alpha1.NodePoolSpec{Labels: map[string]string{"a/b/c": "d"}},
+			"spec.labels",
+		},
+		"invalid label value": {
+			appsv1alpha1.NodePoolSpec{Labels: map[string]string{"a": "b c"}},
+			"spec.labels",
+		},
+		"reserved label": {
+			appsv1alpha1.NodePoolSpec{Labels: map[string]string{appsv1alpha1.LabelCurrentNodePool: "foo"}},
+			"spec.labels[apps.openyurt.io/nodepool]",
+		},
+		"reserved annotation": {
+			appsv1alpha1.NodePoolSpec{Annotations: map[string]string{appsv1alpha1.AnnotationPrevAttrs: "{}"}},
+			"spec.annotations[nodepool.openyurt.io/previous-attributes]",
+		},
+		"invalid taint effect": {
+			appsv1alpha1.NodePoolSpec{Taints: []corev1.Taint{{Key: "foo", Effect: "Invalid"}}},
+			"spec.taints[0].effect",
+		},
+		"duplicated taints": {
+			appsv1alpha1.NodePoolSpec{Taints: []corev1.Taint{
+				{Key: "foo", Value: "a", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "foo", Value: "b", Effect: corev1.TaintEffectNoSchedule},
+			}},
+			"spec.taints[1]",
+		},
+		"invalid selector": {
+			appsv1alpha1.NodePoolSpec{Selector: &metav1.LabelSelector{
+				MatchExpressions: []metav1.LabelSelectorRequirement{
+					{Key: "site", Operator: metav1.LabelSelectorOpIn},
+				},
+			}},
+			"spec.selector.matchExpressions[0].values",
+		},
+		"invalid pool type": {
+			appsv1alpha1.NodePoolSpec{Type: "Fog"},
+			"spec.type",
+		},
+		"autonomy of cloud pool": {
+			appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Cloud, Autonomy: true},
+			"spec.autonomy",
+		},
+		"edge worker label conflicts with pool type": {
+			appsv1alpha1.NodePoolSpec{
+				Type:   appsv1alpha1.Cloud,
+				Labels: map[string]string{appsv1alpha1.LabelEdgeWorker: "true"},
+			},
+			"spec.labels[openyurt.io/is-edge-worker]",
+		},
+		"invalid deletion policy": {
+			appsv1alpha1.NodePoolSpec{DeletionPolicy: "Orphan"},
+			"spec.deletionPolicy",
+		},
+		"invalid percentage": {
+			appsv1alpha1.NodePoolSpec{HealthPolicy: &appsv1alpha1.NodePoolHealthPolicy{
+				MaxUnreadyNodes: &invalidPercent,
+			}},
+			"spec.healthPolicy.maxUnreadyNodes",
+		},
+		"negative number": {
+			appsv1alpha1.NodePoolSpec{HealthPolicy: &appsv1alpha1.NodePoolHealthPolicy{
+				MinReadyNodes: &negative,
+			}},
+			"spec.healthPolicy.minReadyNodes",
+		},
+		"unsupported quota resource": {
+			appsv1alpha1.NodePoolSpec{Quota: &appsv1alpha1.NodePoolQuota{
+				Hard: corev1.ResourceList{corev1.ResourceStorage: resource.MustParse("1Gi")},
+			}},
+			"spec.quota.hard[storage]",
+		},
+		"negative quota": {
+			appsv1alpha1.NodePoolSpec{Quota: &appsv1alpha1.NodePoolQuota{
+				Hard: corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("-1")},
+			}},
+			"spec.quota.hard[cpu]",
+		},
+		"duplicated quota namespaces": {
+			appsv1alpha1.NodePoolSpec{Quota: &appsv1alpha1.NodePoolQuota{
+				Namespaces: []appsv1alpha1.NodePoolNamespaceQuota{
+					{Namespace: "foo"},
+					{Namespace: "foo"},
+				},
+			}},
+			"spec.quota.namespaces[1].namespace",
+		},
+		"invalid registry": {
+			appsv1alpha1.NodePoolSpec{ImageRegistryMirrors: map[string]string{
+				"https://docker.io": "registry.site-a.local",
+			}},
+			"spec.imageRegistryMirrors",
+		},
+		"invalid mirror": {
+			appsv1alpha1.NodePoolSpec{ImageRegistryMirrors: map[string]string{
+				"docker.io": "registry.site-a.local:port",
+			}},
+			"spec.imageRegistryMirrors[docker.io]",
+		},
+	}
+	for name, tc := range errorCases {
+		errs := validateNodePoolSpec(&tc.spec)
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %s", name)
+			continue
+		}
+		for _, err := range errs {
+			if !strings.HasPrefix(err.Field, tc.field) {
+				t.Errorf("%s: unexpected error field %s: %v", name, err.Field, err)
+			}
+		}
+	}
+}
+
+func TestValidateNodePoolName(t *testing.T) {
+	if errs := validateNodePoolName("hangzhou-site-1"); len(errs) != 0 {
+		t.Errorf("expected success: %v", errs)
+	}
+	if errs := validateNodePoolName(strings.Repeat("a", 64)); len(errs) == 0 {
+		t.Errorf("expected failure for the name longer than 63 characters")
+	}
+}
@@ -0,0 +1,212 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutating
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	"github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+	webhookutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/util"
+)
+
+// PodCreateHandler restricts the new pods to the nodepools allowed by their
+// namespace through the node affinity, injects the pool context into the pods
+// that ask for it, and rewrites the images of the pods to pull from the image
+// registry mirrors of their pool
+type PodCreateHandler struct {
+	Client client.Client
+
+	// Decoder decodes objects
+	Decoder *admission.Decoder
+}
+
+var _ webhookutil.Handler = &PodCreateHandler{}
+
+func (h *PodCreateHandler) SetOptions(options webhookutil.Options) {
+	return
+}
+
+// Handle handles admission requests.
+func (h *PodCreateHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.AdmissionRequest.Operation != admissionv1.Create ||
+		req.AdmissionRequest.SubResource != "" {
+		return admission.Allowed("")
+	}
+
+	pod := corev1.Pod{}
+	err := h.Decoder.Decode(req, &pod)
+	if err != nil {
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	if _, exist := pod.Annotations[corev1.MirrorPodAnnotationKey]; exist {
+		return admission.Allowed("")
+	}
+	if pod.Namespace == "" {
+		pod.Namespace = req.AdmissionRequest.Namespace
+	}
+
+	affinityInjected, err := h.injectNodePoolAffinity(ctx, &pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	np, err := h.podNodePool(ctx, &pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	contextInjected := injectPoolContext(&pod, np)
+	imagesRewritten, err := h.rewriteImages(ctx, &pod, np)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	if !affinityInjected && !contextInjected && !imagesRewritten {
+		return admission.Allowed("")
+	}
+
+	marshalled, err := json.Marshal(&pod)
+	if err != nil {
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+	resp := admission.PatchResponseFromRaw(req.AdmissionRequest.Object.Raw,
+		marshalled)
+	if len(resp.Patches) > 0 {
+		klog.V(5).Infof("Admit Pod %s/%s patches: %v", pod.Namespace, pod.Name, util.DumpJSON(resp.Patches))
+	}
+	return resp
+}
+
+// injectNodePoolAffinity restricts the pod to the nodepools allowed by its
+// namespace, it returns true if the pod is changed
+func (h *PodCreateHandler) injectNodePoolAffinity(ctx context.Context, pod *corev1.Pod) (bool, error) {
+	ns := corev1.Namespace{}
+	if err := h.Client.Get(ctx, types.NamespacedName{Name: pod.Namespace}, &ns); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	allowed := nodepoolutil.AllowedNodePools(&ns)
+	if len(allowed) == 0 {
+		return false, nil
+	}
+
+	var poolList appsv1alpha1.NodePoolList
+	if err := h.Client.List(ctx, &poolList); err != nil {
+		return false, err
+	}
+	pools := nodepoolutil.ExpandNodePools(allowed, poolList.Items)
+	if !nodepoolutil.InjectNodePoolAffinity(pod, pools) {
+		return false, nil
+	}
+	klog.V(4).Infof("restrict pod(%s/%s) to nodepools %v", pod.Namespace, pod.Name, pools)
+	return true, nil
+}
+
+// podNodePool returns the pool of the pod if it is known at creation, i.e.
+// the pod is pinned to a pool or bound to a node, otherwise it returns nil
+func (h *PodCreateHandler) podNodePool(ctx context.Context, pod *corev1.Pod) (*appsv1alpha1.NodePool, error) {
+	poolName := nodepoolutil.PinnedNodePool(pod)
+	if pod.Spec.NodeName != "" {
+		node := corev1.Node{}
+		if err := h.Client.Get(ctx, types.NamespacedName{Name: pod.Spec.NodeName}, &node); err != nil {
+			return nil, client.IgnoreNotFound(err)
+		}
+		poolName = node.Labels[appsv1alpha1.LabelCurrentNodePool]
+	}
+	if poolName == "" {
+		return nil, nil
+	}
+
+	np := appsv1alpha1.NodePool{}
+	if err := h.Client.Get(ctx, types.NamespacedName{Name: poolName}, &np); err != nil {
+		return nil, client.IgnoreNotFound(err)
+	}
+	return &np, nil
+}
+
+// injectPoolContext sets the pool context on the pod if its pool is known at
+// creation, other pods get the pool context labels from the poolcontext
+// controller once they are bound. The environment variables are only injected
+// along with the labels at creation, as the containers of the other pods may
+// start before the labels are set. It returns true if the pod is changed
+func injectPoolContext(pod *corev1.Pod, np *appsv1alpha1.NodePool) bool {
+	mode := nodepoolutil.PoolContextMode(pod)
+	if mode == "" {
+		return false
+	}
+	if np == nil {
+		if mode == nodepoolutil.PoolContextEnv {
+			klog.V(4).Infof("pool of pod(%s/%s) is unknown at creation, only set the pool context labels",
+				pod.Namespace, pod.Name)
+		}
+		return false
+	}
+
+	changed := nodepoolutil.ApplyPoolContext(pod, np)
+	if mode == nodepoolutil.PoolContextEnv && nodepoolutil.InjectPoolContextEnv(pod) {
+		changed = true
+	}
+	return changed
+}
+
+// rewriteImages rewrites the images of the pod to pull from the image
+// registry mirrors of its pool, including those inherited from the ancestor
+// pools. It returns true if the pod is changed
+func (h *PodCreateHandler) rewriteImages(ctx context.Context, pod *corev1.Pod,
+	np *appsv1alpha1.NodePool) (bool, error) {
+	if np == nil {
+		return false, nil
+	}
+	mirrors := np.Spec.ImageRegistryMirrors
+	if np.Spec.Parent != "" {
+		var poolList appsv1alpha1.NodePoolList
+		if err := h.Client.List(ctx, &poolList); err != nil {
+			return false, err
+		}
+		mirrors = nodepoolutil.InheritAttributes(np, poolList.Items).Spec.ImageRegistryMirrors
+	}
+	if !nodepoolutil.RewritePodImages(pod, mirrors) {
+		return false, nil
+	}
+	klog.V(4).Infof("rewrite images of pod(%s/%s) with the mirrors of nodepool %s",
+		pod.Namespace, pod.Name, np.GetName())
+	return true, nil
+}
+
+var _ admission.DecoderInjector = &PodCreateHandler{}
+
+// InjectDecoder injects the decoder into the PodCreateHandler
+func (h *PodCreateHandler) InjectDecoder(d *admission.Decoder) error {
+	h.Decoder = d
+	return nil
+}
+
+var _ inject.Client = &PodCreateHandler{}
+
+// InjectClient injects the client into the PodCreateHandler
+func (h *PodCreateHandler) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package mutating
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+func TestInjectPoolContext(t *testing.T) {
+	np := &appsv1alpha1.NodePool{
+		ObjectMeta: metav1.ObjectMeta{Name: "hangzhou"},
+		Spec:       appsv1alpha1.NodePoolSpec{Type: appsv1alpha1.Edge},
+	}
+	tests := []struct {
+		name         string
+		mode         string
+		np           *appsv1alpha1.NodePool
+		expectLabels bool
+		expectEnv    bool
+	}{
+		{"no pool context", "", np, false, false},
+		{"labels of known pool", nodepoolutil.PoolContextLabels, np, true, false},
+		{"env of known pool", nodepoolutil.PoolContextEnv, np, true, true},
+		{"labels of unknown pool", nodepoolutil.PoolContextLabels, nil, false, false},
+		{"env of unknown pool", nodepoolutil.PoolContextEnv, nil, false, false},
+	}
+
+	for _, tt := range tests {
+		st := tt
+		tf := func(t *testing.T) {
+			t.Parallel()
+			t.Logf("\tTestCase: %s", st.name)
+			pod := &corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{
+					Annotations: map[string]string{appsv1alpha1.AnnotationInjectPoolContext: st.mode},
+				},
+				Spec: corev1.PodSpec{Containers: []corev1.Container{{Name: "app"}}},
+			}
+			changed := injectPoolContext(pod, st.np)
+			if changed != (st.expectLabels || st.expectEnv) {
+				t.Errorf("expect changed %v, but get %v", st.expectLabels || st.expectEnv, changed)
+			}
+			if get := pod.Labels[appsv1alpha1.LabelCurrentNodePool] == "hangzhou"; get != st.expectLabels {
+				t.Errorf("expect pool labels %v, but get %v", st.expectLabels, pod.Labels)
+			}
+			if get := len(pod.Spec.Containers[0].Env) != 0; get != st.expectEnv {
+				t.Errorf("expect pool env %v, but get %v", st.expectEnv, pod.Spec.Containers[0].Env)
+			}
+		}
+		t.Run(st.name, tf)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	webhookutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/util"
+)
+
+// PodCreateHandler checks the nodepools allowed by the namespace and the
+// NodePool quota when pods are bound to nodes, either by the scheduler through
+// the binding subresource or by creating pods with the nodeName set
+type PodCreateHandler struct {
+	Client client.Client
+
+	// Decoder decodes objects
+	Decoder *admission.Decoder
+}
+
+var _ webhookutil.Handler = &PodCreateHandler{}
+
+func (h *PodCreateHandler) SetOptions(options webhookutil.Options) {
+	return
+}
+
+// Handle handles admission requests.
+func (h *PodCreateHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	if req.AdmissionRequest.Operation != admissionv1.Create {
+		return admission.ValidationResponse(true, "")
+	}
+
+	pod := corev1.Pod{}
+	var nodeName string
+	switch req.AdmissionRequest.SubResource {
+	case "binding":
+		klog.V(4).Info("capture the pod binding request")
+		binding := corev1.Binding{}
+		if err := h.Decoder.Decode(req, &binding); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if err := h.Client.Get(ctx, types.NamespacedName{
+			Namespace: req.AdmissionRequest.Namespace,
+			Name:      req.AdmissionRequest.Name,
+		}, &pod); err != nil {
+			// the pod may not be synced to the cache yet, let it go as the
+			// quota is best effort
+			if apierrors.IsNotFound(err) {
+				return admission.ValidationResponse(true, "")
+			}
+			return admission.Errored(http.StatusInternalServerError, err)
+		}
+		nodeName = binding.Target.Name
+	case "":
+		klog.V(4).Info("capture the pod creation request")
+		if err := h.Decoder.Decode(req, &pod); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if pod.Namespace == "" {
+			pod.Namespace = req.AdmissionRequest.Namespace
+		}
+		nodeName = pod.Spec.NodeName
+	default:
+		return admission.ValidationResponse(true, "")
+	}
+
+	allErrs := validatePodNodePool(ctx, h.Client, &pod, nodeName)
+	if nodeName != "" {
+		allErrs = append(allErrs, validatePodQuota(ctx, h.Client, &pod, nodeName)...)
+	}
+	if len(allErrs) > 0 {
+		return admission.Errored(http.StatusForbidden, allErrs.ToAggregate())
+	}
+	return admission.ValidationResponse(true, "")
+}
+
+var _ admission.DecoderInjector = &PodCreateHandler{}
+
+// InjectDecoder injects the decoder into the PodCreateHandler
+func (h *PodCreateHandler) InjectDecoder(d *admission.Decoder) error {
+	h.Decoder = d
+	return nil
+}
+
+var _ inject.Client = &PodCreateHandler{}
+
+// InjectClient injects the client into the PodCreateHandler
+func (h *PodCreateHandler) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
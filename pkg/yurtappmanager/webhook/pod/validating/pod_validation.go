@@ -0,0 +1,165 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	nodepoolutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/util/nodepool"
+)
+
+var (
+	nodeNamePath     = field.NewPath("spec").Child("nodeName")
+	nodeSelectorPath = field.NewPath("spec").Child("nodeSelector").Key(appsv1alpha1.LabelCurrentNodePool)
+)
+
+// validatePodNodePool rejects the pod if its namespace restricts the pods to
+// some nodepools, and the pod selects another pool through the nodeSelector,
+// or it is bound to a node outside of the allowed pools
+func validatePodNodePool(ctx context.Context, cli client.Client,
+	pod *corev1.Pod, nodeName string) field.ErrorList {
+	if _, exist := pod.Annotations[corev1.MirrorPodAnnotationKey]; exist {
+		return nil
+	}
+
+	ns := corev1.Namespace{}
+	if err := cli.Get(ctx, types.NamespacedName{Name: pod.Namespace}, &ns); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return field.ErrorList{field.InternalError(nodeNamePath,
+			fmt.Errorf("fail to get namespace %s: %v", pod.Namespace, err))}
+	}
+	allowed := nodepoolutil.AllowedNodePools(&ns)
+	if len(allowed) == 0 {
+		return nil
+	}
+
+	var poolList appsv1alpha1.NodePoolList
+	if err := cli.List(ctx, &poolList); err != nil {
+		return field.ErrorList{field.InternalError(nodeNamePath,
+			fmt.Errorf("fail to list nodepools: %v", err))}
+	}
+	pools := sets.NewString(nodepoolutil.ExpandNodePools(allowed, poolList.Items)...)
+
+	allErrs := field.ErrorList{}
+	if pool, exist := pod.Spec.NodeSelector[appsv1alpha1.LabelCurrentNodePool]; exist && !pools.Has(pool) {
+		allErrs = append(allErrs, field.Forbidden(nodeSelectorPath,
+			fmt.Sprintf("namespace %s only allows nodepools %v", pod.Namespace, allowed)))
+	}
+	if nodeName == "" {
+		return allErrs
+	}
+
+	node := corev1.Node{}
+	if err := cli.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return allErrs
+		}
+		return append(allErrs, field.InternalError(nodeNamePath,
+			fmt.Errorf("fail to get node %s: %v", nodeName, err)))
+	}
+	if pool := node.Labels[appsv1alpha1.LabelCurrentNodePool]; !pools.Has(pool) {
+		allErrs = append(allErrs, field.Forbidden(nodeNamePath,
+			fmt.Sprintf("node %s is not in the nodepools %v allowed by namespace %s",
+				nodeName, allowed, pod.Namespace)))
+	}
+	return allErrs
+}
+
+// validatePodQuota rejects the pod if binding it to the node will exceed the
+// quota of the pool that the node belongs to. The usage is calculated from
+// the pods in the cache, so concurrent bindings may slightly exceed the quota
+func validatePodQuota(ctx context.Context, cli client.Client,
+	pod *corev1.Pod, nodeName string) field.ErrorList {
+	if nodepoolutil.IsQuotaExempt(pod) {
+		return nil
+	}
+
+	node := corev1.Node{}
+	if err := cli.Get(ctx, types.NamespacedName{Name: nodeName}, &node); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return field.ErrorList{field.InternalError(nodeNamePath,
+			fmt.Errorf("fail to get node %s: %v", nodeName, err))}
+	}
+	poolName := node.Labels[appsv1alpha1.LabelCurrentNodePool]
+	if poolName == "" {
+		return nil
+	}
+
+	np := appsv1alpha1.NodePool{}
+	if err := cli.Get(ctx, types.NamespacedName{Name: poolName}, &np); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return field.ErrorList{field.InternalError(nodeNamePath,
+			fmt.Errorf("fail to get nodepool %s: %v", poolName, err))}
+	}
+	quota := np.Spec.Quota
+	if quota == nil {
+		return nil
+	}
+
+	pods, err := nodepoolutil.ListPoolPods(ctx, cli, poolName)
+	if err != nil {
+		return field.ErrorList{field.InternalError(nodeNamePath,
+			fmt.Errorf("fail to list pods of nodepool %s: %v", poolName, err))}
+	}
+	// the pod itself is not counted, in case the request is retried
+	for i := range pods {
+		if pods[i].Namespace == pod.Namespace && pods[i].Name == pod.Name {
+			pods = append(pods[:i], pods[i+1:]...)
+			break
+		}
+	}
+	usage := nodepoolutil.CalculateQuotaUsage(quota, pods)
+	delta := nodepoolutil.PodUsage(pod)
+
+	allErrs := field.ErrorList{}
+	if exceeded := nodepoolutil.ExceededResources(quota.Hard, usage.Used, delta); len(exceeded) != 0 {
+		allErrs = append(allErrs, field.Forbidden(nodeNamePath,
+			fmt.Sprintf("exceeded quota of nodepool %s: %v", poolName, exceeded)))
+	}
+	for _, nq := range quota.Namespaces {
+		if nq.Namespace != pod.Namespace {
+			continue
+		}
+		var used corev1.ResourceList
+		for _, ns := range usage.Namespaces {
+			if ns.Namespace == nq.Namespace {
+				used = ns.Used
+			}
+		}
+		if exceeded := nodepoolutil.ExceededResources(nq.Hard, used, delta); len(exceeded) != 0 {
+			allErrs = append(allErrs, field.Forbidden(nodeNamePath,
+				fmt.Sprintf("exceeded quota of namespace %s in nodepool %s: %v",
+					nq.Namespace, poolName, exceeded)))
+		}
+	}
+	return allErrs
+}
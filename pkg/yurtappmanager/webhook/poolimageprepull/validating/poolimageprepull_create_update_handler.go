@@ -0,0 +1,91 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"context"
+	"net/http"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/runtime/inject"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+	webhookutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/util"
+)
+
+// PoolImagePrePullCreateUpdateHandler handles PoolImagePrePull
+type PoolImagePrePullCreateUpdateHandler struct {
+	Client client.Client
+
+	// Decoder decodes objects
+	Decoder *admission.Decoder
+}
+
+var _ webhookutil.Handler = &PoolImagePrePullCreateUpdateHandler{}
+
+func (h *PoolImagePrePullCreateUpdateHandler) SetOptions(options webhookutil.Options) {
+	return
+}
+
+// Handle handles admission requests.
+func (h *PoolImagePrePullCreateUpdateHandler) Handle(ctx context.Context, req admission.Request) admission.Response {
+	pip := appsv1alpha1.PoolImagePrePull{}
+
+	switch req.AdmissionRequest.Operation {
+	case admissionv1.Create:
+		klog.V(4).Info("capture the poolimageprepull creation request")
+		if err := h.Decoder.Decode(req, &pip); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		allErrs := validatePoolImagePrePullName(pip.Name)
+		allErrs = append(allErrs, validatePoolImagePrePullSpec(&pip.Spec)...)
+		if len(allErrs) > 0 {
+			return admission.Errored(http.StatusUnprocessableEntity,
+				allErrs.ToAggregate())
+		}
+	case admissionv1.Update:
+		klog.V(4).Info("capture the poolimageprepull update request")
+		if err := h.Decoder.Decode(req, &pip); err != nil {
+			return admission.Errored(http.StatusBadRequest, err)
+		}
+		if allErrs := validatePoolImagePrePullSpec(&pip.Spec); len(allErrs) > 0 {
+			return admission.Errored(http.StatusUnprocessableEntity,
+				allErrs.ToAggregate())
+		}
+	}
+
+	return admission.ValidationResponse(true, "")
+}
+
+var _ admission.DecoderInjector = &PoolImagePrePullCreateUpdateHandler{}
+
+// InjectDecoder injects the decoder into the PoolImagePrePullCreateUpdateHandler
+func (h *PoolImagePrePullCreateUpdateHandler) InjectDecoder(d *admission.Decoder) error {
+	h.Decoder = d
+	return nil
+}
+
+var _ inject.Client = &PoolImagePrePullCreateUpdateHandler{}
+
+// InjectClient injects the client into the PoolImagePrePullCreateUpdateHandler
+func (h *PoolImagePrePullCreateUpdateHandler) InjectClient(c client.Client) error {
+	h.Client = c
+	return nil
+}
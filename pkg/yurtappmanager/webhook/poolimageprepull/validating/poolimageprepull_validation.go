@@ -0,0 +1,87 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"strings"
+
+	unversionedvalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+// validatePoolImagePrePullName validates the name, which will be used as the
+// value of the label on the pull pods
+func validatePoolImagePrePullName(name string) field.ErrorList {
+	allErrs := field.ErrorList{}
+	for _, msg := range validation.IsValidLabelValue(name) {
+		allErrs = append(allErrs, field.Invalid(
+			field.NewPath("metadata").Child("name"), name, msg))
+	}
+	return allErrs
+}
+
+// validatePoolImagePrePullSpec validates the poolimageprepull spec.
+func validatePoolImagePrePullSpec(spec *appsv1alpha1.PoolImagePrePullSpec) field.ErrorList {
+	allErrs := field.ErrorList{}
+	fldPath := field.NewPath("spec")
+
+	if len(spec.Images) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("images"), ""))
+	}
+	images := sets.NewString()
+	for i, image := range spec.Images {
+		idxPath := fldPath.Child("images").Index(i)
+		switch {
+		case image == "" || strings.TrimSpace(image) != image:
+			allErrs = append(allErrs, field.Invalid(idxPath, image,
+				"must be non-empty and have no leading or trailing whitespace"))
+		case images.Has(image):
+			allErrs = append(allErrs, field.Duplicate(idxPath, image))
+		}
+		images.Insert(image)
+	}
+
+	if len(spec.NodePools) == 0 && spec.NodePoolSelector == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("nodePools"),
+			"either nodePools or nodePoolSelector must be specified"))
+	}
+	for i, name := range spec.NodePools {
+		for _, msg := range validation.IsValidLabelValue(name) {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("nodePools").Index(i), name, msg))
+		}
+	}
+	if spec.NodePoolSelector != nil {
+		allErrs = append(allErrs, unversionedvalidation.ValidateLabelSelector(spec.NodePoolSelector,
+			fldPath.Child("nodePoolSelector"))...)
+	}
+
+	for i, secret := range spec.ImagePullSecrets {
+		if secret.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("imagePullSecrets").Index(i).Child("name"), ""))
+		}
+	}
+
+	if spec.ActiveDeadlineSeconds != nil && *spec.ActiveDeadlineSeconds <= 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("activeDeadlineSeconds"),
+			*spec.ActiveDeadlineSeconds, "must be greater than 0"))
+	}
+	return allErrs
+}
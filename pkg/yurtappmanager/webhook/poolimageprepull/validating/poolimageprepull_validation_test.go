@@ -0,0 +1,126 @@
+/*
+Copyright 2021 The OpenYurt Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package validating
+
+import (
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	appsv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
+func TestValidatePoolImagePrePullSpec(t *testing.T) {
+	deadline := int64(300)
+	zero := int64(0)
+
+	successCases := map[string]appsv1alpha1.PoolImagePrePullSpec{
+		"pools by name": {
+			Images:    []string{"nginx:1.19", "registry.site-a.local:5000/app@sha256:abcd"},
+			NodePools: []string{"hangzhou", "beijing"},
+		},
+		"full spec": {
+			Images: []string{"nginx"},
+			NodePoolSelector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{"example.com/region": "east"},
+			},
+			ImagePullSecrets:      []corev1.LocalObjectReference{{Name: "regcred"}},
+			ActiveDeadlineSeconds: &deadline,
+		},
+	}
+	for name, spec := range successCases {
+		if errs := validatePoolImagePrePullSpec(&spec); len(errs) != 0 {
+			t.Errorf("expected success for %s: %v", name, errs)
+		}
+	}
+
+	errorCases := map[string]struct {
+		spec  appsv1alpha1.PoolImagePrePullSpec
+		field string
+	}{
+		"no image": {
+			appsv1alpha1.PoolImagePrePullSpec{NodePools: []string{"hangzhou"}},
+			"spec.images",
+		},
+		"empty image": {
+			appsv1alpha1.PoolImagePrePullSpec{Images: []string{" "}, NodePools: []string{"hangzhou"}},
+			"spec.images[0]",
+		},
+		"duplicate image": {
+			appsv1alpha1.PoolImagePrePullSpec{Images: []string{"nginx", "nginx"}, NodePools: []string{"hangzhou"}},
+			"spec.images[1]",
+		},
+		"no target pool": {
+			appsv1alpha1.PoolImagePrePullSpec{Images: []string{"nginx"}},
+			"spec.nodePools",
+		},
+		"invalid pool name": {
+			appsv1alpha1.PoolImagePrePullSpec{Images: []string{"nginx"}, NodePools: []string{"a b"}},
+			"spec.nodePools[0]",
+		},
+		"invalid selector": {
+			appsv1alpha1.PoolImagePrePullSpec{
+				Images: []string{"nginx"},
+				NodePoolSelector: &metav1.LabelSelector{
+					MatchExpressions: []metav1.LabelSelectorRequirement{
+						{Key: "example.com/region", Operator: metav1.LabelSelectorOpIn},
+					},
+				},
+			},
+			"spec.nodePoolSelector",
+		},
+		"empty secret name": {
+			appsv1alpha1.PoolImagePrePullSpec{
+				Images:           []string{"nginx"},
+				NodePools:        []string{"hangzhou"},
+				ImagePullSecrets: []corev1.LocalObjectReference{{}},
+			},
+			"spec.imagePullSecrets[0].name",
+		},
+		"zero deadline": {
+			appsv1alpha1.PoolImagePrePullSpec{
+				Images:                []string{"nginx"},
+				NodePools:             []string{"hangzhou"},
+				ActiveDeadlineSeconds: &zero,
+			},
+			"spec.activeDeadlineSeconds",
+		},
+	}
+	for name, tc := range errorCases {
+		errs := validatePoolImagePrePullSpec(&tc.spec)
+		if len(errs) == 0 {
+			t.Errorf("expected failure for %s", name)
+			continue
+		}
+		for _, err := range errs {
+			if !strings.HasPrefix(err.Field, tc.field) {
+				t.Errorf("%s: unexpected error field %s: %v", name, err.Field, err)
+			}
+		}
+	}
+}
+
+func TestValidatePoolImagePrePullName(t *testing.T) {
+	if errs := validatePoolImagePrePullName("warm-up-v2"); len(errs) != 0 {
+		t.Errorf("expected success: %v", errs)
+	}
+	if errs := validatePoolImagePrePullName(strings.Repeat("a", 64)); len(errs) == 0 {
+		t.Errorf("expected failure for the name longer than 63 characters")
+	}
+}
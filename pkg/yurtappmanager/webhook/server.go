@@ -67,17 +67,16 @@ func addHandlers(m map[string]webhookutil.Handler) {
 	}
 }
 
-func SetupWithManager(mgr manager.Manager) error {
+func SetupWithManager(mgr manager.Manager, options webhookutil.Options) error {
 	server := mgr.GetWebhookServer()
 	server.Host = "0.0.0.0"
 	server.Port = webhookutil.GetPort()
 	server.CertDir = webhookutil.GetCertDir()
 
 	// register admission handlers
+	options.Client = mgr.GetClient()
 	for path, handler := range HandlerMap {
-		handler.SetOptions(webhookutil.Options{
-			Client: mgr.GetClient(),
-		})
+		handler.SetOptions(options)
 		server.Register(path, &webhook.Admission{Handler: handler})
 		klog.V(3).Infof("Registered webhook handler %s", path)
 	}
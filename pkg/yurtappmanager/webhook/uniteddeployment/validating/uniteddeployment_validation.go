@@ -28,6 +28,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	unversionedvalidation "k8s.io/apimachinery/pkg/apis/meta/v1/validation"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 	appsvalidation "k8s.io/kubernetes/pkg/apis/apps/validation"
@@ -100,6 +101,157 @@ func validateUnitedDeploymentSpec(c client.Client, spec *unitv1alpha1.UnitedDepl
 
 	}
 
+	allErrs = append(allErrs, validateReplicasDistribution(spec, fldPath)...)
+
+	if spec.UpdateStrategy != nil {
+		allErrs = append(allErrs, validateUpdateStrategy(spec.UpdateStrategy, fldPath.Child("updateStrategy"))...)
+	}
+
+	if spec.FailoverPolicy != nil {
+		allErrs = append(allErrs, validateFailoverPolicy(spec.FailoverPolicy, fldPath.Child("failoverPolicy"))...)
+	}
+
+	return allErrs
+}
+
+// validateReplicasDistribution validates the replicas of the UnitedDeployment
+// and the share of each pool in it
+func validateReplicasDistribution(spec *unitv1alpha1.UnitedDeploymentSpec, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if spec.Replicas != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*spec.Replicas), fldPath.Child("replicas"))...)
+	}
+
+	var capacityMode bool
+	if distribution := spec.ReplicasDistribution; distribution != nil {
+		distributionPath := fldPath.Child("replicasDistribution")
+		switch distribution.Mode {
+		case "", unitv1alpha1.WeightReplicasDistribution:
+		case unitv1alpha1.ReadyNodesReplicasDistribution, unitv1alpha1.AllocatableReplicasDistribution:
+			capacityMode = true
+		default:
+			allErrs = append(allErrs, field.NotSupported(distributionPath.Child("mode"), distribution.Mode,
+				[]string{string(unitv1alpha1.WeightReplicasDistribution), string(unitv1alpha1.ReadyNodesReplicasDistribution),
+					string(unitv1alpha1.AllocatableReplicasDistribution)}))
+		}
+		if distribution.Resource != "" && distribution.Mode != unitv1alpha1.AllocatableReplicasDistribution {
+			allErrs = append(allErrs, field.Forbidden(distributionPath.Child("resource"),
+				fmt.Sprintf("may only be set with the %s mode", unitv1alpha1.AllocatableReplicasDistribution)))
+		}
+		if spec.Replicas == nil {
+			allErrs = append(allErrs, field.Forbidden(distributionPath, "requires the replicas of the UnitedDeployment"))
+		}
+	}
+
+	var percentSum int
+	for i, pool := range spec.Topology.Pools {
+		poolPath := fldPath.Child("topology", "pools").Index(i)
+		if pool.Replicas != nil {
+			allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*pool.Replicas), poolPath.Child("replicas"))...)
+			if pool.Weight != nil {
+				allErrs = append(allErrs, field.Forbidden(poolPath.Child("weight"), "may not be set with replicas"))
+			}
+		}
+		if pool.Weight != nil {
+			if spec.Replicas == nil {
+				allErrs = append(allErrs, field.Forbidden(poolPath.Child("weight"), "requires the replicas of the UnitedDeployment"))
+			}
+			percent, err := intstr.GetValueFromIntOrPercent(pool.Weight, 100, false)
+			if err != nil {
+				allErrs = append(allErrs, field.Invalid(poolPath.Child("weight"), pool.Weight.String(), err.Error()))
+			} else if percent < 0 || (pool.Weight.Type == intstr.String && percent > 100) {
+				allErrs = append(allErrs, field.Invalid(poolPath.Child("weight"), pool.Weight.String(),
+					"must be a non-negative integer or a percentage between 0% and 100%"))
+			} else if pool.Weight.Type == intstr.String {
+				percentSum += percent
+			} else if capacityMode {
+				allErrs = append(allErrs, field.Forbidden(poolPath.Child("weight"),
+					"may only be a percentage when the replicas are distributed by capacity"))
+			}
+		}
+		if pool.MinReplicas != nil {
+			allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*pool.MinReplicas), poolPath.Child("minReplicas"))...)
+		}
+		if pool.MaxReplicas != nil {
+			allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*pool.MaxReplicas), poolPath.Child("maxReplicas"))...)
+			if pool.MinReplicas != nil && *pool.MinReplicas > *pool.MaxReplicas {
+				allErrs = append(allErrs, field.Invalid(poolPath.Child("maxReplicas"), *pool.MaxReplicas,
+					"must be greater than or equal to minReplicas"))
+			}
+		}
+	}
+	if percentSum > 100 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("topology", "pools"), fmt.Sprintf("%d%%", percentSum),
+			"the sum of the percentages of the pools must not be greater than 100%"))
+	}
+
+	return allErrs
+}
+
+// validateUpdateStrategy validates the staged rollout of the UnitedDeployment,
+// a pool may only be listed in one batch
+func validateUpdateStrategy(strategy *unitv1alpha1.UnitedDeploymentUpdateStrategy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	listed := sets.String{}
+	for i, batch := range strategy.Batches {
+		if len(batch.Pools) == 0 {
+			allErrs = append(allErrs, field.Required(fldPath.Child("batches").Index(i).Child("pools"), ""))
+		}
+		for j, name := range batch.Pools {
+			poolPath := fldPath.Child("batches").Index(i).Child("pools").Index(j)
+			if errs := apimachineryvalidation.NameIsDNSLabel(name, false); len(errs) > 0 {
+				allErrs = append(allErrs, field.Invalid(poolPath, name,
+					fmt.Sprintf("invalid pool name %s", strings.Join(errs, ", "))))
+			}
+			if listed.Has(name) {
+				allErrs = append(allErrs, field.Duplicate(poolPath, name))
+			}
+			listed.Insert(name)
+		}
+	}
+
+	if strategy.MaxUnavailablePools != nil && *strategy.MaxUnavailablePools < 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("maxUnavailablePools"), *strategy.MaxUnavailablePools,
+			"must be greater than or equal to 1"))
+	}
+	allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(strategy.BatchIntervalSeconds),
+		fldPath.Child("batchIntervalSeconds"))...)
+
+	return allErrs
+}
+
+// validateFailoverPolicy validates the failover of the UnitedDeployment, the
+// fallback pools may name a parent NodePool of the pools
+func validateFailoverPolicy(policy *unitv1alpha1.UnitedDeploymentFailoverPolicy, fldPath *field.Path) field.ErrorList {
+	allErrs := field.ErrorList{}
+
+	if len(policy.FallbackPools) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("fallbackPools"), ""))
+	}
+	listed := sets.String{}
+	for i, name := range policy.FallbackPools {
+		poolPath := fldPath.Child("fallbackPools").Index(i)
+		if errs := apimachineryvalidation.NameIsDNSLabel(name, false); len(errs) > 0 {
+			allErrs = append(allErrs, field.Invalid(poolPath, name,
+				fmt.Sprintf("invalid pool name %s", strings.Join(errs, ", "))))
+		}
+		if listed.Has(name) {
+			allErrs = append(allErrs, field.Duplicate(poolPath, name))
+		}
+		listed.Insert(name)
+	}
+
+	if policy.PendingTimeoutSeconds != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*policy.PendingTimeoutSeconds),
+			fldPath.Child("pendingTimeoutSeconds"))...)
+	}
+	if policy.HoldDownSeconds != nil {
+		allErrs = append(allErrs, apivalidation.ValidateNonnegativeField(int64(*policy.HoldDownSeconds),
+			fldPath.Child("holdDownSeconds"))...)
+	}
+
 	return allErrs
 }
 
@@ -17,6 +17,16 @@ limitations under the License.
 
 package validating
 
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+
+	unitv1alpha1 "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/apis/apps/v1alpha1"
+)
+
 /*
 import (
 	"strconv"
@@ -760,3 +770,218 @@ func setTestDefault(obj *appsv1alpha1.UnitedDeployment) {
 
 
 */
+
+func TestValidateUpdateStrategy(t *testing.T) {
+	one, zero := int32(1), int32(0)
+	successCases := map[string]unitv1alpha1.UnitedDeploymentUpdateStrategy{
+		"empty strategy": {},
+		"batches": {
+			Batches: []unitv1alpha1.UnitedDeploymentUpdateBatch{
+				{Pools: []string{"pool-a"}},
+				{Pools: []string{"pool-b", "region"}},
+			},
+			MaxUnavailablePools:  &one,
+			BatchIntervalSeconds: 60,
+			Paused:               true,
+		},
+	}
+	for k, v := range successCases {
+		strategy := v
+		t.Run(k, func(t *testing.T) {
+			if errs := validateUpdateStrategy(&strategy, field.NewPath("spec", "updateStrategy")); len(errs) != 0 {
+				t.Errorf("expected success: %v", errs)
+			}
+		})
+	}
+
+	errorCases := map[string]unitv1alpha1.UnitedDeploymentUpdateStrategy{
+		"empty batch": {
+			Batches: []unitv1alpha1.UnitedDeploymentUpdateBatch{{}},
+		},
+		"invalid pool name": {
+			Batches: []unitv1alpha1.UnitedDeploymentUpdateBatch{{Pools: []string{"Pool_A"}}},
+		},
+		"pool in several batches": {
+			Batches: []unitv1alpha1.UnitedDeploymentUpdateBatch{
+				{Pools: []string{"pool-a"}},
+				{Pools: []string{"pool-b", "pool-a"}},
+			},
+		},
+		"zero max unavailable pools": {
+			MaxUnavailablePools: &zero,
+		},
+		"negative batch interval": {
+			BatchIntervalSeconds: -1,
+		},
+	}
+	for k, v := range errorCases {
+		strategy := v
+		t.Run(k, func(t *testing.T) {
+			errs := validateUpdateStrategy(&strategy, field.NewPath("spec", "updateStrategy"))
+			if len(errs) == 0 {
+				t.Errorf("expected failure for %s", k)
+			}
+			for i := range errs {
+				if !strings.HasPrefix(errs[i].Field, "spec.updateStrategy.") {
+					t.Errorf("%s: missing prefix for: %v", k, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateReplicasDistribution(t *testing.T) {
+	one, two, ten, negative := int32(1), int32(2), int32(10), int32(-1)
+	weight, percent, overPercent := intstr.FromInt(2), intstr.FromString("60%"), intstr.FromString("101%")
+	successCases := map[string]unitv1alpha1.UnitedDeploymentSpec{
+		"pools run their own replicas": {
+			Topology: unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{{Name: "a", Replicas: &two}}},
+		},
+		"replicas distributed across pools": {
+			Replicas: &ten,
+			Topology: unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{
+				{Name: "a", Replicas: &two},
+				{Name: "b", Weight: &weight, MinReplicas: &one, MaxReplicas: &two},
+				{Name: "c", Weight: &percent},
+				{Name: "d"},
+			}},
+		},
+		"replicas distributed by capacity": {
+			Replicas: &ten,
+			ReplicasDistribution: &unitv1alpha1.UnitedDeploymentReplicasDistribution{
+				Mode:     unitv1alpha1.AllocatableReplicasDistribution,
+				Resource: "memory",
+			},
+			Topology: unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{
+				{Name: "a", Replicas: &two},
+				{Name: "b", Weight: &percent},
+				{Name: "c"},
+			}},
+		},
+	}
+	for k, v := range successCases {
+		spec := v
+		t.Run(k, func(t *testing.T) {
+			if errs := validateReplicasDistribution(&spec, field.NewPath("spec")); len(errs) != 0 {
+				t.Errorf("expected success: %v", errs)
+			}
+		})
+	}
+
+	errorCases := map[string]unitv1alpha1.UnitedDeploymentSpec{
+		"negative replicas": {
+			Replicas: &negative,
+		},
+		"weight without replicas": {
+			Topology: unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{{Name: "a", Weight: &weight}}},
+		},
+		"weight with fixed replicas": {
+			Replicas: &ten,
+			Topology: unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{{Name: "a", Replicas: &two, Weight: &weight}}},
+		},
+		"percentage over 100%": {
+			Replicas: &ten,
+			Topology: unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{{Name: "a", Weight: &overPercent}}},
+		},
+		"sum of percentages over 100%": {
+			Replicas: &ten,
+			Topology: unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{
+				{Name: "a", Weight: &percent},
+				{Name: "b", Weight: &percent},
+			}},
+		},
+		"unknown distribution mode": {
+			Replicas:             &ten,
+			ReplicasDistribution: &unitv1alpha1.UnitedDeploymentReplicasDistribution{Mode: "Random"},
+		},
+		"distribution without replicas": {
+			ReplicasDistribution: &unitv1alpha1.UnitedDeploymentReplicasDistribution{Mode: "ReadyNodes"},
+		},
+		"resource without allocatable mode": {
+			Replicas: &ten,
+			ReplicasDistribution: &unitv1alpha1.UnitedDeploymentReplicasDistribution{
+				Mode:     unitv1alpha1.ReadyNodesReplicasDistribution,
+				Resource: "cpu",
+			},
+		},
+		"integer weight with capacity distribution": {
+			Replicas:             &ten,
+			ReplicasDistribution: &unitv1alpha1.UnitedDeploymentReplicasDistribution{Mode: "ReadyNodes"},
+			Topology:             unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{{Name: "a", Weight: &weight}}},
+		},
+		"min replicas greater than max replicas": {
+			Replicas: &ten,
+			Topology: unitv1alpha1.Topology{Pools: []unitv1alpha1.Pool{{Name: "a", MinReplicas: &two, MaxReplicas: &one}}},
+		},
+	}
+	for k, v := range errorCases {
+		spec := v
+		t.Run(k, func(t *testing.T) {
+			errs := validateReplicasDistribution(&spec, field.NewPath("spec"))
+			if len(errs) == 0 {
+				t.Errorf("expected failure for %s", k)
+			}
+			for i := range errs {
+				field := errs[i].Field
+				if field != "spec.replicas" && !strings.HasPrefix(field, "spec.topology.pools") &&
+					!strings.HasPrefix(field, "spec.replicasDistribution") {
+					t.Errorf("%s: missing prefix for: %v", k, errs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestValidateFailoverPolicy(t *testing.T) {
+	zero, sixty, negative := int32(0), int32(60), int32(-1)
+	successCases := map[string]unitv1alpha1.UnitedDeploymentFailoverPolicy{
+		"fallback pools": {
+			FallbackPools: []string{"cloud", "pool-a"},
+		},
+		"timeouts": {
+			FallbackPools:         []string{"cloud"},
+			PendingTimeoutSeconds: &zero,
+			HoldDownSeconds:       &sixty,
+		},
+	}
+	for k, v := range successCases {
+		policy := v
+		t.Run(k, func(t *testing.T) {
+			if errs := validateFailoverPolicy(&policy, field.NewPath("spec", "failoverPolicy")); len(errs) != 0 {
+				t.Errorf("expected success: %v", errs)
+			}
+		})
+	}
+
+	errorCases := map[string]unitv1alpha1.UnitedDeploymentFailoverPolicy{
+		"no fallback pool": {},
+		"invalid pool name": {
+			FallbackPools: []string{"Pool_A"},
+		},
+		"duplicated fallback pool": {
+			FallbackPools: []string{"cloud", "cloud"},
+		},
+		"negative pending timeout": {
+			FallbackPools:         []string{"cloud"},
+			PendingTimeoutSeconds: &negative,
+		},
+		"negative hold-down time": {
+			FallbackPools:   []string{"cloud"},
+			HoldDownSeconds: &negative,
+		},
+	}
+	for k, v := range errorCases {
+		policy := v
+		t.Run(k, func(t *testing.T) {
+			errs := validateFailoverPolicy(&policy, field.NewPath("spec", "failoverPolicy"))
+			if len(errs) == 0 {
+				t.Errorf("expected failure for %s", k)
+			}
+			for i := range errs {
+				if !strings.HasPrefix(errs[i].Field, "spec.failoverPolicy.") {
+					t.Errorf("%s: missing prefix for: %v", k, errs[i])
+				}
+			}
+		})
+	}
+}
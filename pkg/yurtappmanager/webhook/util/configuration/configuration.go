@@ -25,7 +25,9 @@ import (
 
 	webhookutil "github.com/openyurtio/yurt-app-manager/pkg/yurtappmanager/webhook/util"
 	"k8s.io/api/admissionregistration/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -33,8 +35,16 @@ import (
 const (
 	mutatingWebhookConfigurationName   = "yurt-app-mutating-webhook-configuration"
 	validatingWebhookConfigurationName = "yurt-app-validating-webhook-configuration"
+
+	// namespaceNameLabel is the label of the namespace name, which is set on
+	// all namespaces since Kubernetes 1.21
+	namespaceNameLabel = "kubernetes.io/metadata.name"
 )
 
+// podWebhookPaths are the paths of the pod webhooks, they fail closed so that
+// the pods can't bypass the checks while the webhook is down
+var podWebhookPaths = sets.NewString("/mutate-core-v1-pod", "/validate-core-v1-pod")
+
 func Ensure(c client.Client, handlers map[string]webhookutil.Handler, caBundle []byte) error {
 	mutatingConfig := &v1beta1.MutatingWebhookConfiguration{}
 	if err := c.Get(context.TODO(), types.NamespacedName{Name: mutatingWebhookConfigurationName}, mutatingConfig); err != nil {
@@ -73,6 +83,11 @@ func Ensure(c client.Client, handlers map[string]webhookutil.Handler, caBundle [
 		if host := webhookutil.GetHost(); len(host) > 0 && wh.ClientConfig.Service != nil {
 			convertClientConfig(&wh.ClientConfig, host, webhookutil.GetPort())
 		}
+		if podWebhookPaths.Has(path) {
+			failurePolicy := v1beta1.Fail
+			wh.FailurePolicy = &failurePolicy
+			wh.NamespaceSelector = podWebhookNamespaceSelector()
+		}
 		mutatingWHs = append(mutatingWHs, *wh)
 	}
 	mutatingConfig.Webhooks = mutatingWHs
@@ -96,6 +111,11 @@ func Ensure(c client.Client, handlers map[string]webhookutil.Handler, caBundle [
 		if host := webhookutil.GetHost(); len(host) > 0 && wh.ClientConfig.Service != nil {
 			convertClientConfig(&wh.ClientConfig, host, webhookutil.GetPort())
 		}
+		if podWebhookPaths.Has(path) {
+			failurePolicy := v1beta1.Fail
+			wh.FailurePolicy = &failurePolicy
+			wh.NamespaceSelector = podWebhookNamespaceSelector()
+		}
 		validatingWHs = append(validatingWHs, *wh)
 	}
 	validatingConfig.Webhooks = validatingWHs
@@ -110,6 +130,19 @@ func Ensure(c client.Client, handlers map[string]webhookutil.Handler, caBundle [
 	return nil
 }
 
+// podWebhookNamespaceSelector selects the namespaces of the pods checked by the
+// pod webhooks, the pods of kube-system and yurt-app-manager itself are not
+// handled, so that they can be created while the webhook is down
+func podWebhookNamespaceSelector() *metav1.LabelSelector {
+	return &metav1.LabelSelector{
+		MatchExpressions: []metav1.LabelSelectorRequirement{{
+			Key:      namespaceNameLabel,
+			Operator: metav1.LabelSelectorOpNotIn,
+			Values:   sets.NewString(metav1.NamespaceSystem, webhookutil.GetNamespace()).List(),
+		}},
+	}
+}
+
 func getPath(clientConfig *v1beta1.WebhookClientConfig) (string, error) {
 	if clientConfig.Service != nil {
 		return *clientConfig.Service.Path, nil
@@ -23,6 +23,14 @@ import (
 
 type Options struct {
 	Client client.Client
+
+	// NodePoolChangeProtection rejects changing the desired nodepool of nodes
+	// that still run pods of pool-scoped workloads
+	NodePoolChangeProtection bool
+
+	// DefaultNodePoolRules decide the desired nodepool of new nodes, each rule
+	// is in the format of `<label-key>=<label-value>:<nodepool>`
+	DefaultNodePoolRules []string
 }
 type Handler interface {
 	admission.Handler